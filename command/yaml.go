@@ -0,0 +1,130 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sojson/service"
+	"sojson/zlog"
+
+	"github.com/urfave/cli/v2"
+)
+
+// yamlFlags YAML子命令共用的参数
+var yamlFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name:    "indent",
+		Aliases: []string{"i"},
+		Value:   2,
+		Usage:   "缩进空格数，0 表示压缩（仅输出JSON时）",
+	},
+	&cli.StringFlag{
+		Name:    "documents",
+		Aliases: []string{"d"},
+		Usage:   "多文档的组织方式：auto、array、ndjson",
+	},
+	&cli.BoolFlag{
+		Name:  "sort-keys",
+		Usage: "递归地按键排序",
+	},
+}
+
+// YAMLCommand JSON 和 YAML 互相转换的子命令
+var YAMLCommand = &cli.Command{
+	Name:   "yaml",
+	Usage:  "JSON 和 YAML 互相转换，从文件或标准输入读取，结果写到标准输出",
+	Before: initCLI,
+	Subcommands: []*cli.Command{
+		{
+			Name:      "to-json",
+			Usage:     "YAML 转换为 JSON，锚点、标签等的处理说明输出到标准错误",
+			ArgsUsage: "[文件]",
+			Flags:     yamlFlags,
+			Action: func(c *cli.Context) error {
+				return runYAML(c, service.JSONProcessorService.YAMLToJSON)
+			},
+		},
+		{
+			Name:      "from-json",
+			Usage:     "JSON 转换为 YAML",
+			ArgsUsage: "[文件]",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:    "style",
+					Aliases: []string{"s"},
+					Usage:   "YAML 样式：block、flow、compact",
+				},
+				&cli.StringFlag{
+					Name:  "dialect",
+					Usage: "输入的方言：json、jsonc、json5",
+				},
+			}, yamlFlags...),
+			Action: func(c *cli.Context) error {
+				return runYAML(c, service.JSONProcessorService.JSONToYAML)
+			},
+		},
+	},
+}
+
+// initCLI 命令行工具的错误直接输出到标准错误，服务层的日志只保留致命错误，且不占用标准输出
+func initCLI(c *cli.Context) error {
+	if err := zlog.InitLogger("fatal", ""); err != nil {
+		return fmt.Errorf("初始化日志失败: %v", err)
+	}
+	zlog.Logger.SetOutput(os.Stderr)
+	return nil
+}
+
+// runYAML 读取输入，按参数调用 convert，把结果写到标准输出
+func runYAML(c *cli.Context, convert func(ctx context.Context, text string, opts service.YAMLOptions) (*service.ConversionResult, error)) error {
+	text, err := readInput(c)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	opts := service.YAMLOptions{FormatOptions: service.FormatOptions{
+		Indent:          c.Int("indent"),
+		TrailingNewline: true,
+		Dialect:         service.Dialect(strings.ToLower(c.String("dialect"))),
+	}}
+	if c.Bool("sort-keys") {
+		opts.KeyOrder = service.KeyOrderLexical
+	}
+	if opts.Documents, err = service.ParseDocumentLayout(c.String("documents")); err != nil {
+		return cli.Exit(err.Error(), 2)
+	}
+	if opts.Style, err = service.ParseYAMLStyle(c.String("style")); err != nil {
+		return cli.Exit(err.Error(), 2)
+	}
+
+	result, err := convert(c.Context, text, opts)
+	if err != nil {
+		return cli.Exit("转换失败: "+err.Error(), 1)
+	}
+	for _, n := range result.Notes {
+		fmt.Fprintf(c.App.ErrWriter, "第 %d 行第 %d 列: %s\n", n.Line, n.Column, n.Message)
+	}
+	_, err = io.WriteString(c.App.Writer, result.Result)
+	return err
+}
+
+// readInput 读取第一个参数指定的文件，没有参数或参数为 - 时读取标准输入
+func readInput(c *cli.Context) (string, error) {
+	name := c.Args().First()
+	if name == "" || name == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("读取标准输入失败: %v", err)
+		}
+		return string(data), nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %v", err)
+	}
+	return string(data), nil
+}
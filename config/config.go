@@ -0,0 +1,47 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config 服务器配置
+type Config struct {
+	// QuoteRules 裸值加引号的默认规则，未配置时使用内置规则
+	QuoteRules []QuoteRule `json:"quote_rules,omitempty"`
+	// SchemaDir schema 注册表的存储目录，默认为当前目录下的 schemas
+	SchemaDir string `json:"schema_dir,omitempty"`
+	// SchemaCompatibility 主题没有单独设置时的兼容性要求，默认为 BACKWARD
+	SchemaCompatibility string `json:"schema_compatibility,omitempty"`
+}
+
+// QuoteRule 裸值加引号规则，字段含义与 /api/process 请求中的 quote_rules 相同
+type QuoteRule struct {
+	Name  string `json:"name"`
+	Key   string `json:"key,omitempty"` // 键的正则表达式，为空时匹配任意键
+	Value string `json:"value"`         // 内置值类型名称，或匹配整个值的正则表达式
+}
+
+var current = &Config{}
+
+// Load 从 JSON 文件加载配置
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %v", path, err)
+	}
+
+	current = cfg
+	return cfg, nil
+}
+
+// Get 获取当前配置
+func Get() *Config {
+	return current
+}
@@ -1,7 +1,11 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"sojson/dto"
 	"sojson/service"
@@ -28,11 +32,56 @@ func (ctrl *jsonController) UnescapeJSON(c *gin.Context) {
 		return
 	}
 
+	if processLines(c, req.Mode, req.Text, formatOptions(req.FormatSettings), func(line string, _ service.FormatOptions) (string, error) {
+		return service.JSONProcessorService.UnescapeJSON(line)
+	}) {
+		return
+	}
+
 	result, err := service.JSONProcessorService.UnescapeJSON(req.Text)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.JSONResponse{
 			Success: false,
 			Error:   "去除转义失败: " + err.Error(),
+			Detail:  errorDetail(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.JSONResponse{
+		Result:  result,
+		Success: true,
+	})
+}
+
+// EscapeJSON 添加转义接口
+func (ctrl *jsonController) EscapeJSON(c *gin.Context) {
+	var req dto.EscapeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+
+	opts := service.EscapeOptions{
+		Level:     req.Level,
+		ASCIIOnly: req.ASCIIOnly,
+		HTMLSafe:  req.HTMLSafe,
+		Minify:    req.Minify,
+	}
+	if processLines(c, req.Mode, req.Text, service.FormatOptions{}, func(line string, _ service.FormatOptions) (string, error) {
+		return service.JSONProcessorService.EscapeJSON(c.Request.Context(), line, opts)
+	}) {
+		return
+	}
+
+	result, err := service.JSONProcessorService.EscapeJSON(c.Request.Context(), req.Text, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "添加转义失败: " + err.Error(),
 		})
 		return
 	}
@@ -54,16 +103,18 @@ func (ctrl *jsonController) FormatJSON(c *gin.Context) {
 		return
 	}
 
-	indent := req.Indent
-	if indent == 0 {
-		indent = 2
+	if processLines(c, req.Mode, req.Text, formatOptions(req.FormatSettings), func(line string, opts service.FormatOptions) (string, error) {
+		return service.JSONProcessorService.FormatJSONWithOptions(c.Request.Context(), line, opts)
+	}) {
+		return
 	}
 
-	result, err := service.JSONProcessorService.FormatJSON(c.Request.Context(), req.Text, indent)
+	result, err := service.JSONProcessorService.FormatJSONWithOptions(c.Request.Context(), req.Text, formatOptions(req.FormatSettings))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.JSONResponse{
 			Success: false,
 			Error:   "JSON格式错误: " + err.Error(),
+			Detail:  errorDetail(err),
 		})
 		return
 	}
@@ -85,16 +136,79 @@ func (ctrl *jsonController) ProcessJSON(c *gin.Context) {
 		return
 	}
 
-	indent := req.Indent
-	if indent == 0 {
-		indent = 2
+	mongo, err := service.ParseMongoMode(req.Mongo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.DeepExpand && req.Repair {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "deep_expand 和 repair 不能同时使用",
+		})
+		return
+	}
+
+	opts := service.ProcessOptions{
+		FormatOptions: formatOptions(req.FormatSettings),
+		QuoteRules:    quoteRules(req.QuoteRules),
+		Mongo:         mongo,
 	}
 
-	result, err := service.JSONProcessorService.ProcessJSON(c.Request.Context(), req.Text, indent)
+	if processLines(c, req.Mode, req.Text, opts.FormatOptions, func(line string, lineOpts service.FormatOptions) (string, error) {
+		return processLine(c, req, line, service.ProcessOptions{FormatOptions: lineOpts, QuoteRules: opts.QuoteRules, Mongo: opts.Mongo})
+	}) {
+		return
+	}
+
+	if req.DeepExpand {
+		expanded, err := service.JSONProcessorService.DeepExpandJSON(c.Request.Context(), req.Text, opts)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.JSONResponse{
+				Success: false,
+				Error:   err.Error(),
+				Detail:  errorDetail(err),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.JSONResponse{
+			Result:        expanded.Result,
+			Success:       true,
+			Layers:        expanded.Layers,
+			ExpandedPaths: expanded.ExpandedPaths,
+		})
+		return
+	}
+
+	if req.Repair {
+		repaired, err := service.JSONProcessorService.ProcessJSONWithRepair(c.Request.Context(), req.Text, opts)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.JSONResponse{
+				Success: false,
+				Error:   err.Error(),
+				Detail:  errorDetail(err),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.JSONResponse{
+			Result:  repaired.Result,
+			Success: true,
+			Fixes:   repairFixes(repaired.Fixes),
+		})
+		return
+	}
+
+	result, err := service.JSONProcessorService.ProcessJSONWithOptions(c.Request.Context(), req.Text, opts)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.JSONResponse{
 			Success: false,
 			Error:   err.Error(),
+			Detail:  errorDetail(err),
 		})
 		return
 	}
@@ -105,30 +219,935 @@ func (ctrl *jsonController) ProcessJSON(c *gin.Context) {
 	})
 }
 
-// ValidateJSON 验证JSON接口
-func (ctrl *jsonController) ValidateJSON(c *gin.Context) {
+// RepairJSON 修复JSON接口
+func (ctrl *jsonController) RepairJSON(c *gin.Context) {
+	var req dto.JSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+
+	if processLines(c, req.Mode, req.Text, formatOptions(req.FormatSettings), func(line string, opts service.FormatOptions) (string, error) {
+		result, err := service.JSONProcessorService.RepairJSON(c.Request.Context(), line, opts)
+		if err != nil {
+			return "", err
+		}
+		return result.Result, nil
+	}) {
+		return
+	}
+
+	result, err := service.JSONProcessorService.RepairJSON(c.Request.Context(), req.Text, formatOptions(req.FormatSettings))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "无法修复JSON: " + err.Error(),
+			Detail:  errorDetail(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.JSONResponse{
+		Result:  result.Result,
+		Success: true,
+		Fixes:   repairFixes(result.Fixes),
+	})
+}
 
+// ConvertJSON 把其他语言打印出的字面量转换为JSON接口
+func (ctrl *jsonController) ConvertJSON(c *gin.Context) {
 	var req dto.JSONRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ValidateResponse{
-			Valid: false,
-			Error: "请提供要验证的文本",
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
 		})
 		return
 	}
 
-	if err := service.JSONProcessorService.ValidateJSON(req.Text); err != nil {
-		response := dto.ValidateResponse{
-			Valid: false,
-			Error: err.Error(),
+	lang, err := service.ParseSourceLanguage(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if processLines(c, req.Mode, req.Text, formatOptions(req.FormatSettings), func(line string, opts service.FormatOptions) (string, error) {
+		result, err := service.JSONProcessorService.ConvertToJSON(c.Request.Context(), line, lang, opts)
+		if err != nil {
+			return "", err
 		}
-		c.JSON(http.StatusOK, response)
+		return result.Result, nil
+	}) {
 		return
 	}
 
-	response := dto.ValidateResponse{
-		Valid:   true,
-		Message: "JSON格式正确",
+	result, err := service.JSONProcessorService.ConvertToJSON(c.Request.Context(), req.Text, lang, formatOptions(req.FormatSettings))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "无法转换为JSON: " + err.Error(),
+			Detail:  errorDetail(err),
+		})
+		return
 	}
-	c.JSON(http.StatusOK, response)
+
+	c.JSON(http.StatusOK, dto.JSONResponse{
+		Result:   result.Result,
+		Success:  true,
+		Language: string(result.Language),
+	})
+}
+
+// ExtractJSON 从日志等文本中提取JSON片段接口
+func (ctrl *jsonController) ExtractJSON(c *gin.Context) {
+	var req dto.JSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ExtractResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+
+	// 提取本身就逐段扫描整个文本，按行处理没有意义
+	if err := documentMode(req.Mode, "提取JSON"); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ExtractResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := service.JSONProcessorService.ExtractJSON(c.Request.Context(), req.Text, req.Inline, formatOptions(req.FormatSettings))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ExtractResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	fragments := make([]dto.ExtractFragment, 0, len(result.Fragments))
+	for _, f := range result.Fragments {
+		fragments = append(fragments, dto.ExtractFragment{
+			Start:   f.Offset,
+			End:     f.End,
+			Line:    f.Line,
+			Column:  f.Column,
+			Escaped: f.Escaped,
+			Result:  f.Result,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.ExtractResponse{
+		Result:    result.Result,
+		Fragments: fragments,
+		Success:   true,
+	})
+}
+
+// YAMLToJSON YAML转换为JSON接口
+func (ctrl *jsonController) YAMLToJSON(c *gin.Context) {
+	ctrl.convertYAML(c, service.JSONProcessorService.YAMLToJSON)
+}
+
+// JSONToYAML JSON转换为YAML接口
+func (ctrl *jsonController) JSONToYAML(c *gin.Context) {
+	ctrl.convertYAML(c, service.JSONProcessorService.JSONToYAML)
+}
+
+// convertYAML 解析YAML转换选项并调用 convert
+func (ctrl *jsonController) convertYAML(c *gin.Context, convert func(ctx context.Context, text string, opts service.YAMLOptions) (*service.ConversionResult, error)) {
+	var req dto.JSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+	if err := documentMode(req.Mode, "YAML转换"); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	opts := service.YAMLOptions{FormatOptions: formatOptions(req.FormatSettings)}
+	var err error
+	if opts.Documents, err = service.ParseDocumentLayout(req.Documents); err == nil {
+		opts.Style, err = service.ParseYAMLStyle(req.YAMLStyle)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := convert(c.Request.Context(), req.Text, opts)
+	conversionResponse(c, result, err)
+}
+
+// TOMLToJSON TOML转换为JSON接口
+func (ctrl *jsonController) TOMLToJSON(c *gin.Context) {
+	ctrl.convertTOML(c, service.JSONProcessorService.TOMLToJSON)
+}
+
+// JSONToTOML JSON转换为TOML接口
+func (ctrl *jsonController) JSONToTOML(c *gin.Context) {
+	ctrl.convertTOML(c, service.JSONProcessorService.JSONToTOML)
+}
+
+// convertTOML 按请求中的格式化设置调用 convert
+func (ctrl *jsonController) convertTOML(c *gin.Context, convert func(ctx context.Context, text string, opts service.FormatOptions) (*service.ConversionResult, error)) {
+	var req dto.JSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+	if err := documentMode(req.Mode, "TOML转换"); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := convert(c.Request.Context(), req.Text, formatOptions(req.FormatSettings))
+	conversionResponse(c, result, err)
+}
+
+// XMLToJSON XML转换为JSON接口
+func (ctrl *jsonController) XMLToJSON(c *gin.Context) {
+	ctrl.convertXML(c, service.JSONProcessorService.XMLToJSON)
+}
+
+// JSONToXML JSON转换为XML接口
+func (ctrl *jsonController) JSONToXML(c *gin.Context) {
+	ctrl.convertXML(c, service.JSONProcessorService.JSONToXML)
+}
+
+// convertXML 解析XML转换选项并调用 convert
+func (ctrl *jsonController) convertXML(c *gin.Context, convert func(ctx context.Context, text string, opts service.XMLOptions) (*service.ConversionResult, error)) {
+	var req dto.JSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+	if err := documentMode(req.Mode, "XML转换"); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	convention, err := service.ParseXMLConvention(req.XMLConvention)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	opts := service.XMLOptions{
+		FormatOptions:   formatOptions(req.FormatSettings),
+		Convention:      convention,
+		ForceArrays:     req.ForceArrays,
+		StripNamespaces: req.StripNamespaces,
+		Root:            req.Root,
+	}
+	result, err := convert(c.Request.Context(), req.Text, opts)
+	conversionResponse(c, result, err)
+}
+
+// CSVToJSON CSV/TSV转换为JSON接口
+func (ctrl *jsonController) CSVToJSON(c *gin.Context) {
+	ctrl.convertCSV(c, service.JSONProcessorService.CSVToJSON)
+}
+
+// JSONToCSV JSON转换为CSV/TSV接口
+func (ctrl *jsonController) JSONToCSV(c *gin.Context) {
+	ctrl.convertCSV(c, service.JSONProcessorService.JSONToCSV)
+}
+
+// convertCSV 解析CSV转换选项并调用 convert
+func (ctrl *jsonController) convertCSV(c *gin.Context, convert func(ctx context.Context, text string, opts service.CSVOptions) (*service.ConversionResult, error)) {
+	var req dto.JSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+	if err := documentMode(req.Mode, "CSV转换"); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	opts := service.CSVOptions{
+		FormatOptions: formatOptions(req.FormatSettings),
+		Pointer:       req.Pointer,
+		Separator:     req.ArraySeparator,
+		RawStrings:    req.RawStrings,
+		BOM:           req.BOM,
+		KeepFormulas:  req.KeepFormulas,
+	}
+	var err error
+	if opts.Format, err = service.ParseCSVFormat(req.CSVFormat); err == nil {
+		opts.Arrays, err = service.ParseArrayEncoding(req.ArrayEncoding)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := convert(c.Request.Context(), req.Text, opts)
+	conversionResponse(c, result, err)
+}
+
+// Generate 从JSON样本生成目标语言的模型代码接口，语言由路径参数 lang 指定
+func (ctrl *jsonController) Generate(c *gin.Context) {
+	var req dto.CodegenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+
+	lang, err := service.ParseCodegenLanguage(c.Param("lang"))
+	var mode service.InputMode
+	if err == nil {
+		mode, err = service.ParseInputMode(req.Mode)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := service.JSONProcessorService.Generate(c.Request.Context(), lang, req.Text, service.CodegenOptions{
+		Dialect:         service.Dialect(strings.ToLower(req.Dialect)),
+		Mode:            mode,
+		TypeName:        req.TypeName,
+		Package:         req.Package,
+		Inline:          req.InlineTypes,
+		Framework:       strings.ToLower(req.Framework),
+		PointerNullable: req.PointerNullable,
+		Declaration:     strings.ToLower(req.Declaration),
+		Zod:             req.Zod,
+		EnumLimit:       req.EnumLimit,
+	})
+	conversionResponse(c, result, err)
+}
+
+// GoExample 由Go结构体定义生成示例JSON接口
+func (ctrl *jsonController) GoExample(c *gin.Context) {
+	var req dto.ExampleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+	if err := documentMode(req.Mode, "生成示例JSON"); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := service.JSONProcessorService.GoExample(c.Request.Context(), req.Text, service.ExampleOptions{
+		FormatOptions: formatOptions(req.FormatSettings),
+		TypeName:      req.TypeName,
+		OmitEmpty:     req.OmitEmpty,
+	})
+	conversionResponse(c, result, err)
+}
+
+// InferSchema 从JSON样本推断 JSON Schema 接口
+func (ctrl *jsonController) InferSchema(c *gin.Context) {
+	var req dto.InferSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "请提供要处理的文本",
+		})
+		return
+	}
+
+	mode, err := service.ParseInputMode(req.Mode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := service.JSONProcessorService.InferSchema(c.Request.Context(), req.Text, service.SchemaOptions{
+		FormatOptions: formatOptions(req.FormatSettings),
+		Mode:          mode,
+		EnumLimit:     req.EnumLimit,
+		Strict:        req.Strict,
+	})
+	conversionResponse(c, result, err)
+}
+
+// ValidateSchema 按 JSON Schema 校验JSON接口，返回所有不符合的位置
+func (ctrl *jsonController) ValidateSchema(c *gin.Context) {
+	var req dto.ValidateSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Schema == "" && req.Subject == "") {
+		c.JSON(http.StatusBadRequest, dto.ValidateResponse{
+			Valid: false,
+			Error: "请提供要校验的文本和 JSON Schema 或注册表中的主题",
+		})
+		return
+	}
+	if err := documentMode(req.Mode, "按 JSON Schema 校验"); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ValidateResponse{
+			Valid: false,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	schema := req.Schema
+	if schema == "" {
+		version, err := service.JSONProcessorService.GetSchema(c.Request.Context(), req.Subject, req.Version)
+		if err != nil {
+			c.JSON(registryStatus(err), dto.ValidateResponse{
+				Valid: false,
+				Error: err.Error(),
+			})
+			return
+		}
+		schema = version.Schema
+	}
+
+	dialect := service.Dialect(strings.ToLower(req.Dialect))
+	violations, err := service.JSONProcessorService.ValidateSchema(c.Request.Context(), req.Text, schema, dialect)
+	if err != nil {
+		if detail := errorDetail(err); detail != nil {
+			// 文档本身的语法错误
+			c.JSON(http.StatusOK, dto.ValidateResponse{
+				Valid:  false,
+				Error:  err.Error(),
+				Detail: detail,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.ValidateResponse{
+			Valid: false,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	if len(violations) == 0 {
+		c.JSON(http.StatusOK, dto.ValidateResponse{
+			Valid:   true,
+			Message: "符合 JSON Schema",
+		})
+		return
+	}
+
+	items := make([]dto.Violation, 0, len(violations))
+	for _, v := range violations {
+		items = append(items, dto.Violation{
+			InstancePath: v.InstancePath,
+			KeywordPath:  v.KeywordPath,
+			Message:      v.Message,
+			Offset:       v.Offset,
+			Line:         v.Line,
+			Column:       v.Column,
+		})
+	}
+	c.JSON(http.StatusOK, dto.ValidateResponse{
+		Valid:      false,
+		Error:      fmt.Sprintf("共 %d 处不符合 JSON Schema", len(violations)),
+		Violations: items,
+	})
+}
+
+// ListSchemaSubjects 列出注册表中的所有主题接口
+func (ctrl *jsonController) ListSchemaSubjects(c *gin.Context) {
+	subjects, err := service.JSONProcessorService.SchemaSubjects(c.Request.Context())
+	if err != nil {
+		registryErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SchemaSubjectsResponse{
+		Success:  true,
+		Subjects: subjects,
+	})
+}
+
+// ListSchemaVersions 列出主题的所有版本接口
+func (ctrl *jsonController) ListSchemaVersions(c *gin.Context) {
+	subject := c.Param("subject")
+	versions, err := service.JSONProcessorService.SchemaVersions(c.Request.Context(), subject)
+	if err != nil {
+		registryErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SchemaRegistryResponse{
+		Success:  true,
+		Subject:  subject,
+		Versions: versions,
+	})
+}
+
+// GetSchemaVersion 获取主题的一个版本接口，版本为版本号或 latest
+func (ctrl *jsonController) GetSchemaVersion(c *gin.Context) {
+	version, err := service.JSONProcessorService.GetSchema(c.Request.Context(), c.Param("subject"), c.Param("version"))
+	if err != nil {
+		registryErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SchemaRegistryResponse{
+		Success: true,
+		Subject: version.Subject,
+		Version: version.Version,
+		Schema:  version.Schema,
+	})
+}
+
+// RegisterSchema 把 schema 注册为主题的新版本接口，不满足兼容性要求时返回 409
+func (ctrl *jsonController) RegisterSchema(c *gin.Context) {
+	var req dto.SchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.SchemaRegistryResponse{
+			Success: false,
+			Error:   "请提供要注册的 JSON Schema",
+		})
+		return
+	}
+
+	version, created, err := service.JSONProcessorService.RegisterSchema(c.Request.Context(), c.Param("subject"), req.Schema)
+	if err != nil {
+		registryErrorResponse(c, err)
+		return
+	}
+
+	message := fmt.Sprintf("已注册为版本 %d", version.Version)
+	if !created {
+		message = fmt.Sprintf("与已注册的版本 %d 相同，未创建新版本", version.Version)
+	}
+	c.JSON(http.StatusOK, dto.SchemaRegistryResponse{
+		Success: true,
+		Message: message,
+		Subject: version.Subject,
+		Version: version.Version,
+	})
+}
+
+// CheckSchemaCompatibility 检查 schema 能否注册为主题的新版本接口，不写入注册表
+func (ctrl *jsonController) CheckSchemaCompatibility(c *gin.Context) {
+	var req dto.SchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.SchemaRegistryResponse{
+			Success: false,
+			Error:   "请提供要检查的 JSON Schema",
+		})
+		return
+	}
+
+	subject := c.Param("subject")
+	level, problems, err := service.JSONProcessorService.CheckSchemaCompatibility(c.Request.Context(), subject, req.Schema)
+	if err != nil {
+		registryErrorResponse(c, err)
+		return
+	}
+	compatible := len(problems) == 0
+	c.JSON(http.StatusOK, dto.SchemaRegistryResponse{
+		Success:           true,
+		Subject:           subject,
+		Compatibility:     string(level),
+		IsCompatible:      &compatible,
+		Incompatibilities: incompatibilities(problems),
+	})
+}
+
+// GetSubjectConfig 获取主题的兼容性要求接口
+func (ctrl *jsonController) GetSubjectConfig(c *gin.Context) {
+	subject := c.Param("subject")
+	level, err := service.JSONProcessorService.SubjectCompatibility(c.Request.Context(), subject)
+	if err != nil {
+		registryErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SchemaRegistryResponse{
+		Success:       true,
+		Subject:       subject,
+		Compatibility: string(level),
+	})
+}
+
+// SetSubjectConfig 设置主题的兼容性要求接口
+func (ctrl *jsonController) SetSubjectConfig(c *gin.Context) {
+	var req dto.CompatibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.SchemaRegistryResponse{
+			Success: false,
+			Error:   "请提供兼容性要求",
+		})
+		return
+	}
+
+	subject := c.Param("subject")
+	level, err := service.JSONProcessorService.SetSubjectCompatibility(c.Request.Context(), subject, req.Compatibility)
+	if err != nil {
+		registryErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SchemaRegistryResponse{
+		Success:       true,
+		Subject:       subject,
+		Compatibility: string(level),
+	})
+}
+
+// registryErrorResponse 返回注册表的错误，不兼容时列出所有不兼容的位置
+func registryErrorResponse(c *gin.Context, err error) {
+	response := dto.SchemaRegistryResponse{
+		Success: false,
+		Error:   err.Error(),
+	}
+	var incompatible *service.IncompatibleSchemaError
+	if errors.As(err, &incompatible) {
+		response.Compatibility = string(incompatible.Level)
+		response.Incompatibilities = incompatibilities(incompatible.Incompatibilities)
+	}
+	c.JSON(registryStatus(err), response)
+}
+
+// registryStatus 注册表错误对应的状态码：主题或版本不存在为 404，不满足兼容性要求为 409
+func registryStatus(err error) int {
+	var notFound *service.SchemaNotFoundError
+	var incompatible *service.IncompatibleSchemaError
+	switch {
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &incompatible):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func incompatibilities(problems []service.SchemaIncompatibility) []dto.Incompatibility {
+	items := make([]dto.Incompatibility, 0, len(problems))
+	for _, p := range problems {
+		items = append(items, dto.Incompatibility{
+			Version:     p.Version,
+			Direction:   p.Direction,
+			KeywordPath: p.KeywordPath,
+			Message:     p.Message,
+		})
+	}
+	return items
+}
+
+// conversionResponse 返回格式转换的结果和说明，失败时返回错误及其位置
+func conversionResponse(c *gin.Context, result *service.ConversionResult, err error) {
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   "转换失败: " + err.Error(),
+			Detail:  errorDetail(err),
+		})
+		return
+	}
+
+	notes := make([]dto.Note, 0, len(result.Notes))
+	for _, n := range result.Notes {
+		notes = append(notes, dto.Note{Line: n.Line, Column: n.Column, Message: n.Message})
+	}
+	c.JSON(http.StatusOK, dto.JSONResponse{
+		Result:    result.Result,
+		Success:   true,
+		Documents: result.Documents,
+		Notes:     notes,
+	})
+}
+
+// ValidateJSON 验证JSON接口
+func (ctrl *jsonController) ValidateJSON(c *gin.Context) {
+
+	var req dto.JSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ValidateResponse{
+			Valid: false,
+			Error: "请提供要验证的文本",
+		})
+		return
+	}
+
+	dialect := service.Dialect(strings.ToLower(req.Dialect))
+	mode, err := service.ParseInputMode(req.Mode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ValidateResponse{
+			Valid: false,
+			Error: err.Error(),
+		})
+		return
+	}
+	if mode != service.ModeDocument {
+		ctrl.validateLines(c, req.Text, mode, dialect)
+		return
+	}
+
+	if err := service.JSONProcessorService.ValidateJSONWithDialect(req.Text, dialect); err != nil {
+		response := dto.ValidateResponse{
+			Valid:  false,
+			Error:  err.Error(),
+			Detail: errorDetail(err),
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	response := dto.ValidateResponse{
+		Valid:   true,
+		Message: "JSON格式正确",
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// validateLines 逐行验证 NDJSON，或逐个验证数组的元素
+func (ctrl *jsonController) validateLines(c *gin.Context, text string, mode service.InputMode, dialect service.Dialect) {
+	result, err := service.JSONProcessorService.ProcessLines(c.Request.Context(), text, mode, service.FormatOptions{Dialect: dialect}, func(line string, _ service.FormatOptions) (string, error) {
+		return line, service.JSONProcessorService.ValidateJSONWithDialect(line, dialect)
+	})
+	if err != nil {
+		c.JSON(http.StatusOK, dto.ValidateResponse{
+			Valid:  false,
+			Error:  err.Error(),
+			Detail: errorDetail(err),
+		})
+		return
+	}
+
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusOK, dto.ValidateResponse{
+			Valid:      false,
+			Error:      fmt.Sprintf("共 %d 行，%d 行格式错误", result.Lines, len(result.Errors)),
+			Detail:     errorDetail(result.Errors[0]),
+			Lines:      result.Lines,
+			LineErrors: lineErrors(result.Errors),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ValidateResponse{
+		Valid:   true,
+		Message: fmt.Sprintf("共 %d 行，格式全部正确", result.Lines),
+		Lines:   result.Lines,
+	})
+}
+
+// processLine 按请求中的选项完整处理一行
+func processLine(c *gin.Context, req dto.JSONRequest, line string, opts service.ProcessOptions) (string, error) {
+	switch {
+	case req.DeepExpand:
+		expanded, err := service.JSONProcessorService.DeepExpandJSON(c.Request.Context(), line, opts)
+		if err != nil {
+			return "", err
+		}
+		return expanded.Result, nil
+	case req.Repair:
+		repaired, err := service.JSONProcessorService.ProcessJSONWithRepair(c.Request.Context(), line, opts)
+		if err != nil {
+			return "", err
+		}
+		return repaired.Result, nil
+	}
+	return service.JSONProcessorService.ProcessJSONWithOptions(c.Request.Context(), line, opts)
+}
+
+// documentMode 检查只按单个文档处理的接口收到的 mode，name 为接口的功能名称
+func documentMode(modeName string, name string) error {
+	mode, err := service.ParseInputMode(modeName)
+	if err == nil && mode != service.ModeDocument {
+		err = fmt.Errorf("%s不支持处理模式 %s，只能按单个文档处理", name, modeName)
+	}
+	return err
+}
+
+// processLines 按请求中的 mode 逐行处理并写入响应；mode 为 document 时返回 false，由调用方按单个文档处理
+func processLines(c *gin.Context, modeName string, text string, opts service.FormatOptions, fn service.LineFunc) bool {
+	mode, err := service.ParseInputMode(modeName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return true
+	}
+	if mode == service.ModeDocument {
+		return false
+	}
+
+	result, err := service.JSONProcessorService.ProcessLines(c.Request.Context(), text, mode, opts, fn)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success: false,
+			Error:   err.Error(),
+			Detail:  errorDetail(err),
+		})
+		return true
+	}
+
+	// 全部失败时视为请求失败，部分失败时保留成功的行
+	if result.Lines > 0 && len(result.Errors) == result.Lines {
+		c.JSON(http.StatusBadRequest, dto.JSONResponse{
+			Success:    false,
+			Error:      "所有行均处理失败: " + result.Errors[0].Error(),
+			Detail:     errorDetail(result.Errors[0]),
+			Lines:      result.Lines,
+			LineErrors: lineErrors(result.Errors),
+		})
+		return true
+	}
+
+	c.JSON(http.StatusOK, dto.JSONResponse{
+		Result:     result.Result,
+		Success:    true,
+		Lines:      result.Lines,
+		LineErrors: lineErrors(result.Errors),
+	})
+	return true
+}
+
+// lineErrors 转换按行处理的错误
+func lineErrors(errs []*service.LineError) []dto.LineError {
+	if len(errs) == 0 {
+		return nil
+	}
+	result := make([]dto.LineError, 0, len(errs))
+	for _, err := range errs {
+		result = append(result, dto.LineError{
+			Line:   err.Line,
+			Error:  err.Error(),
+			Detail: errorDetail(err),
+		})
+	}
+	return result
+}
+
+// formatOptions 把请求中的格式化设置转换为服务层选项
+func formatOptions(req dto.FormatSettings) service.FormatOptions {
+	indent := req.Indent
+	if indent == 0 {
+		indent = 2
+	}
+
+	opts := service.FormatOptions{
+		Indent:          indent,
+		UseTabs:         req.UseTabs,
+		LineWidth:       req.LineWidth,
+		TrailingNewline: req.TrailingNewline,
+		CRLF:            strings.EqualFold(req.LineEnding, "crlf"),
+		Dialect:         service.Dialect(strings.ToLower(req.Dialect)),
+		TargetDialect:   service.Dialect(strings.ToLower(req.TargetDialect)),
+	}
+
+	if req.SortKeys {
+		opts.KeyOrder = service.KeyOrderLexical
+		if req.NaturalSort {
+			opts.KeyOrder = service.KeyOrderNatural
+		}
+	}
+
+	if req.SpaceAfterColon != nil {
+		opts.ColonSpace = service.ColonSpaceNever
+		if *req.SpaceAfterColon {
+			opts.ColonSpace = service.ColonSpaceAlways
+		}
+	}
+
+	return opts
+}
+
+// errorDetail 从带位置信息的服务层错误中提取错误详情，其他错误返回 nil
+func errorDetail(err error) *dto.ErrorDetail {
+	var posErr service.PositionError
+	if !errors.As(err, &posErr) {
+		return nil
+	}
+
+	pos := posErr.Pos()
+	detail := &dto.ErrorDetail{
+		Offset:  pos.Offset,
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Snippet: pos.Snippet,
+	}
+
+	var syntaxErr *service.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		detail.Expected = syntaxErr.Expected
+	}
+	return detail
+}
+
+// repairFixes 转换修复记录
+func repairFixes(fixes []service.RepairFix) []dto.RepairFix {
+	result := make([]dto.RepairFix, 0, len(fixes))
+	for _, fix := range fixes {
+		result = append(result, dto.RepairFix{
+			Kind:    fix.Kind,
+			Message: fix.Message,
+			Offset:  fix.Offset,
+			Line:    fix.Line,
+			Column:  fix.Column,
+		})
+	}
+	return result
+}
+
+// quoteRules 转换请求中的加引号规则，未传入时返回 nil 以使用默认规则
+func quoteRules(rules []dto.QuoteRule) []service.QuoteRule {
+	if rules == nil {
+		return nil
+	}
+
+	result := make([]service.QuoteRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, service.QuoteRule{
+			Name:  rule.Name,
+			Key:   rule.Key,
+			Value: rule.Value,
+		})
+	}
+	return result
 }
@@ -1,21 +1,227 @@
 package dto
 
+// FormatSettings 输出JSON的格式化设置，嵌入到各个会输出JSON的请求中
+type FormatSettings struct {
+	Indent          int    `json:"indent,omitempty"`
+	UseTabs         bool   `json:"use_tabs,omitempty"`          // 使用制表符缩进
+	LineWidth       int    `json:"line_width,omitempty"`        // 行宽，能放进一行的短数组和对象折叠为单行，0表示不折叠
+	SortKeys        bool   `json:"sort_keys,omitempty"`         // 递归地按键排序，默认保持原始顺序
+	NaturalSort     bool   `json:"natural_sort,omitempty"`      // 排序时按自然顺序比较数字部分
+	SpaceAfterColon *bool  `json:"space_after_colon,omitempty"` // 冒号后是否加空格，默认缩进时加、压缩时不加
+	TrailingNewline bool   `json:"trailing_newline,omitempty"`  // 输出末尾追加换行
+	LineEnding      string `json:"line_ending,omitempty"`       // 换行符：lf（默认）或 crlf
+	Dialect         string `json:"dialect,omitempty"`           // 输入的方言：json（默认）、jsonc 或 json5
+	TargetDialect   string `json:"target_dialect,omitempty"`    // 输出的方言，默认与输入相同
+}
+
 // JSONRequest JSON处理请求
 type JSONRequest struct {
-	Text   string `json:"text" binding:"required"`
-	Indent int    `json:"indent,omitempty"`
+	Text string `json:"text" binding:"required"`
+	FormatSettings
+	DeepExpand      bool        `json:"deep_expand,omitempty"`      // 深度展开多层转义及内嵌的JSON字符串
+	Repair          bool        `json:"repair,omitempty"`           // 宽松解析并修复常见错误（仅 /api/process）
+	QuoteRules      []QuoteRule `json:"quote_rules"`                // 裸值加引号规则，不传时使用服务器默认规则，传空数组则不加引号
+	Language        string      `json:"language,omitempty"`         // 转换的来源语言：auto（默认）、python、javascript、ruby、go、java（仅 /api/convert）
+	Mongo           string      `json:"mongo,omitempty"`            // 按 mongo shell 语法解析：canonical、relaxed 转换为 Extended JSON，flatten 展平为普通值（仅 /api/process）
+	Mode            string      `json:"mode,omitempty"`             // 处理模式：document（默认）、ndjson、ndjson_to_array、array_to_ndjson
+	Inline          bool        `json:"inline,omitempty"`           // 把格式化后的片段放回原文（仅 /api/extract）
+	Documents       string      `json:"documents,omitempty"`        // YAML 多文档的组织方式：auto（默认）、array、ndjson（仅 /api/yaml/*）
+	YAMLStyle       string      `json:"yaml_style,omitempty"`       // 输出YAML的样式：block（默认）、flow、compact（仅 /api/yaml/from-json）
+	XMLConvention   string      `json:"xml_convention,omitempty"`   // XML与JSON的对应约定：attr（默认）、badgerfish、parker（仅 /api/xml/*）
+	ForceArrays     []string    `json:"force_arrays,omitempty"`     // 总是转换为数组的元素，元素名或从根元素开始以点分隔的路径（仅 /api/xml/to-json）
+	StripNamespaces bool        `json:"strip_namespaces,omitempty"` // 去掉元素和属性名的命名空间前缀及 xmlns 声明（仅 /api/xml/to-json）
+	Root            string      `json:"root,omitempty"`             // 根元素名，默认取顶层唯一的键，否则为 root（仅 /api/xml/from-json）
+	CSVFormat       string      `json:"csv_format,omitempty"`       // 表格格式：csv（默认）或 tsv（仅 /api/csv/*）
+	Pointer         string      `json:"pointer,omitempty"`          // 要转换的数组的 JSON Pointer，如 /data/items（仅 /api/csv/from-json）
+	ArrayEncoding   string      `json:"array_encoding,omitempty"`   // 嵌套数组的写法：json（默认）、join、index（仅 /api/csv/from-json）
+	ArraySeparator  string      `json:"array_separator,omitempty"`  // join 写法的分隔符，默认为 ;（仅 /api/csv/from-json）
+	RawStrings      bool        `json:"raw_strings,omitempty"`      // 不推断类型，所有值保持为字符串（仅 /api/csv/to-json）
+	BOM             bool        `json:"bom,omitempty"`              // 输出带 UTF-8 BOM，便于 Excel 识别编码（仅 /api/csv/from-json）
+	KeepFormulas    bool        `json:"keep_formulas,omitempty"`    // 以 = + - @ 开头的单元格不加单引号（仅 /api/csv/from-json）
+}
+
+// CodegenRequest 从JSON样本生成模型代码的请求
+type CodegenRequest struct {
+	Text            string `json:"text" binding:"required"`
+	Dialect         string `json:"dialect,omitempty"`          // 样本的方言，同 FormatSettings.Dialect
+	Mode            string `json:"mode,omitempty"`             // 处理模式，ndjson 时每行为一个样本
+	TypeName        string `json:"type_name,omitempty"`        // 生成的顶层类型名，默认为 Root
+	Package         string `json:"package,omitempty"`          // 包名，不为空时输出 package 子句（仅 go、java、kotlin）
+	InlineTypes     bool   `json:"inline_types,omitempty"`     // 嵌套的对象生成为匿名结构，而不是单独命名的类型（仅 go、typescript）
+	Framework       string `json:"framework,omitempty"`        // 代码风格：java 为 lombok（默认）或 record，python 为 dataclass（默认）或 pydantic
+	PointerNullable bool   `json:"pointer_nullable,omitempty"` // 出现过 null 的字段使用指针类型（仅 go）
+	Declaration     string `json:"declaration,omitempty"`      // 对象类型的声明方式：interface（默认）或 type（仅 typescript）
+	Zod             bool   `json:"zod,omitempty"`              // 生成 Zod schema 及由其推导的类型（仅 typescript）
+	EnumLimit       int    `json:"enum_limit,omitempty"`       // 字符串取值不超过该数量且有重复时生成字面量联合类型，默认 5，-1 表示不生成（仅 typescript）
+}
+
+// ExampleRequest 由Go结构体定义生成示例JSON的请求
+type ExampleRequest struct {
+	Text string `json:"text" binding:"required"`
+	FormatSettings
+	Mode      string `json:"mode,omitempty"`       // 只支持 document
+	TypeName  string `json:"type_name,omitempty"`  // 作为顶层的类型名，默认取第一个结构体
+	OmitEmpty bool   `json:"omit_empty,omitempty"` // 省略带 omitempty 的字段
+}
+
+// InferSchemaRequest 从JSON样本推断 JSON Schema 的请求
+type InferSchemaRequest struct {
+	Text string `json:"text" binding:"required"`
+	FormatSettings
+	Mode      string `json:"mode,omitempty"`       // 处理模式，ndjson 时每行为一个样本
+	EnumLimit int    `json:"enum_limit,omitempty"` // 字符串取值不超过该数量且有重复时生成 enum，默认 5，-1 表示不生成
+	Strict    bool   `json:"strict,omitempty"`     // 对象不允许样本以外的属性
+}
+
+// ValidateSchemaRequest 按 JSON Schema 校验的请求，schema 和 subject 至少提供一个
+type ValidateSchemaRequest struct {
+	Text    string `json:"text" binding:"required"`
+	Dialect string `json:"dialect,omitempty"` // 文档的方言，同 FormatSettings.Dialect
+	Mode    string `json:"mode,omitempty"`    // 只支持 document
+	Schema  string `json:"schema,omitempty"`  // 用于校验的 JSON Schema，支持 draft-07 和 2020-12
+	Subject string `json:"subject,omitempty"` // 不提供 schema 时，按注册表中该主题的 schema 校验
+	Version string `json:"version,omitempty"` // 主题的版本号，默认为 latest
+}
+
+// SchemaRequest 注册 schema 或检查兼容性的请求
+type SchemaRequest struct {
+	Schema string `json:"schema" binding:"required"` // JSON Schema 文本
+}
+
+// CompatibilityRequest 设置主题兼容性要求的请求
+type CompatibilityRequest struct {
+	Compatibility string `json:"compatibility" binding:"required"` // NONE、BACKWARD、BACKWARD_TRANSITIVE、FORWARD、FORWARD_TRANSITIVE、FULL、FULL_TRANSITIVE
+}
+
+// QuoteRule 裸值加引号规则
+type QuoteRule struct {
+	Name  string `json:"name"`
+	Key   string `json:"key,omitempty"` // 键的正则表达式，为空时匹配任意键
+	Value string `json:"value"`         // 内置值类型（rfc3339、datetime、date、uuid、ip、hex、word）或正则表达式
+}
+
+// EscapeRequest JSON转义请求
+type EscapeRequest struct {
+	Text      string `json:"text" binding:"required"`
+	Level     int    `json:"level,omitempty"`      // 转义次数，默认为1
+	ASCIIOnly bool   `json:"ascii_only,omitempty"` // 非ASCII字符输出为\uXXXX
+	HTMLSafe  bool   `json:"html_safe,omitempty"`  // <、>、&输出为\uXXXX
+	Minify    bool   `json:"minify,omitempty"`     // 转义前先压缩JSON
+	Mode      string `json:"mode,omitempty"`       // 处理模式，同 JSONRequest.Mode
 }
 
 // JSONResponse JSON处理响应
 type JSONResponse struct {
-	Result  string `json:"result,omitempty"`
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
+	Result        string       `json:"result,omitempty"`
+	Success       bool         `json:"success"`
+	Error         string       `json:"error,omitempty"`
+	Detail        *ErrorDetail `json:"detail,omitempty"`         // 错误的位置信息
+	Layers        int          `json:"layers,omitempty"`         // 深度展开时去除的转义层数
+	ExpandedPaths []string     `json:"expanded_paths,omitempty"` // 深度展开时被展开的字段路径
+	Fixes         []RepairFix  `json:"fixes,omitempty"`          // 修复时应用的每一处修复
+	Language      string       `json:"language,omitempty"`       // 转换时实际使用的来源语言
+	Lines         int          `json:"lines,omitempty"`          // 按行处理时参与处理的行数
+	LineErrors    []LineError  `json:"line_errors,omitempty"`    // 按行处理时失败的行
+	Documents     int          `json:"documents,omitempty"`      // YAML 转换时的文档数
+	Notes         []Note       `json:"notes,omitempty"`          // 转换时对锚点、标签等无法直接对应的写法所做处理的说明
+}
+
+// Note 转换说明
+type Note struct {
+	Line    int    `json:"line"`    // 行号，从1开始
+	Column  int    `json:"column"`  // 列号，从1开始
+	Message string `json:"message"` // 说明
+}
+
+// LineError 按行处理时某一行的错误
+type LineError struct {
+	Line   int          `json:"line"`             // 行号，从1开始；array_to_ndjson 时为元素所在的行
+	Error  string       `json:"error"`            // 错误信息
+	Detail *ErrorDetail `json:"detail,omitempty"` // 错误的位置信息
+}
+
+// ExtractResponse 提取JSON片段响应
+type ExtractResponse struct {
+	Result    string            `json:"result,omitempty"` // inline 时为放回格式化片段后的全文
+	Fragments []ExtractFragment `json:"fragments,omitempty"`
+	Success   bool              `json:"success"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// ExtractFragment 提取出的JSON片段
+type ExtractFragment struct {
+	Start   int    `json:"start"`             // 开始字节偏移，从0开始
+	End     int    `json:"end"`               // 结束字节偏移（不含）
+	Line    int    `json:"line"`              // 开始行号，从1开始
+	Column  int    `json:"column"`            // 开始列号（按 UTF-16 码元计），从1开始
+	Escaped bool   `json:"escaped,omitempty"` // 原文中是否经过转义
+	Result  string `json:"result"`            // 格式化后的片段
+}
+
+// RepairFix 一次修复记录
+type RepairFix struct {
+	Kind    string `json:"kind"`    // 修复类型，如 trailing_comma、single_quote
+	Message string `json:"message"` // 修复说明
+	Offset  int    `json:"offset"`  // 字节偏移，从0开始
+	Line    int    `json:"line"`    // 行号，从1开始
+	Column  int    `json:"column"`  // 列号（按 UTF-16 码元计），从1开始
 }
 
 // ValidateResponse JSON验证响应
 type ValidateResponse struct {
-	Valid   bool   `json:"valid"`
-	Message string `json:"message,omitempty"`
-	Error   string `json:"error,omitempty"`
+	Valid      bool         `json:"valid"`
+	Message    string       `json:"message,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	Detail     *ErrorDetail `json:"detail,omitempty"`      // 错误的位置信息
+	Lines      int          `json:"lines,omitempty"`       // 按行验证时参与验证的行数
+	LineErrors []LineError  `json:"line_errors,omitempty"` // 按行验证时失败的行
+	Violations []Violation  `json:"violations,omitempty"`  // 按 JSON Schema 校验时不符合的位置
+}
+
+// Violation 文档中不符合 JSON Schema 的一处
+type Violation struct {
+	InstancePath string `json:"instance_path"` // 值的 JSON Pointer，文档本身为空字符串
+	KeywordPath  string `json:"keyword_path"`  // 约束在 schema 中的路径，如 /properties/id/minimum
+	Message      string `json:"message"`       // 说明
+	Offset       int    `json:"offset"`        // 值的字节偏移，从0开始
+	Line         int    `json:"line"`          // 行号，从1开始
+	Column       int    `json:"column"`        // 列号（按 UTF-16 码元计），从1开始
+}
+
+// ErrorDetail 错误的位置信息
+type ErrorDetail struct {
+	Offset   int    `json:"offset"`             // 字节偏移，从0开始
+	Line     int    `json:"line"`               // 行号，从1开始
+	Column   int    `json:"column"`             // 列号（按 UTF-16 码元计），从1开始
+	Expected string `json:"expected,omitempty"` // 期望出现的内容
+	Snippet  string `json:"snippet,omitempty"`  // 出错位置附近的文本
+}
+
+// SchemaRegistryResponse schema 注册表响应
+type SchemaRegistryResponse struct {
+	Success           bool              `json:"success"`
+	Error             string            `json:"error,omitempty"`
+	Message           string            `json:"message,omitempty"`
+	Subject           string            `json:"subject,omitempty"`           // 主题
+	Version           int               `json:"version,omitempty"`           // 版本号
+	Versions          []int             `json:"versions,omitempty"`          // 主题的所有版本号
+	Schema            string            `json:"schema,omitempty"`            // schema 文本
+	Compatibility     string            `json:"compatibility,omitempty"`     // 主题的兼容性要求
+	IsCompatible      *bool             `json:"is_compatible,omitempty"`     // 检查兼容性时是否兼容
+	Incompatibilities []Incompatibility `json:"incompatibilities,omitempty"` // 不兼容的位置
+}
+
+// SchemaSubjectsResponse 注册表中所有主题的响应
+type SchemaSubjectsResponse struct {
+	Success  bool     `json:"success"`
+	Error    string   `json:"error,omitempty"`
+	Subjects []string `json:"subjects"` // 按名称排序，没有主题时为空数组
+}
+
+// Incompatibility 新的 schema 与已注册版本之间的一处不兼容
+type Incompatibility struct {
+	Version     int    `json:"version"`      // 比较的已注册版本
+	Direction   string `json:"direction"`    // backward：新版本不接受该版本的数据；forward：该版本不接受新版本的数据
+	KeywordPath string `json:"keyword_path"` // 约束在读取方 schema 中的路径，backward 时为新版本，forward 时为已注册版本
+	Message     string `json:"message"`      // 说明
 }
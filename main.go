@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 
+	"sojson/command"
 	"sojson/env"
 	"sojson/server"
 	"sojson/zlog"
@@ -22,7 +23,6 @@ func main() {
 				Name: "SoJSON Team",
 			},
 		},
-		Before: initApp,
 		Commands: []*cli.Command{
 			{
 				Name:    "server",
@@ -41,9 +41,20 @@ func main() {
 						Value:   2378,
 						Usage:   "服务器监听端口",
 					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "配置文件路径（JSON）",
+					},
+					&cli.StringFlag{
+						Name:  "schema-dir",
+						Usage: "schema 注册表的存储目录，优先于配置文件中的 schema_dir",
+					},
 				},
+				Before: initApp,
 				Action: server.RunHTTPServer,
 			},
+			command.YAMLCommand,
 		},
 		DefaultCommand: "server",
 	}
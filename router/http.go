@@ -22,9 +22,32 @@ func RegisterHTTP(engine *gin.Engine) *gin.Engine {
 	api := engine.Group("/api")
 	{
 		api.POST("/unescape", controller.JSONController.UnescapeJSON)
+		api.POST("/escape", controller.JSONController.EscapeJSON)
 		api.POST("/format", controller.JSONController.FormatJSON)
 		api.POST("/process", controller.JSONController.ProcessJSON)
 		api.POST("/validate", controller.JSONController.ValidateJSON)
+		api.POST("/repair", controller.JSONController.RepairJSON)
+		api.POST("/convert", controller.JSONController.ConvertJSON)
+		api.POST("/extract", controller.JSONController.ExtractJSON)
+		api.POST("/yaml/to-json", controller.JSONController.YAMLToJSON)
+		api.POST("/yaml/from-json", controller.JSONController.JSONToYAML)
+		api.POST("/toml/to-json", controller.JSONController.TOMLToJSON)
+		api.POST("/toml/from-json", controller.JSONController.JSONToTOML)
+		api.POST("/xml/to-json", controller.JSONController.XMLToJSON)
+		api.POST("/xml/from-json", controller.JSONController.JSONToXML)
+		api.POST("/csv/to-json", controller.JSONController.CSVToJSON)
+		api.POST("/csv/from-json", controller.JSONController.JSONToCSV)
+		api.POST("/codegen/:lang", controller.JSONController.Generate)
+		api.POST("/example/go", controller.JSONController.GoExample)
+		api.POST("/schema/infer", controller.JSONController.InferSchema)
+		api.POST("/schema/validate", controller.JSONController.ValidateSchema)
+		api.GET("/schemas", controller.JSONController.ListSchemaSubjects)
+		api.GET("/schemas/:subject/versions", controller.JSONController.ListSchemaVersions)
+		api.POST("/schemas/:subject/versions", controller.JSONController.RegisterSchema)
+		api.GET("/schemas/:subject/versions/:version", controller.JSONController.GetSchemaVersion)
+		api.POST("/schemas/:subject/compatibility", controller.JSONController.CheckSchemaCompatibility)
+		api.GET("/schemas/:subject/config", controller.JSONController.GetSubjectConfig)
+		api.PUT("/schemas/:subject/config", controller.JSONController.SetSubjectConfig)
 	}
 
 	return engine
@@ -10,8 +10,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"sojson/config"
 	"sojson/env"
 	"sojson/router"
+	"sojson/service"
 	"sojson/static"
 	"sojson/zlog"
 
@@ -80,6 +82,36 @@ func RunHTTPServer(ctx *cli.Context) error {
 	port := ctx.Int("port")
 	address := fmt.Sprintf("%s:%d", host, port)
 
+	// 加载配置文件
+	if path := ctx.String("config"); path != "" {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := service.JSONProcessorService.SetSchemaRegistry(cfg.SchemaDir, cfg.SchemaCompatibility); err != nil {
+			return fmt.Errorf("配置文件中的兼容性要求无效: %v", err)
+		}
+		if cfg.QuoteRules != nil {
+			rules := make([]service.QuoteRule, 0, len(cfg.QuoteRules))
+			for _, rule := range cfg.QuoteRules {
+				rules = append(rules, service.QuoteRule{
+					Name:  rule.Name,
+					Key:   rule.Key,
+					Value: rule.Value,
+				})
+			}
+			if err := service.JSONProcessorService.SetDefaultQuoteRules(rules); err != nil {
+				return fmt.Errorf("配置文件中的加引号规则无效: %v", err)
+			}
+		}
+		zlog.Infof(ctx.Context, "已加载配置文件: %s", path)
+	}
+	if dir := ctx.String("schema-dir"); dir != "" {
+		if err := service.JSONProcessorService.SetSchemaRegistry(dir, ""); err != nil {
+			return err
+		}
+	}
+
 	// 创建路由
 	engine := newGinEngine(ctx.Context, static.StaticFiles, static.TemplateFiles)
 
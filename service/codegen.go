@@ -0,0 +1,704 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sojson/zlog"
+)
+
+// CodegenOptions 代码生成选项
+type CodegenOptions struct {
+	Dialect         Dialect   // 样本的方言
+	Mode            InputMode // 为 ndjson 时每行是一个样本，否则整个输入是一个样本
+	TypeName        string    // 顶层类型名，默认为 Root
+	Package         string    // 包名，不为空时输出 package 子句（Go、Java、Kotlin）
+	Inline          bool      // 嵌套的对象生成为匿名的结构，而不是单独命名的类型（Go、TypeScript）
+	Framework       string    // 代码风格：Java 为 lombok（默认）或 record，Python 为 dataclass（默认）或 pydantic
+	PointerNullable bool      // 出现过 null 的字段使用指针类型（仅 Go）
+	Declaration     string    // 对象类型的声明方式：interface（默认）或 type（仅 TypeScript）
+	Zod             bool      // 生成 Zod schema 及由其推导的类型（仅 TypeScript）
+	EnumLimit       int       // 字符串的取值不超过该数量且有取值重复出现时生成字面量联合类型，0 表示默认的 5，负数表示不生成（仅 TypeScript）
+}
+
+// enumLimit 生成字面量联合类型的最多取值个数
+func (o CodegenOptions) enumLimit() int {
+	switch {
+	case o.EnumLimit == 0:
+		return 5
+	case o.EnumLimit < 0:
+		return 0
+	}
+	return o.EnumLimit
+}
+
+// typeName 顶层类型名
+func (o CodegenOptions) typeName() string {
+	if o.TypeName == "" {
+		return "Root"
+	}
+	return o.TypeName
+}
+
+// codegenLanguage 一种目标语言的代码生成规则
+type codegenLanguage struct {
+	unions   bool                               // 类型不一致时保留为联合类型，否则生成为任意类型并说明
+	inline   bool                               // 支持匿名的嵌套类型
+	typeName func(string) string                // 类型名的命名规则
+	emit     func(m *codeModel) (string, error) // 输出代码
+}
+
+// codegenLanguages 支持的目标语言，新增语言只需在这里注册
+var codegenLanguages = map[string]*codegenLanguage{
+	"go":         {inline: true, typeName: goIdent, emit: emitGo},
+	"typescript": {unions: true, inline: true, typeName: pascalIdent, emit: emitTypeScript},
+	"java":       {typeName: pascalIdent, emit: emitJava},
+	"kotlin":     {typeName: pascalIdent, emit: emitKotlin},
+	"rust":       {typeName: pascalIdent, emit: emitRust},
+	"python":     {unions: true, typeName: pascalIdent, emit: emitPython},
+}
+
+// codegenAliases 目标语言的别名
+var codegenAliases = map[string]string{
+	"golang": "go",
+	"ts":     "typescript",
+	"kt":     "kotlin",
+	"rs":     "rust",
+	"py":     "python",
+}
+
+// ParseCodegenLanguage 解析目标语言名称，返回注册的语言名
+func ParseCodegenLanguage(name string) (string, error) {
+	lang := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := codegenAliases[lang]; ok {
+		lang = alias
+	}
+	if _, ok := codegenLanguages[lang]; !ok {
+		names := make([]string, 0, len(codegenLanguages))
+		for n := range codegenLanguages {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("不支持的语言: %s，可选 %s", name, strings.Join(names, "、"))
+	}
+	return lang, nil
+}
+
+// codeModel 交给各语言输出的类型模型
+type codeModel struct {
+	opts  CodegenOptions
+	name  string           // 顶层类型名
+	root  *typeNode        // 顶层类型
+	types []*typeNode      // 命名的对象类型，按深度优先的顺序排列，匿名时为空
+	text  string           // 输入的样本，用于换算说明的位置
+	notes []ConversionNote // 输出时对目标语言无法表示的写法所做的说明
+	noted map[string]bool  // 已说明过的位置和内容
+}
+
+// note 记录一条输出时的说明，同一位置的相同说明只记录一次
+func (m *codeModel) note(offset int, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	key := strconv.Itoa(offset) + ":" + message
+	if m.noted[key] {
+		return
+	}
+	if m.noted == nil {
+		m.noted = make(map[string]bool)
+	}
+	m.noted[key] = true
+	pos := newPosition(m.text, offset)
+	m.notes = append(m.notes, ConversionNote{Line: pos.Line, Column: pos.Column, Message: message})
+}
+
+// rootNamed 顶层是否为命名的对象类型，否则需要单独声明顶层类型
+func (m *codeModel) rootNamed() bool {
+	return len(m.types) > 0 && m.types[0] == m.root
+}
+
+// Generate 从JSON样本推断类型并按目标语言生成模型代码：多个数组元素或样本中的字段合并，
+// 只在部分对象中出现的字段为可选字段
+func (s *jsonProcessorService) Generate(ctx context.Context, lang string, text string, opts CodegenOptions) (*ConversionResult, error) {
+	lang, err := ParseCodegenLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+	language := codegenLanguages[lang]
+
+	root, notes, err := inferTypes(text, opts, language.unions)
+	if err != nil {
+		zlog.Errorf(ctx, "Generate: inferTypes failed, language: %s, input text length: %d, error: %v", lang, len(text), err)
+		return nil, err
+	}
+
+	m := &codeModel{opts: opts, name: language.typeName(opts.typeName()), root: root, text: text}
+	if !opts.Inline || !language.inline {
+		m.types = nameTypes(root, m.name, language.typeName)
+	}
+	result, err := language.emit(m)
+	if err != nil {
+		zlog.Errorf(ctx, "Generate: emit failed, language: %s, error: %v", lang, err)
+		return nil, err
+	}
+
+	zlog.Infof(ctx, "Generate: successfully generated, language: %s, types: %d, input length: %d, output length: %d", lang, len(m.types), len(text), len(result))
+	return &ConversionResult{Result: result, Notes: append(notes, m.notes...)}, nil
+}
+
+// typeKind 从样本推断出的值类型
+type typeKind int
+
+const (
+	kindNull   typeKind = iota // 只出现过 null
+	kindBool                   // 布尔值
+	kindInt                    // 64 位范围内的整数
+	kindFloat                  // 浮点数，或整数与浮点数混合
+	kindNumber                 // 超出 64 位范围的整数，需要保持原样
+	kindString                 // 字符串
+	kindObject                 // 对象
+	kindArray                  // 数组
+	kindMixed                  // 样本中的类型不一致，允许联合类型时各类型记录在 variants 中
+)
+
+var typeKindNames = map[typeKind]string{
+	kindBool:   "布尔值",
+	kindInt:    "整数",
+	kindFloat:  "浮点数",
+	kindNumber: "大整数",
+	kindString: "字符串",
+	kindObject: "对象",
+	kindArray:  "数组",
+}
+
+// typeNode 从所有样本合并推断出的类型
+type typeNode struct {
+	kind     typeKind
+	nullable bool         // 出现过 null
+	fields   []*typeField // kindObject 的字段，按第一次出现的顺序排列
+	objects  int          // 合并的对象个数，字段出现的次数少于它时为可选字段
+	elem     *typeNode    // kindArray 的元素类型，只出现过空数组时为 nil
+	variants []*typeNode  // kindMixed 的各个类型，每种类型一个，不含 null
+	values   []string     // kindString 出现过的不同取值，超过上限时为 nil
+	many     bool         // kindString 的取值个数超过了上限
+	count    int          // kindString 出现的次数
+	format   string       // kindString 的所有取值都符合的格式，如 date-time、uuid
+	minimum  string       // 数字的最小值，保留原始写法
+	maximum  string       // 数字的最大值，保留原始写法
+	name     string       // 命名后的类型名，匿名时为空
+}
+
+// typeField 对象的字段
+type typeField struct {
+	key    string
+	typ    *typeNode
+	count  int // 出现该字段的对象个数
+	offset int // 第一次出现时值在输入中的偏移
+}
+
+// optional 字段是否只在部分对象中出现
+func (t *typeNode) optional(f *typeField) bool {
+	return f.count < t.objects
+}
+
+// enum 字符串的取值是否像枚举：取值个数不超过上限，且有取值重复出现
+func (t *typeNode) enum() []string {
+	if t.kind != kindString || t.many || len(t.values) == 0 || t.count <= len(t.values) {
+		return nil
+	}
+	return t.values
+}
+
+// typeInferrer 逐个合并样本中的值，推断出类型
+type typeInferrer struct {
+	text      string
+	base      int  // 当前样本在输入中的偏移
+	unions    bool // 类型不一致时保留为联合类型，否则合并为任意类型并说明
+	enumLimit int
+	notes     []ConversionNote
+	noted     map[string]bool // 已说明过类型不一致的路径
+}
+
+// inferTypes 解析样本并推断出合并后的类型；mode 为 ndjson 时每行是一个样本，为 array_to_ndjson 时
+// 顶层数组的每个元素是一个样本，unions 为 true 时类型不一致的值推断为联合类型
+func inferTypes(text string, opts CodegenOptions, unions bool) (*typeNode, []ConversionNote, error) {
+	if err := opts.Dialect.check(); err != nil {
+		return nil, nil, err
+	}
+
+	inf := &typeInferrer{text: text, unions: unions, enumLimit: opts.enumLimit(), noted: map[string]bool{}}
+	var root *typeNode
+	switch opts.Mode {
+	case "", ModeDocument:
+		tree, err := parseDialectTree(text, opts.Dialect)
+		if err != nil {
+			return nil, nil, err
+		}
+		root = inf.add(nil, tree, "$")
+	case ModeArrayToNDJSON:
+		tree, err := parseDialectTree(text, opts.Dialect)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tree.kind != nodeArray || len(tree.elements) == 0 {
+			return nil, nil, fmt.Errorf("输入不是非空的JSON数组")
+		}
+		for _, el := range tree.elements {
+			root = inf.add(root, el, "$")
+		}
+	case ModeNDJSON, ModeNDJSONToArray:
+		offset := 0
+		for _, raw := range strings.SplitAfter(text, "\n") {
+			if line := strings.TrimRight(raw, "\r\n"); strings.TrimSpace(line) != "" {
+				tree, err := parseDialectTree(line, opts.Dialect)
+				if err != nil {
+					return nil, nil, newLineError(text, offset, true, err)
+				}
+				inf.base = offset
+				root = inf.add(root, tree, "$")
+			}
+			offset += len(raw)
+		}
+		if root == nil {
+			return nil, nil, fmt.Errorf("输入中没有非空行")
+		}
+	default:
+		return nil, nil, fmt.Errorf("类型推断不支持处理模式 %s，可选 document、ndjson、array_to_ndjson", opts.Mode)
+	}
+	return root, inf.notes, nil
+}
+
+// add 把值合并到已推断的类型 t 中，t 为 nil 时新建
+func (inf *typeInferrer) add(t *typeNode, node *jsonNode, path string) *typeNode {
+	if t == nil {
+		t = &typeNode{kind: kindNull}
+	}
+	if node.kind == nodeLiteral && node.raw == "null" {
+		t.nullable = true
+		return t
+	}
+
+	kind := valueKind(node)
+	switch t.kind {
+	case kindNull:
+		t.kind = kind
+	case kindMixed:
+		if inf.unions {
+			return inf.addVariant(t, node, path, kind)
+		}
+		return t
+	default:
+		merged, ok := mergeKinds(t.kind, kind)
+		if !ok && inf.unions {
+			// 已有的类型作为联合类型的第一个成员
+			first := *t
+			first.nullable = false
+			*t = typeNode{kind: kindMixed, nullable: t.nullable, variants: []*typeNode{&first}}
+			return inf.addVariant(t, node, path, kind)
+		}
+		if !ok {
+			if !inf.noted[path] {
+				inf.noted[path] = true
+				pos := newPosition(inf.text, inf.base+node.offset)
+				inf.notes = append(inf.notes, ConversionNote{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf("%s 在样本中既是%s又是%s，生成为任意类型", path, typeKindNames[t.kind], typeKindNames[kind])})
+			}
+			t.kind, t.fields, t.elem = kindMixed, nil, nil
+			return t
+		}
+		t.kind = merged
+	}
+
+	switch kind {
+	case kindObject:
+		t.objects++
+		seen := make(map[string]bool, len(node.members))
+		for _, m := range node.members {
+			key := m.keyValue()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			f := t.field(key)
+			if f.count == 0 {
+				f.offset = inf.base + m.value.offset
+			}
+			f.count++
+			f.typ = inf.add(f.typ, m.value, path+"."+key)
+		}
+	case kindArray:
+		for _, el := range node.elements {
+			t.elem = inf.add(t.elem, el, path+"[*]")
+		}
+	case kindString:
+		t.count++
+		value := node.stringValue()
+		if !t.many && !containsString(t.values, value) {
+			t.values = append(t.values, value)
+			if len(t.values) > inf.enumLimit {
+				t.values, t.many = nil, true
+			}
+		}
+		if format := stringFormat(value); t.count == 1 {
+			t.format = format
+		} else if format != t.format {
+			t.format = ""
+		}
+	case kindInt, kindFloat, kindNumber:
+		if node.nonFinite {
+			break
+		}
+		if t.minimum == "" || compareNumbers(node.raw, t.minimum) < 0 {
+			t.minimum = node.raw
+		}
+		if t.maximum == "" || compareNumbers(node.raw, t.maximum) > 0 {
+			t.maximum = node.raw
+		}
+	}
+	return t
+}
+
+// compareNumbers 比较两个JSON数字的大小
+func compareNumbers(a, b string) int {
+	x, _, errA := big.ParseFloat(a, 10, 256, big.ToNearestEven)
+	y, _, errB := big.ParseFloat(b, 10, 256, big.ToNearestEven)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return x.Cmp(y)
+}
+
+// addVariant 把值合并到联合类型中可以合并的成员，没有时追加一个成员
+func (inf *typeInferrer) addVariant(t *typeNode, node *jsonNode, path string, kind typeKind) *typeNode {
+	for _, v := range t.variants {
+		if _, ok := mergeKinds(v.kind, kind); ok {
+			inf.add(v, node, path)
+			return t
+		}
+	}
+	t.variants = append(t.variants, inf.add(nil, node, path))
+	return t
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// field 返回名为 key 的字段，不存在时追加
+func (t *typeNode) field(key string) *typeField {
+	for _, f := range t.fields {
+		if f.key == key {
+			return f
+		}
+	}
+	f := &typeField{key: key}
+	t.fields = append(t.fields, f)
+	return f
+}
+
+// valueKind 非 null 值的类型
+func valueKind(node *jsonNode) typeKind {
+	switch node.kind {
+	case nodeObject:
+		return kindObject
+	case nodeArray:
+		return kindArray
+	case nodeString:
+		return kindString
+	case nodeNumber:
+		if node.nonFinite || strings.ContainsAny(node.raw, ".eE") {
+			return kindFloat
+		}
+		if _, err := strconv.ParseInt(node.raw, 10, 64); err != nil {
+			return kindNumber
+		}
+		return kindInt
+	}
+	return kindBool
+}
+
+// mergeKinds 合并两种类型：整数和浮点数合并为浮点数，与大整数合并时保持原样，其余不同的类型无法合并
+func mergeKinds(a, b typeKind) (typeKind, bool) {
+	if a == b {
+		return a, true
+	}
+	numeric := func(k typeKind) bool { return k == kindInt || k == kindFloat || k == kindNumber }
+	if !numeric(a) || !numeric(b) {
+		return kindMixed, false
+	}
+	if a == kindNumber || b == kindNumber {
+		return kindNumber, true
+	}
+	return kindFloat, true
+}
+
+// typeShape 类型结构的签名，结构相同的对象类型可以共用一个名称
+func typeShape(t *typeNode) string {
+	if t == nil {
+		return "_"
+	}
+	var sb strings.Builder
+	sb.WriteString(strconv.Itoa(int(t.kind)))
+	if t.nullable {
+		sb.WriteByte('?')
+	}
+	switch t.kind {
+	case kindObject:
+		sb.WriteByte('{')
+		for _, f := range t.fields {
+			sb.WriteString(strconv.Quote(f.key))
+			if t.optional(f) {
+				sb.WriteByte('?')
+			}
+			sb.WriteByte(':')
+			sb.WriteString(typeShape(f.typ))
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('}')
+	case kindArray:
+		sb.WriteByte('[')
+		sb.WriteString(typeShape(t.elem))
+		sb.WriteByte(']')
+	case kindMixed:
+		sb.WriteByte('(')
+		for _, v := range t.variants {
+			sb.WriteString(typeShape(v))
+			sb.WriteByte('|')
+		}
+		sb.WriteByte(')')
+	case kindString:
+		for _, v := range t.enum() {
+			sb.WriteString(strconv.Quote(v))
+		}
+	}
+	return sb.String()
+}
+
+// typeNamer 为有字段的对象类型命名
+type typeNamer struct {
+	ident  func(string) string // 把键转换为类型名
+	byName map[string]*typeNode
+	order  []*typeNode // 按深度优先的顺序排列的已命名类型，同名同结构的只保留第一个
+}
+
+// nameTypes 从顶层开始为对象类型命名：字段的类型以字段名命名，数组元素以单数形式命名，
+// 重名时先加上外层类型名作为前缀，仍然重名则加上数字后缀；结构相同的类型共用一个名称
+func nameTypes(root *typeNode, rootName string, ident func(string) string) []*typeNode {
+	n := &typeNamer{ident: ident, byName: map[string]*typeNode{}}
+	n.walk(root, rootName, "")
+	return n.order
+}
+
+func (n *typeNamer) walk(t *typeNode, name string, parent string) {
+	if t == nil {
+		return
+	}
+	switch t.kind {
+	case kindArray:
+		n.walk(t.elem, singular(name), parent)
+	case kindMixed:
+		for _, v := range t.variants {
+			n.walk(v, name, parent)
+		}
+	case kindObject:
+		if len(t.fields) == 0 {
+			return
+		}
+		shape := typeShape(t)
+		for i := 0; ; i++ {
+			candidate := name
+			switch {
+			case i == 1 && parent != "":
+				candidate = parent + name
+			case i > 1:
+				candidate = name + strconv.Itoa(i)
+			}
+			existing, ok := n.byName[candidate]
+			if !ok {
+				t.name = candidate
+				n.byName[candidate] = t
+				break
+			}
+			if typeShape(existing) == shape {
+				// 结构相同，共用已有的类型，其字段已经命名过
+				t.name = candidate
+				return
+			}
+		}
+		n.order = append(n.order, t)
+		for _, f := range t.fields {
+			n.walk(f.typ, n.ident(f.key), t.name)
+		}
+	}
+}
+
+// singular 数组元素的类型名：取英文复数的单数形式，不是复数时加上 Item 后缀
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && len(name) > 1 &&
+		!strings.HasSuffix(name, "ss") && !strings.HasSuffix(name, "us") && !strings.HasSuffix(name, "is"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+// dependencyOrder 把命名的类型排列为被引用的类型在前，用于需要先定义后使用的语言
+func dependencyOrder(types []*typeNode) []*typeNode {
+	byName := make(map[string]*typeNode, len(types))
+	for _, t := range types {
+		byName[t.name] = t
+	}
+
+	var order []*typeNode
+	done := make(map[string]bool, len(types))
+	var visit func(t *typeNode)
+	var refs func(t *typeNode)
+	refs = func(t *typeNode) {
+		if t == nil {
+			return
+		}
+		switch t.kind {
+		case kindObject:
+			if t.name != "" {
+				visit(byName[t.name])
+				return
+			}
+			for _, f := range t.fields {
+				refs(f.typ)
+			}
+		case kindArray:
+			refs(t.elem)
+		case kindMixed:
+			for _, v := range t.variants {
+				refs(v)
+			}
+		}
+	}
+	visit = func(t *typeNode) {
+		if done[t.name] {
+			return
+		}
+		done[t.name] = true
+		for _, f := range t.fields {
+			refs(f.typ)
+		}
+		order = append(order, t)
+	}
+
+	for _, t := range types {
+		visit(t)
+	}
+	return order
+}
+
+// fieldNames 按命名规则转换对象的字段名，重复时加上数字后缀
+func fieldNames(t *typeNode, ident func(string) string) []string {
+	names := make([]string, len(t.fields))
+	used := make(map[string]int, len(t.fields))
+	for i, f := range t.fields {
+		name := ident(f.key)
+		used[name]++
+		if n := used[name]; n > 1 {
+			name += strconv.Itoa(n)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// splitWords 按非字母数字字符和驼峰边界拆分单词，如 userID、HTTPServer、first_name
+func splitWords(s string) []string {
+	var words []string
+	var cur []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(cur) > 0 {
+				words = append(words, string(cur))
+				cur = nil
+			}
+			continue
+		}
+		if len(cur) > 0 && unicode.IsUpper(r) {
+			prev := cur[len(cur)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(cur))
+				cur = nil
+			}
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		words = append(words, string(cur))
+	}
+	return words
+}
+
+// pascalIdent 把键转换为 PascalCase 的类型名，如 order_item → OrderItem
+func pascalIdent(key string) string {
+	var sb strings.Builder
+	for _, word := range splitWords(key) {
+		runes := []rune(word)
+		sb.WriteRune(unicode.ToUpper(runes[0]))
+		sb.WriteString(string(runes[1:]))
+	}
+
+	name := sb.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		return "Field" + name
+	}
+	return name
+}
+
+// camelIdent 把键转换为 camelCase 的字段名，全部大写的缩写只保留首字母大写，如 user_ID → userId
+func camelIdent(key string) string {
+	var sb strings.Builder
+	for i, word := range splitWords(key) {
+		runes := []rune(strings.ToLower(word))
+		if word != strings.ToUpper(word) {
+			runes = []rune(word)
+		}
+		if i == 0 {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		sb.WriteString(string(runes))
+	}
+
+	name := sb.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		runes := []rune(name)
+		if len(runes) > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		return "field" + string(runes)
+	}
+	return name
+}
+
+// snakeIdent 把键转换为 snake_case 的字段名，如 userID → user_id
+func snakeIdent(key string) string {
+	words := splitWords(key)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+
+	name := strings.Join(words, "_")
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		return "field_" + name
+	}
+	return name
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goInitialisms Go 命名中应全部大写的缩写
+var goInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"QPS": true, "RAM": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true, "XSRF": true, "XSS": true,
+}
+
+// emitGo 输出Go结构体定义，只在部分对象中出现的字段加上 omitempty
+func emitGo(m *codeModel) (string, error) {
+	g := &goGenerator{opts: m.opts, model: m}
+	var decls []string
+	if !m.rootNamed() {
+		// 顶层不是有字段的对象时单独声明
+		decls = append(decls, "type "+m.name+" "+strings.TrimPrefix(g.expr(m.root), "*"))
+	}
+	for _, t := range m.types {
+		decls = append(decls, "type "+t.name+" "+g.structType(t))
+	}
+
+	var sb strings.Builder
+	if m.opts.Package != "" {
+		sb.WriteString("package " + m.opts.Package + "\n\n")
+		if g.jsonNumber {
+			sb.WriteString("import \"encoding/json\"\n\n")
+		}
+	}
+	sb.WriteString(strings.Join(decls, "\n\n"))
+	source, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("生成的代码无法格式化: %v", err)
+	}
+	return strings.TrimSuffix(string(source), "\n"), nil
+}
+
+// goGenerator 输出Go类型
+type goGenerator struct {
+	opts       CodegenOptions
+	model      *codeModel
+	jsonNumber bool // 是否用到了 json.Number
+}
+
+// expr 类型表达式，未命名的对象展开为匿名结构体
+func (g *goGenerator) expr(t *typeNode) string {
+	if t == nil {
+		return "any"
+	}
+	var s string
+	switch t.kind {
+	case kindBool:
+		s = "bool"
+	case kindInt:
+		s = "int64"
+	case kindFloat:
+		s = "float64"
+	case kindNumber:
+		g.jsonNumber = true
+		s = "json.Number"
+	case kindString:
+		s = "string"
+	case kindArray:
+		return "[]" + g.expr(t.elem)
+	case kindObject:
+		if len(t.fields) == 0 {
+			return "map[string]any"
+		}
+		s = t.name
+		if s == "" {
+			s = g.structType(t)
+		}
+	default:
+		return "any"
+	}
+	if g.opts.PointerNullable && t.nullable {
+		s = "*" + s
+	}
+	return s
+}
+
+// structType 结构体类型，字段名重复时加上数字后缀
+func (g *goGenerator) structType(t *typeNode) string {
+	var sb strings.Builder
+	sb.WriteString("struct {\n")
+	names := fieldNames(t, goIdent)
+	for i, f := range t.fields {
+		name := names[i]
+		tag := g.tagName(f)
+		if t.optional(f) && tag != "-" {
+			tag = strings.TrimSuffix(tag, ",") + ",omitempty"
+		}
+		sb.WriteString(name + " " + g.expr(f.typ) + " " + goTag("json:"+strconv.Quote(tag)) + "\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// tagName json 标签中的名称。键为 - 时写为 "-,"，否则 encoding/json 会忽略该字段；
+// 含有逗号、引号等字符的键 encoding/json 无法按标签对应，标签写为 - 并在 Notes 中说明
+func (g *goGenerator) tagName(f *typeField) string {
+	switch {
+	case f.key == "-":
+		return "-,"
+	case !isValidJSONTag(f.key):
+		g.model.note(f.offset, "键 %q 无法写为 encoding/json 的字段标签，生成的字段不参与编解码，需要自行实现 MarshalJSON 和 UnmarshalJSON", f.key)
+		return "-"
+	}
+	return f.key
+}
+
+// isValidJSONTag 与 encoding/json 判断标签名称是否有效的规则相同，无效时 encoding/json 改用字段名
+func isValidJSONTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// 允许的标点符号，不含引号、反斜杠和逗号
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// goTag 结构体标签的字面量，含有反引号时使用双引号字符串
+func goTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// goIdent 把键转换为导出的Go标识符：按非字母数字字符和驼峰拆分为单词，首字母大写，常见缩写全部大写
+func goIdent(key string) string {
+	var sb strings.Builder
+	for _, word := range splitWords(key) {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		sb.WriteRune(unicode.ToUpper(runes[0]))
+		sb.WriteString(string(runes[1:]))
+	}
+
+	name := sb.String()
+	if name == "" {
+		return "Field"
+	}
+	first := []rune(name)[0]
+	switch {
+	case unicode.IsDigit(first):
+		return "Field" + name
+	case !unicode.IsUpper(first):
+		// 没有大小写的文字（如中文）开头时无法导出
+		return "X" + name
+	}
+	return name
+}
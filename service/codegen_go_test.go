@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGo(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    CodegenOptions
+		want    string
+		notes   []string // 每条说明中应包含的文字
+		wantErr string
+	}{
+		{
+			name:  "合并数组元素中的可选字段",
+			input: `{"user_id":1,"items":[{"sku":"a","price":1},{"sku":"b","price":2.5,"tags":["x"]}],"big":18446744073709551615}`,
+			want: "type Root struct {\n" +
+				"\tUserID int64       `json:\"user_id\"`\n" +
+				"\tItems  []Item      `json:\"items\"`\n" +
+				"\tBig    json.Number `json:\"big\"`\n" +
+				"}\n\n" +
+				"type Item struct {\n" +
+				"\tSku   string   `json:\"sku\"`\n" +
+				"\tPrice float64  `json:\"price\"`\n" +
+				"\tTags  []string `json:\"tags,omitempty\"`\n" +
+				"}",
+		},
+		{
+			name:  "可为null的字段使用指针并输出包名",
+			input: `[{"name":"a","age":null,"addr":{"city":"x"}},{"name":null,"age":3,"addr":null}]`,
+			opts:  CodegenOptions{TypeName: "users", Package: "model", PointerNullable: true},
+			want: "package model\n\n" +
+				"type Users []User\n\n" +
+				"type User struct {\n" +
+				"\tName *string `json:\"name\"`\n" +
+				"\tAge  *int64  `json:\"age\"`\n" +
+				"\tAddr *Addr   `json:\"addr\"`\n" +
+				"}\n\n" +
+				"type Addr struct {\n" +
+				"\tCity string `json:\"city\"`\n" +
+				"}",
+		},
+		{
+			name:  "匿名结构体",
+			input: `{"a":{"b":[{"c":true}]},"e":{},"n":null}`,
+			opts:  CodegenOptions{Inline: true},
+			want: "type Root struct {\n" +
+				"\tA struct {\n" +
+				"\t\tB []struct {\n" +
+				"\t\t\tC bool `json:\"c\"`\n" +
+				"\t\t} `json:\"b\"`\n" +
+				"\t} `json:\"a\"`\n" +
+				"\tE map[string]any `json:\"e\"`\n" +
+				"\tN any            `json:\"n\"`\n" +
+				"}",
+		},
+		{
+			name:  "重名的类型加上外层类型名，结构相同的共用",
+			input: `{"from":{"meta":{"x":1}},"to":{"meta":{"y":"s"}},"cc":{"meta":{"x":2}}}`,
+			want: "type Root struct {\n" +
+				"\tFrom From `json:\"from\"`\n" +
+				"\tTo   To   `json:\"to\"`\n" +
+				"\tCc   Cc   `json:\"cc\"`\n" +
+				"}\n\n" +
+				"type From struct {\n" +
+				"\tMeta Meta `json:\"meta\"`\n" +
+				"}\n\n" +
+				"type Meta struct {\n" +
+				"\tX int64 `json:\"x\"`\n" +
+				"}\n\n" +
+				"type To struct {\n" +
+				"\tMeta ToMeta `json:\"meta\"`\n" +
+				"}\n\n" +
+				"type ToMeta struct {\n" +
+				"\tY string `json:\"y\"`\n" +
+				"}\n\n" +
+				"type Cc struct {\n" +
+				"\tMeta Meta `json:\"meta\"`\n" +
+				"}",
+		},
+		{
+			name:  "NDJSON中的多个样本和类型不一致",
+			input: "{\"id\":1,\"v\":\"a\",\"HTTPCode\":200,\"名称\":\"x\",\"2fa\":true}\n{\"id\":2,\"v\":3}\n",
+			opts:  CodegenOptions{Mode: ModeNDJSON},
+			want: "type Root struct {\n" +
+				"\tID       int64  `json:\"id\"`\n" +
+				"\tV        any    `json:\"v\"`\n" +
+				"\tHTTPCode int64  `json:\"HTTPCode,omitempty\"`\n" +
+				"\tX名称      string `json:\"名称,omitempty\"`\n" +
+				"\tField2fa bool   `json:\"2fa,omitempty\"`\n" +
+				"}",
+			notes: []string{"$.v 在样本中既是字符串又是整数"},
+		},
+		{
+			name:  "键为-或含有逗号",
+			input: "[{\"-\":1,\"a,b\":2},\n{\"-\":3}]",
+			want: "type Root []RootItem\n\n" +
+				"type RootItem struct {\n" +
+				"\tField int64 `json:\"-,\"`\n" +
+				"\tAB    int64 `json:\"-\"`\n" +
+				"}",
+			notes: []string{`键 "a,b" 无法写为 encoding/json 的字段标签`},
+		},
+		{
+			name:    "NDJSON中的语法错误",
+			input:   "{\"a\":1}\n{\"a\":}",
+			opts:    CodegenOptions{Mode: ModeNDJSON},
+			wantErr: "第 2 行",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.Generate(ctx, "go", tt.input, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("GenerateGo() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateGo() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("GenerateGo() =\n%s\nwant\n%s", result.Result, tt.want)
+			}
+			if len(result.Notes) != len(tt.notes) {
+				t.Fatalf("GenerateGo() notes = %v, want %d notes", result.Notes, len(tt.notes))
+			}
+			for i, note := range result.Notes {
+				if !strings.Contains(note.Message, tt.notes[i]) {
+					t.Errorf("note %d = %q, want containing %q", i, note.Message, tt.notes[i])
+				}
+			}
+		})
+	}
+}
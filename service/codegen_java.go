@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// javaKeywords Java 的关键字和字面量，不能作为字段名
+var javaKeywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true, "catch": true,
+	"char": true, "class": true, "const": true, "continue": true, "default": true, "do": true, "double": true,
+	"else": true, "enum": true, "extends": true, "false": true, "final": true, "finally": true, "float": true,
+	"for": true, "goto": true, "if": true, "implements": true, "import": true, "instanceof": true, "int": true,
+	"interface": true, "long": true, "native": true, "new": true, "null": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "short": true, "static": true, "strictfp": true,
+	"super": true, "switch": true, "synchronized": true, "this": true, "throw": true, "throws": true,
+	"transient": true, "true": true, "try": true, "void": true, "volatile": true, "_": true,
+}
+
+// emitJava 输出 Jackson 可以直接反序列化的 Java 类：默认为 Lombok 的 @Data 类，framework 为 record 时输出 record；
+// 顶层是对象时其余类型嵌套在顶层类型中，否则只有第一个类型是 public 的，可为null或可选的字段使用包装类型
+func emitJava(m *codeModel) (string, error) {
+	switch m.opts.Framework {
+	case "", "lombok", "record":
+	default:
+		return "", fmt.Errorf("不支持的代码风格: %s，Java 可选 lombok、record", m.opts.Framework)
+	}
+
+	g := &javaGenerator{record: m.opts.Framework == "record", imports: make(map[string]bool)}
+	var body string
+	if m.rootNamed() {
+		body = g.class(m.types[0], m.types[1:], "public ", 0)
+	} else {
+		// 顶层不是对象时无法声明为类，说明顶层的类型后依次输出各个类，一个文件中只能有一个 public 的顶层类型
+		decls := []string{"// " + m.name + ": " + g.expr(m.root, false)}
+		for i, t := range m.types {
+			modifier := ""
+			if i == 0 {
+				modifier = "public "
+			}
+			decls = append(decls, g.class(t, nil, modifier, 0))
+		}
+		body = strings.Join(decls, "\n\n")
+	}
+
+	var sb strings.Builder
+	if m.opts.Package != "" {
+		sb.WriteString("package " + m.opts.Package + ";\n\n")
+	}
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for imp := range g.imports {
+			imports = append(imports, "import "+imp+";")
+		}
+		sort.Strings(imports)
+		sb.WriteString(strings.Join(imports, "\n") + "\n\n")
+	}
+	sb.WriteString(body)
+	return sb.String(), nil
+}
+
+// javaGenerator 输出 Java 类型
+type javaGenerator struct {
+	record  bool
+	imports map[string]bool // 用到的类型需要导入的完整类名
+}
+
+// expr 类型表达式，boxed 为 true 时基本类型使用包装类型
+func (g *javaGenerator) expr(t *typeNode, boxed bool) string {
+	if t == nil {
+		return "Object"
+	}
+	boxed = boxed || t.nullable
+	switch t.kind {
+	case kindBool:
+		return javaPrimitive("boolean", "Boolean", boxed)
+	case kindInt:
+		return javaPrimitive("long", "Long", boxed)
+	case kindFloat:
+		return javaPrimitive("double", "Double", boxed)
+	case kindNumber:
+		g.imports["java.math.BigDecimal"] = true
+		return "BigDecimal"
+	case kindString:
+		return "String"
+	case kindArray:
+		g.imports["java.util.List"] = true
+		return "List<" + g.expr(t.elem, true) + ">"
+	case kindObject:
+		if len(t.fields) == 0 || t.name == "" {
+			g.imports["java.util.Map"] = true
+			return "Map<String, Object>"
+		}
+		return t.name
+	}
+	return "Object"
+}
+
+// class 类或 record 的声明，nested 中的类型作为静态内部类输出
+func (g *javaGenerator) class(t *typeNode, nested []*typeNode, modifier string, depth int) string {
+	indent := strings.Repeat("    ", depth)
+
+	names := fieldNames(t, javaIdent)
+	var sb strings.Builder
+	if g.record {
+		sb.WriteString(indent + modifier + "record " + t.name + "(\n")
+		for i, f := range t.fields {
+			sb.WriteString(indent + "    " + g.property(f, names[i]) + g.expr(f.typ, t.optional(f)) + " " + names[i])
+			if i < len(t.fields)-1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent + ") {\n")
+	} else {
+		g.imports["lombok.Data"] = true
+		sb.WriteString(indent + "@Data\n" + indent + modifier + "class " + t.name + " {\n")
+		for i, f := range t.fields {
+			if property := g.property(f, names[i]); property != "" {
+				sb.WriteString(indent + "    " + strings.TrimSuffix(property, " ") + "\n")
+			}
+			sb.WriteString(indent + "    private " + g.expr(f.typ, t.optional(f)) + " " + names[i] + ";\n")
+		}
+	}
+	nestedModifier := "public static "
+	if g.record {
+		// 嵌套的 record 隐式为 static
+		nestedModifier = "public "
+	}
+	for _, n := range nested {
+		sb.WriteString("\n" + g.class(n, nil, nestedModifier, depth+1) + "\n")
+	}
+	sb.WriteString(indent + "}")
+	return sb.String()
+}
+
+// property 字段名与键不同时的 @JsonProperty 注解，后面带一个空格
+func (g *javaGenerator) property(f *typeField, name string) string {
+	if name == f.key {
+		return ""
+	}
+	g.imports["com.fasterxml.jackson.annotation.JsonProperty"] = true
+	return "@JsonProperty(" + encodeJSONString(f.key, false, false) + ") "
+}
+
+// javaPrimitive 基本类型或其包装类型
+func javaPrimitive(primitive string, wrapper string, boxed bool) string {
+	if boxed {
+		return wrapper
+	}
+	return primitive
+}
+
+// javaIdent 把键转换为 camelCase 的字段名，与关键字相同时加上下划线
+func javaIdent(key string) string {
+	name := camelIdent(key)
+	if javaKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
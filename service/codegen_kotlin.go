@@ -0,0 +1,125 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+// kotlinKeywords Kotlin 的硬关键字，作为属性名时需要加反引号
+var kotlinKeywords = map[string]bool{
+	"as": true, "break": true, "class": true, "continue": true, "do": true, "else": true, "false": true,
+	"for": true, "fun": true, "if": true, "in": true, "interface": true, "is": true, "null": true,
+	"object": true, "package": true, "return": true, "super": true, "this": true, "throw": true,
+	"true": true, "try": true, "typealias": true, "typeof": true, "val": true, "var": true,
+	"when": true, "while": true,
+}
+
+// emitKotlin 输出 kotlinx.serialization 的 data class：键与属性名不同时加上 @SerialName，
+// 可选的属性默认为 null，类型不一致或未知的值使用 JsonElement
+func emitKotlin(m *codeModel) (string, error) {
+	g := &kotlinGenerator{imports: map[string]bool{"kotlinx.serialization.Serializable": true}}
+	var decls []string
+	if !m.rootNamed() {
+		decls = append(decls, "typealias "+m.name+" = "+g.expr(m.root))
+	}
+	for _, t := range m.types {
+		decls = append(decls, g.class(t))
+	}
+
+	var sb strings.Builder
+	if m.opts.Package != "" {
+		sb.WriteString("package " + m.opts.Package + "\n\n")
+	}
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, "import "+imp)
+	}
+	sort.Strings(imports)
+	sb.WriteString(strings.Join(imports, "\n") + "\n\n")
+	sb.WriteString(strings.Join(decls, "\n\n"))
+	return sb.String(), nil
+}
+
+// kotlinGenerator 输出 Kotlin 类型
+type kotlinGenerator struct {
+	imports map[string]bool // 用到的类型需要导入的完整类名
+}
+
+// expr 类型表达式，出现过 null 的类型加上 ?
+func (g *kotlinGenerator) expr(t *typeNode) string {
+	if t == nil {
+		return g.json("JsonElement") + "?"
+	}
+	var s string
+	switch t.kind {
+	case kindBool:
+		s = "Boolean"
+	case kindInt:
+		s = "Long"
+	case kindFloat:
+		s = "Double"
+	case kindNumber:
+		// 超出 Long 范围的整数保持原样
+		s = g.json("JsonPrimitive")
+	case kindString:
+		s = "String"
+	case kindArray:
+		s = "List<" + g.expr(t.elem) + ">"
+	case kindObject:
+		if len(t.fields) == 0 || t.name == "" {
+			s = g.json("JsonObject")
+		} else {
+			s = t.name
+		}
+	default:
+		return g.json("JsonElement") + "?"
+	}
+	if t.nullable {
+		s += "?"
+	}
+	return s
+}
+
+// json 导入 kotlinx.serialization.json 中的类型
+func (g *kotlinGenerator) json(name string) string {
+	g.imports["kotlinx.serialization.json."+name] = true
+	return name
+}
+
+// class data class 的声明
+func (g *kotlinGenerator) class(t *typeNode) string {
+	var sb strings.Builder
+	sb.WriteString("@Serializable\ndata class " + t.name + "(\n")
+	for i, name := range fieldNames(t, kotlinIdent) {
+		f := t.fields[i]
+		if strings.Trim(name, "`") != f.key {
+			g.imports["kotlinx.serialization.SerialName"] = true
+			sb.WriteString("    @SerialName(" + kotlinString(f.key) + ")\n")
+		}
+		typ := g.expr(f.typ)
+		sb.WriteString("    val " + name + ": " + typ)
+		if t.optional(f) {
+			if !strings.HasSuffix(typ, "?") {
+				sb.WriteByte('?')
+			}
+			sb.WriteString(" = null")
+		}
+		sb.WriteString(",\n")
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// kotlinString 带双引号的字符串字面量，$ 需要转义
+func kotlinString(s string) string {
+	return strings.ReplaceAll(encodeJSONString(s, false, false), "$", `\$`)
+}
+
+// kotlinIdent 把键转换为 camelCase 的属性名，与关键字相同时加上反引号
+func kotlinIdent(key string) string {
+	name := camelIdent(key)
+	if kotlinKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// pythonKeywords Python 的小写关键字，不能作为属性名
+var pythonKeywords = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "await": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true, "except": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true, "return": true,
+	"try": true, "while": true, "with": true, "yield": true,
+}
+
+// emitPython 输出 dataclass（默认）或 pydantic 模型：属性名为 snake_case，必需的属性在前，
+// 可选的属性默认为 None；类型不一致的值为 Union，取值少且重复出现的字符串为 Literal
+func emitPython(m *codeModel) (string, error) {
+	switch m.opts.Framework {
+	case "", "dataclass", "pydantic":
+	default:
+		return "", fmt.Errorf("不支持的代码风格: %s，Python 可选 dataclass、pydantic", m.opts.Framework)
+	}
+
+	g := &pythonGenerator{pydantic: m.opts.Framework == "pydantic", typing: make(map[string]bool)}
+	// 类属性的默认值在定义时求值，被引用的类在前，顶层在最后
+	var decls []string
+	for _, t := range dependencyOrder(m.types) {
+		decls = append(decls, g.class(t))
+	}
+	var alias string
+	if !m.rootNamed() {
+		alias = m.name + " = " + g.expr(m.root)
+	}
+
+	// 标准库在前，第三方库单独一组
+	imports := []string{"from __future__ import annotations", ""}
+	if g.field && !g.pydantic {
+		imports = append(imports, "from dataclasses import dataclass, field")
+	} else if len(decls) > 0 && !g.pydantic {
+		imports = append(imports, "from dataclasses import dataclass")
+	}
+	if g.decimal {
+		imports = append(imports, "from decimal import Decimal")
+	}
+	if len(g.typing) > 0 {
+		names := make([]string, 0, len(g.typing))
+		for name := range g.typing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		imports = append(imports, "from typing import "+strings.Join(names, ", "))
+	}
+	if g.pydantic && len(decls) > 0 {
+		if imports[len(imports)-1] != "" {
+			imports = append(imports, "")
+		}
+		if g.field {
+			imports = append(imports, "from pydantic import BaseModel, Field")
+		} else {
+			imports = append(imports, "from pydantic import BaseModel")
+		}
+	}
+
+	// 顶层定义之间空两行
+	result := strings.TrimSuffix(strings.Join(imports, "\n"), "\n")
+	if len(decls) > 0 {
+		result += "\n\n\n" + strings.Join(decls, "\n\n\n")
+	}
+	if alias != "" {
+		result += "\n\n\n" + alias
+	}
+	return result, nil
+}
+
+// pythonGenerator 输出 Python 类型注解
+type pythonGenerator struct {
+	pydantic bool
+	field    bool            // 是否用到了 field 或 Field
+	decimal  bool            // 是否用到了 Decimal
+	typing   map[string]bool // 用到的 typing 中的名称
+}
+
+// expr 类型注解，出现过 null 的类型为 Optional
+func (g *pythonGenerator) expr(t *typeNode) string {
+	if t == nil {
+		return g.use("Any")
+	}
+	var s string
+	switch t.kind {
+	case kindNull:
+		return "None"
+	case kindBool:
+		s = "bool"
+	case kindInt:
+		s = "int"
+	case kindFloat:
+		s = "float"
+	case kindNumber:
+		g.decimal = true
+		s = "Decimal"
+	case kindString:
+		s = "str"
+		if values := t.enum(); values != nil {
+			s = g.use("Literal") + "[" + strings.Join(quoteAll(values), ", ") + "]"
+		}
+	case kindArray:
+		s = g.use("List") + "[" + g.expr(t.elem) + "]"
+	case kindObject:
+		if len(t.fields) == 0 || t.name == "" {
+			s = g.use("Dict") + "[str, " + g.use("Any") + "]"
+		} else {
+			s = t.name
+		}
+	case kindMixed:
+		if len(t.variants) == 0 {
+			return g.use("Any")
+		}
+		parts := make([]string, 0, len(t.variants))
+		for _, v := range t.variants {
+			parts = append(parts, g.expr(v))
+		}
+		s = g.use("Union") + "[" + strings.Join(parts, ", ") + "]"
+	}
+	if t.nullable {
+		s = g.use("Optional") + "[" + s + "]"
+	}
+	return s
+}
+
+// use 记录用到的 typing 中的名称
+func (g *pythonGenerator) use(name string) string {
+	g.typing[name] = true
+	return name
+}
+
+// class 类的声明，有默认值的可选属性排在必需的属性之后
+func (g *pythonGenerator) class(t *typeNode) string {
+	var required, optional []string
+	for i, name := range fieldNames(t, pythonIdent) {
+		f := t.fields[i]
+		typ := g.expr(f.typ)
+		opt := t.optional(f)
+		if opt && !strings.HasPrefix(typ, "Optional[") && typ != "None" && typ != "Any" {
+			typ = g.use("Optional") + "[" + typ + "]"
+		}
+
+		line := "    " + name + ": " + typ
+		switch {
+		case name != f.key && g.pydantic:
+			g.field = true
+			if opt {
+				line += " = Field(None, alias=" + encodeJSONString(f.key, false, false) + ")"
+			} else {
+				line += " = Field(alias=" + encodeJSONString(f.key, false, false) + ")"
+			}
+		case name != f.key:
+			g.field = true
+			metadata := "metadata={\"json\": " + encodeJSONString(f.key, false, false) + "}"
+			if opt {
+				line += " = field(default=None, " + metadata + ")"
+			} else {
+				line += " = field(" + metadata + ")"
+			}
+		case opt:
+			line += " = None"
+		}
+
+		if opt {
+			optional = append(optional, line)
+		} else {
+			required = append(required, line)
+		}
+	}
+
+	header := "@dataclass\nclass " + t.name + ":\n"
+	if g.pydantic {
+		header = "class " + t.name + "(BaseModel):\n"
+	}
+	return header + strings.Join(append(required, optional...), "\n")
+}
+
+// pythonIdent 把键转换为 snake_case 的属性名，与关键字相同时加上下划线
+func pythonIdent(key string) string {
+	name := snakeIdent(key)
+	if pythonKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"strings"
+)
+
+// rustKeywords Rust 的关键字，作为字段名时使用原始标识符 r#
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true, "continue": true, "dyn": true,
+	"else": true, "enum": true, "extern": true, "false": true, "fn": true, "for": true, "if": true,
+	"impl": true, "in": true, "let": true, "loop": true, "match": true, "mod": true, "move": true,
+	"mut": true, "pub": true, "ref": true, "return": true, "static": true, "struct": true, "trait": true,
+	"true": true, "type": true, "unsafe": true, "use": true, "where": true, "while": true, "abstract": true,
+	"become": true, "box": true, "do": true, "final": true, "macro": true, "override": true, "priv": true,
+	"try": true, "typeof": true, "unsized": true, "virtual": true, "yield": true,
+}
+
+// rustReserved 不能写为原始标识符的关键字
+var rustReserved = map[string]bool{"crate": true, "self": true, "super": true, "_": true}
+
+// emitRust 输出 serde 的结构体：字段名为 snake_case，与键不同时加上 #[serde(rename)]；
+// 可为null或可选的字段为 Option，类型不一致或未知的值使用 serde_json::Value
+func emitRust(m *codeModel) (string, error) {
+	decls := []string{"use serde::{Deserialize, Serialize};"}
+	if !m.rootNamed() {
+		decls = append(decls, "pub type "+m.name+" = "+rustExpr(m.root)+";")
+	}
+	for _, t := range m.types {
+		decls = append(decls, rustStruct(t))
+	}
+	return strings.Join(decls, "\n\n"), nil
+}
+
+// rustExpr 类型表达式，出现过 null 的类型为 Option
+func rustExpr(t *typeNode) string {
+	if t == nil {
+		return "serde_json::Value"
+	}
+	var s string
+	switch t.kind {
+	case kindBool:
+		s = "bool"
+	case kindInt:
+		s = "i64"
+	case kindFloat:
+		s = "f64"
+	case kindNumber:
+		s = "serde_json::Number"
+	case kindString:
+		s = "String"
+	case kindArray:
+		s = "Vec<" + rustExpr(t.elem) + ">"
+	case kindObject:
+		if len(t.fields) == 0 || t.name == "" {
+			s = "serde_json::Map<String, serde_json::Value>"
+		} else {
+			s = t.name
+		}
+	default:
+		// serde_json::Value 本身可以表示 null
+		return "serde_json::Value"
+	}
+	if t.nullable {
+		s = "Option<" + s + ">"
+	}
+	return s
+}
+
+// rustStruct 结构体的声明，可选字段在序列化时省略
+func rustStruct(t *typeNode) string {
+	var sb strings.Builder
+	sb.WriteString("#[derive(Debug, Clone, Serialize, Deserialize)]\npub struct " + t.name + " {\n")
+	for i, name := range fieldNames(t, rustIdent) {
+		f := t.fields[i]
+		typ := rustExpr(f.typ)
+		var attrs []string
+		if strings.TrimPrefix(name, "r#") != f.key {
+			attrs = append(attrs, "rename = "+encodeJSONString(f.key, false, false))
+		}
+		if t.optional(f) {
+			if !strings.HasPrefix(typ, "Option<") && typ != "serde_json::Value" {
+				typ = "Option<" + typ + ">"
+			}
+			attrs = append(attrs, "default", `skip_serializing_if = "Option::is_none"`)
+			if typ == "serde_json::Value" {
+				attrs[len(attrs)-1] = `skip_serializing_if = "serde_json::Value::is_null"`
+			}
+		}
+		if len(attrs) > 0 {
+			sb.WriteString("    #[serde(" + strings.Join(attrs, ", ") + ")]\n")
+		}
+		sb.WriteString("    pub " + name + ": " + typ + ",\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// rustIdent 把键转换为 snake_case 的字段名，与关键字相同时使用原始标识符
+func rustIdent(key string) string {
+	name := snakeIdent(key)
+	switch {
+	case rustReserved[name]:
+		return name + "_"
+	case rustKeywords[name]:
+		return "r#" + name
+	}
+	return name
+}
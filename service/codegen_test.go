@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+
+	const users = `[
+  {"userID":1,"name":"a","score":9.5,"addr":{"city":"x"},"class":"vip"},
+  {"userID":2,"name":null,"score":3,"addr":{"city":"y","zip":"100"},"tags":["t"]}
+]`
+
+	tests := []struct {
+		name    string
+		lang    string
+		input   string
+		opts    CodegenOptions
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Java Lombok类嵌套在顶层类中",
+			lang:  "java",
+			input: `{"user_id":1,"ok":true,"addr":{"city":"x"},"big":18446744073709551615,"items":[{"n":null}]}`,
+			opts:  CodegenOptions{Package: "com.example"},
+			want: "package com.example;\n\n" +
+				"import com.fasterxml.jackson.annotation.JsonProperty;\n" +
+				"import java.math.BigDecimal;\n" +
+				"import java.util.List;\n" +
+				"import lombok.Data;\n\n" +
+				"@Data\n" +
+				"public class Root {\n" +
+				"    @JsonProperty(\"user_id\")\n" +
+				"    private long userId;\n" +
+				"    private boolean ok;\n" +
+				"    private Addr addr;\n" +
+				"    private BigDecimal big;\n" +
+				"    private List<Item> items;\n\n" +
+				"    @Data\n" +
+				"    public static class Addr {\n" +
+				"        private String city;\n" +
+				"    }\n\n" +
+				"    @Data\n" +
+				"    public static class Item {\n" +
+				"        private Object n;\n" +
+				"    }\n" +
+				"}",
+		},
+		{
+			name:  "Java record，可选和可为null的字段使用包装类型",
+			lang:  "java",
+			input: users,
+			opts:  CodegenOptions{TypeName: "users", Framework: "record"},
+			want: "import com.fasterxml.jackson.annotation.JsonProperty;\n" +
+				"import java.util.List;\n\n" +
+				"// Users: List<User>\n\n" +
+				"public record User(\n" +
+				"    @JsonProperty(\"userID\") long userId,\n" +
+				"    String name,\n" +
+				"    double score,\n" +
+				"    Addr addr,\n" +
+				"    @JsonProperty(\"class\") String class_,\n" +
+				"    List<String> tags\n" +
+				") {\n" +
+				"}\n\n" +
+				"record Addr(\n" +
+				"    String city,\n" +
+				"    String zip\n" +
+				") {\n" +
+				"}",
+		},
+		{
+			name:  "Kotlin data class",
+			lang:  "kt",
+			input: users,
+			opts:  CodegenOptions{TypeName: "users", Package: "com.example"},
+			want: "package com.example\n\n" +
+				"import kotlinx.serialization.SerialName\n" +
+				"import kotlinx.serialization.Serializable\n\n" +
+				"typealias Users = List<User>\n\n" +
+				"@Serializable\n" +
+				"data class User(\n" +
+				"    @SerialName(\"userID\")\n" +
+				"    val userId: Long,\n" +
+				"    val name: String?,\n" +
+				"    val score: Double,\n" +
+				"    val addr: Addr,\n" +
+				"    val `class`: String? = null,\n" +
+				"    val tags: List<String>? = null,\n" +
+				")\n\n" +
+				"@Serializable\n" +
+				"data class Addr(\n" +
+				"    val city: String,\n" +
+				"    val zip: String? = null,\n" +
+				")",
+		},
+		{
+			name:  "Kotlin以数字开头和空的键",
+			lang:  "kotlin",
+			input: `{"2fa":1,"":"s"}`,
+			want: "import kotlinx.serialization.SerialName\n" +
+				"import kotlinx.serialization.Serializable\n\n" +
+				"@Serializable\n" +
+				"data class Root(\n" +
+				"    @SerialName(\"2fa\")\n" +
+				"    val field2fa: Long,\n" +
+				"    @SerialName(\"\")\n" +
+				"    val field: String,\n" +
+				")",
+		},
+		{
+			name:  "Rust serde结构体",
+			lang:  "rust",
+			input: users,
+			opts:  CodegenOptions{TypeName: "users"},
+			want: "use serde::{Deserialize, Serialize};\n\n" +
+				"pub type Users = Vec<User>;\n\n" +
+				"#[derive(Debug, Clone, Serialize, Deserialize)]\n" +
+				"pub struct User {\n" +
+				"    #[serde(rename = \"userID\")]\n" +
+				"    pub user_id: i64,\n" +
+				"    pub name: Option<String>,\n" +
+				"    pub score: f64,\n" +
+				"    pub addr: Addr,\n" +
+				"    #[serde(default, skip_serializing_if = \"Option::is_none\")]\n" +
+				"    pub class: Option<String>,\n" +
+				"    #[serde(default, skip_serializing_if = \"Option::is_none\")]\n" +
+				"    pub tags: Option<Vec<String>>,\n" +
+				"}\n\n" +
+				"#[derive(Debug, Clone, Serialize, Deserialize)]\n" +
+				"pub struct Addr {\n" +
+				"    pub city: String,\n" +
+				"    #[serde(default, skip_serializing_if = \"Option::is_none\")]\n" +
+				"    pub zip: Option<String>,\n" +
+				"}",
+		},
+		{
+			name:  "Rust关键字使用原始标识符",
+			lang:  "rs",
+			input: `{"type":"a","self":1,"x":[1,"b"]}`,
+			want: "use serde::{Deserialize, Serialize};\n\n" +
+				"#[derive(Debug, Clone, Serialize, Deserialize)]\n" +
+				"pub struct Root {\n" +
+				"    pub r#type: String,\n" +
+				"    #[serde(rename = \"self\")]\n" +
+				"    pub self_: i64,\n" +
+				"    pub x: Vec<serde_json::Value>,\n" +
+				"}",
+		},
+		{
+			name:  "Python dataclass，被引用的类在前，可选属性在后",
+			lang:  "python",
+			input: users,
+			opts:  CodegenOptions{TypeName: "users"},
+			want: "from __future__ import annotations\n\n" +
+				"from dataclasses import dataclass, field\n" +
+				"from typing import List, Optional\n\n\n" +
+				"@dataclass\n" +
+				"class Addr:\n" +
+				"    city: str\n" +
+				"    zip: Optional[str] = None\n\n\n" +
+				"@dataclass\n" +
+				"class User:\n" +
+				"    user_id: int = field(metadata={\"json\": \"userID\"})\n" +
+				"    name: Optional[str]\n" +
+				"    score: float\n" +
+				"    addr: Addr\n" +
+				"    class_: Optional[str] = field(default=None, metadata={\"json\": \"class\"})\n" +
+				"    tags: Optional[List[str]] = None\n\n\n" +
+				"Users = List[User]",
+		},
+		{
+			name:  "Python pydantic模型和联合类型",
+			lang:  "py",
+			input: `{"firstName":"a","v":[1,"s",null],"s":{"k":1},"e":{}}`,
+			opts:  CodegenOptions{Framework: "pydantic"},
+			want: "from __future__ import annotations\n\n" +
+				"from typing import Any, Dict, List, Optional, Union\n\n" +
+				"from pydantic import BaseModel, Field\n\n\n" +
+				"class S(BaseModel):\n" +
+				"    k: int\n\n\n" +
+				"class Root(BaseModel):\n" +
+				"    first_name: str = Field(alias=\"firstName\")\n" +
+				"    v: List[Optional[Union[int, str]]]\n" +
+				"    s: S\n" +
+				"    e: Dict[str, Any]",
+		},
+		{
+			name:    "不支持的代码风格",
+			lang:    "java",
+			input:   `{}`,
+			opts:    CodegenOptions{Framework: "pydantic"},
+			wantErr: "不支持的代码风格",
+		},
+		{
+			name:    "不支持的语言",
+			lang:    "cobol",
+			input:   `{}`,
+			wantErr: "不支持的语言: cobol",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.Generate(ctx, tt.lang, tt.input, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Generate() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("Generate() =\n%s\nwant\n%s", result.Result, tt.want)
+			}
+		})
+	}
+}
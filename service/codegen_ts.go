@@ -0,0 +1,208 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// emitTypeScript 输出 TypeScript 声明，或 Zod schema 及由其推导的类型；只在部分对象中出现的字段为可选属性，
+// 类型不一致的值为联合类型，取值少且重复出现的字符串为字面量联合类型
+func emitTypeScript(m *codeModel) (string, error) {
+	switch m.opts.Declaration {
+	case "", "interface", "type":
+	default:
+		return "", fmt.Errorf("不支持的声明方式: %s，可选 interface、type", m.opts.Declaration)
+	}
+
+	g := &tsGenerator{opts: m.opts}
+	var decls []string
+	if m.opts.Zod {
+		// const 需要先定义后使用，被引用的 schema 在前，顶层在最后
+		for _, t := range dependencyOrder(m.types) {
+			decls = append(decls, g.zodDecl(t.name, "z.object("+g.zodFields(t, 0)+")"))
+		}
+		if !m.rootNamed() {
+			decls = append(decls, g.zodDecl(m.name, g.zodExpr(m.root, 0)))
+		}
+		decls = append([]string{`import { z } from "zod";`}, decls...)
+	} else {
+		if !m.rootNamed() {
+			decls = append(decls, "export type "+m.name+" = "+g.tsExpr(m.root, 0)+";")
+		}
+		for _, t := range m.types {
+			if m.opts.Declaration == "type" {
+				decls = append(decls, "export type "+t.name+" = "+g.tsFields(t, 0)+";")
+			} else {
+				decls = append(decls, "export interface "+t.name+" "+g.tsFields(t, 0))
+			}
+		}
+	}
+	return strings.Join(decls, "\n\n"), nil
+}
+
+// tsGenerator 输出 TypeScript 类型和 Zod schema
+type tsGenerator struct {
+	opts CodegenOptions
+}
+
+// tsExpr TypeScript 类型表达式，未命名的对象展开为对象字面量类型
+func (g *tsGenerator) tsExpr(t *typeNode, depth int) string {
+	if t == nil {
+		return "unknown"
+	}
+	var s string
+	switch t.kind {
+	case kindNull:
+		return "null"
+	case kindBool:
+		s = "boolean"
+	case kindInt, kindFloat, kindNumber:
+		s = "number"
+	case kindString:
+		s = "string"
+		if values := t.enum(); values != nil {
+			s = strings.Join(quoteAll(values), " | ")
+		}
+	case kindArray:
+		s = g.tsExpr(t.elem, depth)
+		if isUnionType(t.elem) {
+			s = "(" + s + ")"
+		}
+		s += "[]"
+	case kindObject:
+		switch {
+		case len(t.fields) == 0:
+			s = "Record<string, unknown>"
+		case t.name != "":
+			s = t.name
+		default:
+			s = g.tsFields(t, depth)
+		}
+	case kindMixed:
+		parts := make([]string, 0, len(t.variants))
+		for _, v := range t.variants {
+			parts = append(parts, g.tsExpr(v, depth))
+		}
+		s = strings.Join(parts, " | ")
+	}
+	if t.nullable {
+		s += " | null"
+	}
+	return s
+}
+
+// tsFields 对象字面量类型，可选字段加上 ?
+func (g *tsGenerator) tsFields(t *typeNode, depth int) string {
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for _, f := range t.fields {
+		sb.WriteString(strings.Repeat("  ", depth+1) + tsKey(f.key))
+		if t.optional(f) {
+			sb.WriteByte('?')
+		}
+		sb.WriteString(": " + g.tsExpr(f.typ, depth+1) + ";\n")
+	}
+	sb.WriteString(strings.Repeat("  ", depth) + "}")
+	return sb.String()
+}
+
+// zodDecl 声明名为 name 的 schema 及由其推导的类型
+func (g *tsGenerator) zodDecl(name string, expr string) string {
+	return "export const " + name + "Schema = " + expr + ";\n" +
+		"export type " + name + " = z.infer<typeof " + name + "Schema>;"
+}
+
+// zodExpr Zod schema 表达式，未命名的对象展开为 z.object
+func (g *tsGenerator) zodExpr(t *typeNode, depth int) string {
+	if t == nil {
+		return "z.unknown()"
+	}
+	var s string
+	switch t.kind {
+	case kindNull:
+		return "z.null()"
+	case kindBool:
+		s = "z.boolean()"
+	case kindInt:
+		s = "z.number().int()"
+	case kindFloat, kindNumber:
+		s = "z.number()"
+	case kindString:
+		values := t.enum()
+		switch len(values) {
+		case 0:
+			s = "z.string()"
+		case 1:
+			s = "z.literal(" + quoteAll(values)[0] + ")"
+		default:
+			s = "z.enum([" + strings.Join(quoteAll(values), ", ") + "])"
+		}
+	case kindArray:
+		s = "z.array(" + g.zodExpr(t.elem, depth) + ")"
+	case kindObject:
+		switch {
+		case len(t.fields) == 0:
+			s = "z.record(z.string(), z.unknown())"
+		case t.name != "":
+			s = t.name + "Schema"
+		default:
+			s = "z.object(" + g.zodFields(t, depth) + ")"
+		}
+	case kindMixed:
+		parts := make([]string, 0, len(t.variants))
+		for _, v := range t.variants {
+			parts = append(parts, g.zodExpr(v, depth))
+		}
+		s = "z.union([" + strings.Join(parts, ", ") + "])"
+	}
+	if t.nullable {
+		s += ".nullable()"
+	}
+	return s
+}
+
+// zodFields z.object 的字段，可选字段加上 .optional()
+func (g *tsGenerator) zodFields(t *typeNode, depth int) string {
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for _, f := range t.fields {
+		sb.WriteString(strings.Repeat("  ", depth+1) + tsKey(f.key) + ": " + g.zodExpr(f.typ, depth+1))
+		if t.optional(f) {
+			sb.WriteString(".optional()")
+		}
+		sb.WriteString(",\n")
+	}
+	sb.WriteString(strings.Repeat("  ", depth) + "}")
+	return sb.String()
+}
+
+// isUnionType 类型表达式是否为联合类型，作为数组元素时需要加括号
+func isUnionType(t *typeNode) bool {
+	if t == nil || t.kind == kindNull {
+		return false
+	}
+	return t.nullable || t.kind == kindMixed || len(t.enum()) > 1
+}
+
+// quoteAll 把字符串写为带双引号的字面量
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = encodeJSONString(v, false, false)
+	}
+	return quoted
+}
+
+// tsKey 属性名，不是合法的标识符时加上引号
+func tsKey(key string) string {
+	for i, r := range key {
+		if !(unicode.IsLetter(r) || r == '_' || r == '$' || (i > 0 && unicode.IsDigit(r))) {
+			return encodeJSONString(key, false, false)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
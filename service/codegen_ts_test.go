@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	ctx := context.Background()
+
+	const orders = `[
+  {"id":1,"status":"paid","total":9.5,"items":[{"sku":"a","qty":1}],"tags":["x",1],"first-name":"a"},
+  {"id":2,"status":"refunded","total":null,"items":[{"sku":"b","qty":2,"note":"gift"}],"tags":[]},
+  {"id":3,"status":"paid","total":3,"items":[],"tags":[null]}
+]`
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    CodegenOptions
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "接口、可选属性、联合类型和字面量联合类型",
+			input: orders,
+			opts:  CodegenOptions{TypeName: "orders"},
+			want: "export type Orders = Order[];\n\n" +
+				"export interface Order {\n" +
+				"  id: number;\n" +
+				"  status: \"paid\" | \"refunded\";\n" +
+				"  total: number | null;\n" +
+				"  items: Item[];\n" +
+				"  tags: (string | number | null)[];\n" +
+				"  \"first-name\"?: string;\n" +
+				"}\n\n" +
+				"export interface Item {\n" +
+				"  sku: string;\n" +
+				"  qty: number;\n" +
+				"  note?: string;\n" +
+				"}",
+		},
+		{
+			name:  "type声明和匿名对象",
+			input: `{"a":{"b":[{"c":true}]},"e":{},"n":null}`,
+			opts:  CodegenOptions{Declaration: "type", Inline: true},
+			want: "export type Root = {\n" +
+				"  a: {\n" +
+				"    b: {\n" +
+				"      c: boolean;\n" +
+				"    }[];\n" +
+				"  };\n" +
+				"  e: Record<string, unknown>;\n" +
+				"  n: null;\n" +
+				"};",
+		},
+		{
+			name:  "Zod schema按引用顺序输出",
+			input: orders,
+			opts:  CodegenOptions{TypeName: "orders", Zod: true},
+			want: "import { z } from \"zod\";\n\n" +
+				"export const ItemSchema = z.object({\n" +
+				"  sku: z.string(),\n" +
+				"  qty: z.number().int(),\n" +
+				"  note: z.string().optional(),\n" +
+				"});\n" +
+				"export type Item = z.infer<typeof ItemSchema>;\n\n" +
+				"export const OrderSchema = z.object({\n" +
+				"  id: z.number().int(),\n" +
+				"  status: z.enum([\"paid\", \"refunded\"]),\n" +
+				"  total: z.number().nullable(),\n" +
+				"  items: z.array(ItemSchema),\n" +
+				"  tags: z.array(z.union([z.string(), z.number().int()]).nullable()),\n" +
+				"  \"first-name\": z.string().optional(),\n" +
+				"});\n" +
+				"export type Order = z.infer<typeof OrderSchema>;\n\n" +
+				"export const OrdersSchema = z.array(OrderSchema);\n" +
+				"export type Orders = z.infer<typeof OrdersSchema>;",
+		},
+		{
+			name:  "关闭字面量联合类型",
+			input: `[{"s":"a"},{"s":"a"}]`,
+			opts:  CodegenOptions{EnumLimit: -1, Zod: true, Inline: true},
+			want: "import { z } from \"zod\";\n\n" +
+				"export const RootSchema = z.array(z.object({\n" +
+				"  s: z.string(),\n" +
+				"}));\n" +
+				"export type Root = z.infer<typeof RootSchema>;",
+		},
+		{
+			name:    "不支持的声明方式",
+			input:   `{}`,
+			opts:    CodegenOptions{Declaration: "class"},
+			wantErr: "不支持的声明方式",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.Generate(ctx, "typescript", tt.input, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("GenerateTypeScript() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateTypeScript() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("GenerateTypeScript() =\n%s\nwant\n%s", result.Result, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package service
+
+// 以下类型由 YAML、TOML、XML、CSV 等格式与 JSON 之间的转换共用
+
+// ConversionNote 转换过程中对无法直接对应的特性所做处理的说明
+type ConversionNote struct {
+	Line    int    // 行号，从1开始
+	Column  int    // 列号，从1开始
+	Message string // 说明
+}
+
+// ConversionResult 与其他格式之间的转换结果
+type ConversionResult struct {
+	Result    string
+	Documents int              // 文档数
+	Notes     []ConversionNote // 锚点、标签、重名的列等无法直接对应的写法的处理说明
+}
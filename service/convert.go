@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"sojson/zlog"
+)
+
+// SourceLanguage 待转换文本的来源语言
+type SourceLanguage string
+
+const (
+	// LanguageAuto 自动识别
+	LanguageAuto SourceLanguage = "auto"
+	// LanguageJSON 已经是JSON
+	LanguageJSON SourceLanguage = "json"
+	// LanguagePython Python repr 输出，如 {'a': True, 'b': None}
+	LanguagePython SourceLanguage = "python"
+	// LanguageJavaScript Node util.inspect / console.log 输出，如 { a: 1, b: [Object] }
+	LanguageJavaScript SourceLanguage = "javascript"
+	// LanguageRuby Ruby inspect 输出，如 {:a=>1, "b"=>nil}
+	LanguageRuby SourceLanguage = "ruby"
+	// LanguageGo Go fmt 的 %v、%+v、%#v 输出，如 map[a:1] 或 {Name:Alice Age:30}
+	LanguageGo SourceLanguage = "go"
+	// LanguageJava Java toString 输出，如 Lombok 的 User(id=1, name=foo) 或 Map 的 {k=v}
+	LanguageJava SourceLanguage = "java"
+)
+
+// languageAliases 来源语言的别名
+var languageAliases = map[string]SourceLanguage{
+	"":           LanguageAuto,
+	"auto":       LanguageAuto,
+	"json":       LanguageJSON,
+	"python":     LanguagePython,
+	"py":         LanguagePython,
+	"javascript": LanguageJavaScript,
+	"js":         LanguageJavaScript,
+	"node":       LanguageJavaScript,
+	"ruby":       LanguageRuby,
+	"rb":         LanguageRuby,
+	"go":         LanguageGo,
+	"golang":     LanguageGo,
+	"java":       LanguageJava,
+	"lombok":     LanguageJava,
+	"kotlin":     LanguageJava,
+}
+
+// ParseSourceLanguage 解析来源语言名称，空字符串表示自动识别
+func ParseSourceLanguage(name string) (SourceLanguage, error) {
+	lang, ok := languageAliases[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("不支持的来源语言: %s", name)
+	}
+	return lang, nil
+}
+
+// literalDecoders 各语言的字面量解码器，把调试输出解析为语法树
+var literalDecoders = map[SourceLanguage]func(text string) (*jsonNode, error){
+	LanguageJSON:       parseJSONTree,
+	LanguagePython:     decodePythonLiteral,
+	LanguageJavaScript: decodeJavaScriptLiteral,
+	LanguageRuby:       decodeRubyLiteral,
+	LanguageGo:         decodeGoLiteral,
+	LanguageJava:       decodeJavaLiteral,
+}
+
+// ConvertResult 转换结果
+type ConvertResult struct {
+	Result   string         // 格式化后的JSON
+	Language SourceLanguage // 实际使用的来源语言，自动识别时为识别出的语言
+}
+
+// ConvertToJSON 把 Python、JavaScript、Ruby、Go、Java 打印出的字面量转换为JSON
+// lang 为 LanguageAuto 时按特征依次尝试各语言，使用第一个能完整解析的结果
+func (s *jsonProcessorService) ConvertToJSON(ctx context.Context, text string, lang SourceLanguage, opts FormatOptions) (*ConvertResult, error) {
+	candidates := []SourceLanguage{lang}
+	if lang == LanguageAuto {
+		candidates = detectLanguages(text)
+	}
+
+	var firstErr error
+	for _, candidate := range candidates {
+		decode, ok := literalDecoders[candidate]
+		if !ok {
+			return nil, fmt.Errorf("不支持的来源语言: %s", candidate)
+		}
+
+		tree, err := decode(text)
+		if err == nil && lang == LanguageAuto && candidate != LanguageJSON && tree.kind != nodeObject && tree.kind != nodeArray {
+			// 自动识别时只接受对象和数组，否则任意文本都会被当作未加引号的字符串
+			err = fmt.Errorf("%s 解码结果不是对象或数组", candidate)
+		}
+		if err != nil {
+			zlog.Debugf(ctx, "ConvertToJSON: decode failed, language: %s, error: %v", candidate, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		result := formatJSONTree(tree, opts)
+		zlog.Infof(ctx, "ConvertToJSON: successfully converted, language: %s, input length: %d, output length: %d", candidate, len(text), len(result))
+		return &ConvertResult{Result: result, Language: candidate}, nil
+	}
+
+	zlog.Errorf(ctx, "ConvertToJSON: all decoders failed, language: %s, input text length: %d, error: %v", lang, len(text), firstErr)
+	return nil, firstErr
+}
+
+// 自动识别时使用的语言特征
+var languageHints = []struct {
+	lang    SourceLanguage
+	pattern *regexp.Regexp
+}{
+	{LanguagePython, regexp.MustCompile(`\b(True|False|None)\b|\(\s*\)|,\s*\)`)},
+	{LanguageJavaScript, regexp.MustCompile(`\bundefined\b|\[(Object|Array|Function|Circular|Getter|class)\b|\b(Map|Set)\(\d+\)|\d+n\b|<ref \*\d+>`)},
+	{LanguageRuby, regexp.MustCompile(`=>|#<|\bnil\b|[{,\[]\s*:[A-Za-z_"]`)},
+	{LanguageGo, regexp.MustCompile(`\bmap\[|<nil>|&\{|^\{[A-Za-z_]\w*:\S|[\w\]]\{`)},
+	{LanguageJava, regexp.MustCompile(`[\w$][(\[{]\s*[A-Za-z_$][\w$]*=|[{,]\s*[^\s,=>{}()\[\]]+=[^>]`)},
+}
+
+// detectLanguages 按特征给出尝试顺序：严格JSON优先，其次是命中特征的语言，最后是其余语言
+func detectLanguages(text string) []SourceLanguage {
+	text = strings.TrimSpace(text)
+	type scored struct {
+		lang  SourceLanguage
+		score int
+	}
+
+	candidates := make([]scored, 0, len(languageHints))
+	for _, hint := range languageHints {
+		candidates = append(candidates, scored{lang: hint.lang, score: len(hint.pattern.FindAllStringIndex(text, -1))})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	result := []SourceLanguage{LanguageJSON}
+	for _, c := range candidates {
+		result = append(result, c.lang)
+	}
+	return result
+}
+
+// literalParser 各语言解码器共用的扫描方法
+type literalParser struct {
+	jsonParser
+}
+
+func newLiteralParser(text string) literalParser {
+	return literalParser{jsonParser: jsonParser{data: text}}
+}
+
+// peek 返回当前字符，到达结尾时返回 0
+func (p *literalParser) peek() byte {
+	return p.peekAt(0)
+}
+
+// peekAt 返回当前位置之后第 i 个字符，超出结尾时返回 0
+func (p *literalParser) peekAt(i int) byte {
+	if p.pos+i < len(p.data) {
+		return p.data[p.pos+i]
+	}
+	return 0
+}
+
+// consume 当前位置是 s 时跳过它
+func (p *literalParser) consume(s string) bool {
+	if strings.HasPrefix(p.data[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// expect 跳过 s，不是 s 时报错
+func (p *literalParser) expect(s string) error {
+	if !p.consume(s) {
+		return p.unexpected(fmt.Sprintf("'%s'", s))
+	}
+	return nil
+}
+
+// finish 确认值之后只剩空白
+func (p *literalParser) finish(node *jsonNode) (*jsonNode, error) {
+	p.skipSpace()
+	if p.pos < len(p.data) {
+		return nil, p.unexpected("文本结尾")
+	}
+	return node, nil
+}
+
+// readIdent 读取标识符，不是标识符时返回空字符串
+func (p *literalParser) readIdent() string {
+	start := p.pos
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (p.pos > start && isDigit(c)) {
+			p.pos++
+			continue
+		}
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(p.data[p.pos:])
+			if isIdentRune(r, p.pos == start) {
+				p.pos += size
+				continue
+			}
+		}
+		break
+	}
+	return p.data[start:p.pos]
+}
+
+// readUntil 读取到 stops 中的任一字符（或结尾）为止，返回去掉首尾空白的文本
+func (p *literalParser) readUntil(stops string) string {
+	start := p.pos
+	for p.pos < len(p.data) && strings.IndexByte(stops, p.data[p.pos]) < 0 {
+		p.pos++
+	}
+	return strings.TrimSpace(p.data[start:p.pos])
+}
+
+// readBalanced 读取从 open 开始到与之匹配的 close 为止的原文，跳过其中的引号字符串
+func (p *literalParser) readBalanced(open byte, close byte) (string, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '"' || c == '\'' || c == '`':
+			if _, err := p.readQuoted(); err != nil {
+				return "", err
+			}
+			continue
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return p.data[start:p.pos], nil
+			}
+		}
+		p.pos++
+	}
+	p.pos = start
+	return "", p.errorf("缺少与 '%c' 匹配的 '%c'", open, close)
+}
+
+// readQuoted 读取引号字符串并解码转义，兼容 Python、JavaScript、Ruby 的常见转义写法
+func (p *literalParser) readQuoted() (string, error) {
+	start := p.pos
+	quote := p.data[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if err := p.readLiteralEscape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	p.pos = start
+	return "", p.errorf("字符串未结束")
+}
+
+// readLiteralEscape 解码一个转义序列，无法识别的转义保留原文
+func (p *literalParser) readLiteralEscape(sb *strings.Builder) error {
+	if p.pos+1 >= len(p.data) {
+		return p.errorf("字符串未结束")
+	}
+
+	c := p.data[p.pos+1]
+	p.pos += 2
+	switch c {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'v':
+		sb.WriteByte('\v')
+	case 'a':
+		sb.WriteByte('\a')
+	case 'e':
+		sb.WriteByte(0x1B)
+	case '\n':
+		// 续行
+	case 'x':
+		return p.writeHexEscape(sb, 2)
+	case 'U':
+		return p.writeHexEscape(sb, 8)
+	case 'u':
+		if p.peek() == '{' {
+			end := strings.IndexByte(p.data[p.pos:], '}')
+			if end < 0 {
+				return p.errorf(`\u{ 缺少 '}'`)
+			}
+			value, err := strconv.ParseUint(p.data[p.pos+1:p.pos+end], 16, 32)
+			if err != nil {
+				return p.errorf(`\u{} 中需要十六进制数字`)
+			}
+			sb.WriteRune(rune(value))
+			p.pos += end + 1
+			return nil
+		}
+		r, ok := parseHex4(p.data, p.pos)
+		if !ok {
+			return p.errorf(`\u 后需要 4 位十六进制数字`)
+		}
+		p.pos += 4
+		// JavaScript 和 Python 的字符串都可能用代理对表示辅助平面字符
+		if utf16.IsSurrogate(r) && p.consume(`\u`) {
+			if r2, ok := parseHex4(p.data, p.pos); ok {
+				p.pos += 4
+				r = utf16.DecodeRune(r, r2)
+			} else {
+				p.pos -= 2
+			}
+		}
+		sb.WriteRune(r)
+	default:
+		switch {
+		case '0' <= c && c <= '7':
+			// 八进制转义，最多 3 位
+			value := int(c - '0')
+			for i := 0; i < 2 && '0' <= p.peek() && p.peek() <= '7'; i++ {
+				value = value*8 + int(p.peek()-'0')
+				p.pos++
+			}
+			sb.WriteRune(rune(value))
+		case strings.IndexByte("'\"\\/`#$", c) >= 0:
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		}
+	}
+	return nil
+}
+
+// writeHexEscape 解码 n 位十六进制数字表示的码点
+func (p *literalParser) writeHexEscape(sb *strings.Builder, n int) error {
+	if p.pos+n > len(p.data) {
+		return p.errorf("转义序列不完整")
+	}
+	value, err := strconv.ParseUint(p.data[p.pos:p.pos+n], 16, 32)
+	if err != nil {
+		return p.errorf("转义序列中需要 %d 位十六进制数字", n)
+	}
+	sb.WriteRune(rune(value))
+	p.pos += n
+	return nil
+}
+
+// newStringNode 创建字符串节点
+func newStringNode(value string) *jsonNode {
+	return &jsonNode{kind: nodeString, raw: encodeJSONString(value, false, false)}
+}
+
+// newLiteralNode 创建 true、false、null 节点
+func newLiteralNode(raw string) *jsonNode {
+	return &jsonNode{kind: nodeLiteral, raw: raw}
+}
+
+// newNumberNode 把各语言的数字写法规范化为JSON数字，NaN 和 Infinity 转换为 null
+func newNumberNode(token string) (*jsonNode, bool) {
+	raw, ok := normalizeNumber(token)
+	if !ok {
+		return nil, false
+	}
+	if raw == "" {
+		return newLiteralNode("null"), true
+	}
+	return &jsonNode{kind: nodeNumber, raw: raw}, true
+}
+
+// normalizeNumber 规范化数字写法：下划线分隔、十六进制/八进制/二进制、.5、5.、+1 等，
+// 不是数字时返回 false，非有限值返回空字符串
+func normalizeNumber(token string) (string, bool) {
+	t := strings.TrimPrefix(token, "+")
+	if t == "" || !(isDigit(t[0]) || t[0] == '-' || t[0] == '.') {
+		return "", false
+	}
+	if node, err := parseJSONTree(t); err == nil && node.kind == nodeNumber {
+		return t, true
+	}
+
+	t = strings.ReplaceAll(t, "_", "")
+	sign := ""
+	if strings.HasPrefix(t, "-") {
+		sign, t = "-", t[1:]
+	}
+	if strings.HasPrefix(t, ".") {
+		t = "0" + t
+	}
+	if strings.HasSuffix(t, ".") {
+		t += "0"
+	}
+	if node, err := parseJSONTree(t); err == nil && node.kind == nodeNumber {
+		return sign + t, true
+	}
+	if value, ok := new(big.Int).SetString(t, 0); ok {
+		return sign + value.String(), true
+	}
+	if value, ok := new(big.Int).SetString(t, 10); ok {
+		// 带前导零的十进制整数
+		return sign + value.String(), true
+	}
+	value, err := strconv.ParseFloat(t, 64)
+	if err != nil || strings.ContainsAny(t, "iInN") {
+		return "", false
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return "", true
+	}
+	return sign + strconv.FormatFloat(value, 'g', -1, 64), true
+}
+
+// appendMember 向对象节点追加成员
+func appendMember(obj *jsonNode, key string, value *jsonNode) {
+	obj.members = append(obj.members, &jsonMember{key: encodeJSONString(key, false, false), value: value})
+}
+
+// nodeKeyText 把作为键的节点转换为键名：字符串取其内容，其他值取其JSON文本
+func nodeKeyText(node *jsonNode) string {
+	switch node.kind {
+	case nodeString:
+		return node.stringValue()
+	case nodeNumber, nodeLiteral:
+		return node.raw
+	}
+	return formatJSONTree(node, FormatOptions{})
+}
+
+// pairsToObject 把由二元组组成的数组转换为对象，如 Python 的 OrderedDict([('a', 1)])
+func pairsToObject(node *jsonNode) (*jsonNode, bool) {
+	if node.kind != nodeArray {
+		return nil, false
+	}
+	obj := &jsonNode{kind: nodeObject}
+	for _, e := range node.elements {
+		if e.kind != nodeArray || len(e.elements) != 2 {
+			return nil, false
+		}
+		appendMember(obj, nodeKeyText(e.elements[0]), e.elements[1])
+	}
+	return obj, true
+}
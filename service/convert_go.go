@@ -0,0 +1,401 @@
+package service
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// goTimePattern time.Time 的 %v 输出，如 2024-01-01 08:00:00.123 +0800 CST m=+0.000012
+var goTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?( [+-]\d{4})?( [A-Z][A-Za-z0-9+-]*)?( m=[+-]\d+\.\d+)?`)
+
+// goFieldPattern %+v 输出中结构体字段的开头，如 Name:
+var goFieldPattern = regexp.MustCompile(`^[A-Za-z_]\w*:`)
+
+// goMapKeyPattern %v 输出中 map 键的开头，如 key:
+var goMapKeyPattern = regexp.MustCompile(`^[^\s:\[\]{}]+:`)
+
+// goContext 值所在的上下文，决定未加引号的值在哪里结束
+type goContext int
+
+const (
+	goSyntax     goContext = iota // %#v 的 Go 语法，元素以逗号分隔，字符串带引号
+	goElement                     // %v 的切片元素或无字段名的结构体字段，以空格分隔
+	goMapValue                    // %v 的 map 值，到下一个 key: 为止
+	goFieldValue                  // %+v 的结构体字段值，到下一个 Field: 为止
+)
+
+// goParser Go fmt 输出的解码器
+type goParser struct {
+	literalParser
+}
+
+// decodeGoLiteral 解码 Go fmt 的输出：%v 和 %+v 的 map[a:1]、{Name:Alice Age:30}、&{...}、[1 2 3]，
+// 以及 %#v 的 main.User{Name:"Alice"}、map[string]int{"a":1}、[]string{"a"}
+func decodeGoLiteral(text string) (*jsonNode, error) {
+	p := &goParser{literalParser: newLiteralParser(text)}
+	p.skipSpace()
+	node, err := p.parseValue(goElement)
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(node)
+}
+
+func (p *goParser) parseValue(ctx goContext) (*jsonNode, error) {
+	p.consume("&")
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("Go值")
+	}
+	if p.consume("<nil>") {
+		return newLiteralNode("null"), nil
+	}
+
+	start := p.pos
+	switch c := p.peek(); {
+	case c == '"' || c == '`':
+		return p.parseString()
+	case c == '{':
+		if ctx == goSyntax {
+			return p.parseComposite(false, false)
+		}
+		return p.parseStruct()
+	case c == '(':
+		return p.parseConversion()
+	case c == '[' || strings.HasPrefix(p.data[p.pos:], "map[") || isLetter(c) || c == '_' || c == '*':
+		// %#v 的类型前缀，如 []string{、map[string]int{、main.User{、time.Duration(
+		if p.parseType() {
+			switch typ := strings.TrimLeft(p.data[start:p.pos], "*"); p.peek() {
+			case '{':
+				return p.parseComposite(strings.HasPrefix(typ, "map["), strings.HasPrefix(typ, "["))
+			case '(':
+				return p.parseCall(start)
+			}
+		}
+		p.pos = start
+		switch {
+		case c == '[':
+			return p.parseSlice()
+		case strings.HasPrefix(p.data[p.pos:], "map["):
+			return p.parseMap()
+		}
+	}
+	return p.parseBare(ctx)
+}
+
+// parseType 跳过 Go 类型表达式，返回是否读到了类型
+func (p *goParser) parseType() bool {
+	switch {
+	case p.consume("*"):
+		return p.parseType()
+	case p.consume("[]"):
+		return p.parseType()
+	case p.consume("map["):
+		if !p.parseType() || !p.consume("]") {
+			return false
+		}
+		return p.parseType()
+	case p.peek() == '[':
+		// 数组类型，如 [3]int
+		p.pos++
+		if !p.skipDigits() && !p.consume("...") {
+			return false
+		}
+		return p.consume("]") && p.parseType()
+	case p.consume("chan "):
+		return p.parseType()
+	case p.consume("struct {"), p.consume("interface {"), p.consume("func("):
+		p.pos--
+		open, close := p.data[p.pos], byte('}')
+		if open == '(' {
+			close = ')'
+		}
+		_, err := p.readBalanced(open, close)
+		return err == nil
+	}
+
+	if p.readIdent() == "" {
+		return false
+	}
+	for p.consume(".") {
+		if p.readIdent() == "" {
+			return false
+		}
+	}
+	// 泛型参数，如 main.Pair[int,string]
+	if p.peek() == '[' && p.peekAt(1) != ']' && !isDigit(p.peekAt(1)) {
+		save := p.pos
+		if _, err := p.readBalanced('[', ']'); err != nil || (p.peek() != '{' && p.peek() != '(') {
+			p.pos = save
+		}
+	}
+	return true
+}
+
+// parseComposite 解析 %#v 的复合字面量，元素为 Key:Value 时输出对象，否则输出数组；
+// 为空时按类型区分，切片和数组输出为数组
+func (p *goParser) parseComposite(isMap bool, isList bool) (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	obj := &jsonNode{kind: nodeObject}
+	arr := &jsonNode{kind: nodeArray}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			break
+		}
+
+		// 结构体字段名
+		if m := goFieldPattern.FindString(p.data[p.pos:]); m != "" && !isMap && !strings.HasPrefix(p.data[p.pos+len(m):], ":") {
+			p.pos += len(m)
+			value, err := p.parseValue(goSyntax)
+			if err != nil {
+				return nil, err
+			}
+			appendMember(obj, m[:len(m)-1], value)
+		} else {
+			value, err := p.parseValue(goSyntax)
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.consume(":") {
+				p.skipSpace()
+				mapValue, err := p.parseValue(goSyntax)
+				if err != nil {
+					return nil, err
+				}
+				appendMember(obj, nodeKeyText(value), mapValue)
+			} else {
+				arr.elements = append(arr.elements, value)
+			}
+		}
+
+		p.skipSpace()
+		if p.consume("}") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(arr.elements) > 0 {
+		if len(obj.members) > 0 {
+			return nil, p.errorf("复合字面量中不能同时出现键值对和元素")
+		}
+		return arr, nil
+	}
+	if isList {
+		return arr, nil
+	}
+	return obj, nil
+}
+
+// parseCall 解析类型转换或函数调用，如 main.Status(2)、time.Duration(5)、[]byte(nil)，
+// 只有一个参数时取该参数，否则输出原文
+func (p *goParser) parseCall(start int) (*jsonNode, error) {
+	argsStart := p.pos
+	p.pos++ // (
+	p.skipSpace()
+	if value, err := p.parseValue(goSyntax); err == nil {
+		p.skipSpace()
+		if p.consume(")") {
+			return value, nil
+		}
+	}
+
+	p.pos = argsStart
+	if _, err := p.readBalanced('(', ')'); err != nil {
+		return nil, err
+	}
+	return newStringNode(p.data[start:p.pos]), nil
+}
+
+// parseConversion 解析 (*main.T)(nil)、(*int)(0xc000012345) 等带括号的类型转换
+func (p *goParser) parseConversion() (*jsonNode, error) {
+	start := p.pos
+	if _, err := p.readBalanced('(', ')'); err != nil {
+		return nil, err
+	}
+	if p.peek() == '(' {
+		return p.parseCall(start)
+	}
+	return newStringNode(p.data[start:p.pos]), nil
+}
+
+// parseStruct 解析 %v 和 %+v 的结构体：{Name:Alice Age:30} 输出为对象，{Alice 30} 输出为数组
+func (p *goParser) parseStruct() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	p.skipSpace()
+	if p.consume("}") {
+		return &jsonNode{kind: nodeObject}, nil
+	}
+	if goFieldPattern.FindString(p.data[p.pos:]) == "" {
+		return p.parseElements('}')
+	}
+
+	node := &jsonNode{kind: nodeObject}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			return node, nil
+		}
+		m := goFieldPattern.FindString(p.data[p.pos:])
+		if m == "" {
+			return nil, p.unexpected("字段名")
+		}
+		p.pos += len(m)
+		value, err := p.parseValue(goFieldValue)
+		if err != nil {
+			return nil, err
+		}
+		appendMember(node, m[:len(m)-1], value)
+	}
+}
+
+// parseSlice 解析 %v 的切片，如 [1 2 3]
+func (p *goParser) parseSlice() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // [
+	return p.parseElements(']')
+}
+
+// parseElements 解析以空格分隔、以 close 结尾的元素
+func (p *goParser) parseElements(close byte) (*jsonNode, error) {
+	node := &jsonNode{kind: nodeArray}
+	for {
+		p.skipSpace()
+		if p.consume(string(close)) {
+			return node, nil
+		}
+		value, err := p.parseValue(goElement)
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+	}
+}
+
+// parseMap 解析 %v 的 map，如 map[a:1 b:[1 2]]
+func (p *goParser) parseMap() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos += len("map[")
+	node := &jsonNode{kind: nodeObject}
+	for {
+		p.skipSpace()
+		if p.consume("]") {
+			return node, nil
+		}
+
+		var key string
+		if c := p.peek(); c == '"' || c == '{' || c == '[' || c == '&' {
+			keyNode, err := p.parseValue(goElement)
+			if err != nil {
+				return nil, err
+			}
+			key = nodeKeyText(keyNode)
+		} else {
+			key = p.readUntil(":]")
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(goMapValue)
+		if err != nil {
+			return nil, err
+		}
+		appendMember(node, key, value)
+	}
+}
+
+// parseString 解析带引号的字符串或反引号原始字符串
+func (p *goParser) parseString() (*jsonNode, error) {
+	start := p.pos
+	quote := p.data[p.pos]
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		c := p.data[p.pos]
+		if c == '\\' && quote == '"' {
+			p.pos++
+			continue
+		}
+		if c == quote {
+			p.pos++
+			value, err := strconv.Unquote(p.data[start:p.pos])
+			if err != nil {
+				p.pos = start
+				return nil, p.errorf("无效的Go字符串: %v", err)
+			}
+			return newStringNode(value), nil
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("字符串未结束")
+}
+
+// parseBare 解析未加引号的值，结束位置取决于所在的上下文
+func (p *goParser) parseBare(ctx goContext) (*jsonNode, error) {
+	start := p.pos
+	if ctx == goSyntax {
+		p.readUntil(",})]")
+	} else if m := goTimePattern.FindString(p.data[p.pos:]); m != "" {
+		p.pos += len(m)
+		return newStringNode(m), nil
+	} else {
+		for p.pos < len(p.data) {
+			c := p.data[p.pos]
+			if c == ']' || c == '}' {
+				break
+			}
+			if c == ' ' || c == '\n' || c == '\t' {
+				rest := p.data[p.pos+1:]
+				if ctx == goElement ||
+					(ctx == goFieldValue && goFieldPattern.MatchString(rest)) ||
+					(ctx == goMapValue && goMapKeyPattern.MatchString(rest)) {
+					break
+				}
+			}
+			p.pos++
+		}
+	}
+
+	token := strings.TrimSpace(p.data[start:p.pos])
+	p.pos = start + len(token)
+	if token == "" {
+		return nil, p.unexpected("Go值")
+	}
+	return goScalar(token, ctx == goSyntax), nil
+}
+
+// goScalar 把未加引号的值转换为JSON标量；%v 中的十六进制数只可能是指针地址，保留为字符串
+func goScalar(token string, goSyntax bool) *jsonNode {
+	switch token {
+	case "true", "false":
+		return newLiteralNode(token)
+	case "nil", "+Inf", "-Inf", "NaN":
+		return newLiteralNode("null")
+	}
+	if goSyntax || !strings.HasPrefix(token, "0x") {
+		if node, ok := newNumberNode(token); ok {
+			return node
+		}
+	}
+	return newStringNode(token)
+}
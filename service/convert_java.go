@@ -0,0 +1,290 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+// javaFieldPattern Lombok、record 和 IDE 生成的 toString 中字段的开头，如 name=
+var javaFieldPattern = regexp.MustCompile(`^\s*[A-Za-z_$][\w$]*=`)
+
+// javaMapKeyPattern java.util.Map 的 toString 中键的开头，如 key=
+var javaMapKeyPattern = regexp.MustCompile(`^\s*[^,=\s{}()\[\]]+=`)
+
+// javaContext 值所在的上下文，决定未加引号的值在哪里结束
+type javaContext int
+
+const (
+	javaElement  javaContext = iota // 集合元素，到下一个逗号为止
+	javaField                       // 对象字段值，到下一个 field= 为止
+	javaMapValue                    // Map 的值，到下一个 key= 为止
+)
+
+// javaParser Java toString 输出的解码器
+type javaParser struct {
+	literalParser
+}
+
+// decodeJavaLiteral 解码 Java 的 toString 输出：Lombok 的 User(id=1, addr=Address(city=SH))、
+// record 的 Point[x=1, y=2]、IDE 生成的 User{id=1, name='foo'}、Map 的 {k=v} 以及 List 的 [a, b]，
+// 未加引号的值按内容推断为数字、布尔值、null 或字符串
+func decodeJavaLiteral(text string) (*jsonNode, error) {
+	p := &javaParser{literalParser: newLiteralParser(text)}
+	p.skipSpace()
+	node, err := p.parseValue(javaElement)
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(node)
+}
+
+func (p *javaParser) parseValue(ctx javaContext) (*jsonNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("Java值")
+	}
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.parseMap()
+	case c == '[':
+		return p.parseList()
+	case c == '\'' || c == '"':
+		// IDE 生成的 toString 会给字符串加引号，如 name='foo'
+		start := p.pos
+		value, err := p.readQuoted()
+		if err == nil && p.atValueEnd(ctx) {
+			return newStringNode(value), nil
+		}
+		p.pos = start
+	case isLetter(c) || c == '_' || c == '$':
+		start := p.pos
+		p.readClassName()
+		switch p.peek() {
+		case '(':
+			return p.parseObject(')')
+		case '{':
+			if javaFieldPattern.MatchString(p.data[p.pos+1:]) || p.peekAt(1) == '}' {
+				return p.parseObject('}')
+			}
+		case '[':
+			return p.parseRecord(start)
+		}
+		p.pos = start
+	}
+	return p.parseBare(ctx)
+}
+
+// readClassName 跳过类名，如 User、com.example.User、Outer.Inner、Outer$Inner
+func (p *javaParser) readClassName() {
+	for p.readIdent() != "" && p.consume(".") {
+	}
+}
+
+// parseObject 解析 Lombok 的 User(id=1, name=foo) 或 IDE 生成的 User{id=1}，
+// super=Parent(...) 的字段合并到当前对象
+func (p *javaParser) parseObject(close byte) (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // ( 或 {
+	node := &jsonNode{kind: nodeObject}
+	p.skipSpace()
+	if p.consume(string(close)) {
+		return node, nil
+	}
+	if !javaFieldPattern.MatchString(p.data[p.pos:]) {
+		// 不是 field=value 形式，如 Optional(foo)，按元素列表解析
+		return p.parseElements(close)
+	}
+
+	for {
+		p.skipSpace()
+		name := p.readIdent()
+		if name == "" {
+			return nil, p.unexpected("字段名")
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(javaField)
+		if err != nil {
+			return nil, err
+		}
+		if name == "super" && value.kind == nodeObject {
+			node.members = append(node.members, value.members...)
+		} else {
+			appendMember(node, name, value)
+		}
+
+		if done, err := p.separator(close); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// parseRecord 解析 record 的 Point[x=1, y=2]，Optional[foo] 取其内容
+func (p *javaParser) parseRecord(start int) (*jsonNode, error) {
+	if javaFieldPattern.MatchString(p.data[p.pos+1:]) || p.peekAt(1) == ']' {
+		return p.parseObject(']')
+	}
+
+	name := p.data[start:p.pos]
+	list, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if (name == "Optional" || strings.HasSuffix(name, ".Optional")) && len(list.elements) == 1 {
+		return list.elements[0], nil
+	}
+	return list, nil
+}
+
+// parseMap 解析 java.util.Map 的 {k=v, k2=v2}
+func (p *javaParser) parseMap() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	node := &jsonNode{kind: nodeObject}
+	p.skipSpace()
+	if p.consume("}") {
+		return node, nil
+	}
+	if !javaMapKeyPattern.MatchString(p.data[p.pos:]) {
+		// Set 的 toString 在某些实现中使用花括号
+		return p.parseElements('}')
+	}
+
+	for {
+		p.skipSpace()
+		key := p.readUntil("=}")
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(javaMapValue)
+		if err != nil {
+			return nil, err
+		}
+		appendMember(node, key, value)
+
+		if done, err := p.separator('}'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// parseList 解析 List、Set 和 Arrays.toString 的 [a, b]
+func (p *javaParser) parseList() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // [
+	return p.parseElements(']')
+}
+
+// parseElements 解析以逗号分隔、以 close 结尾的元素
+func (p *javaParser) parseElements(close byte) (*jsonNode, error) {
+	node := &jsonNode{kind: nodeArray}
+	p.skipSpace()
+	if p.consume(string(close)) {
+		return node, nil
+	}
+	for {
+		p.skipSpace()
+		value, err := p.parseValue(javaElement)
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+
+		if done, err := p.separator(close); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// separator 处理元素之间的逗号，返回容器是否已结束
+func (p *javaParser) separator(close byte) (bool, error) {
+	p.skipSpace()
+	if p.consume(string(close)) {
+		return true, nil
+	}
+	if !p.consume(",") {
+		return false, p.unexpected("',' 或 '" + string(close) + "'")
+	}
+	return false, nil
+}
+
+// atValueEnd 当前位置是否是值的结尾
+func (p *javaParser) atValueEnd(ctx javaContext) bool {
+	save := p.pos
+	defer func() { p.pos = save }()
+
+	p.skipSpace()
+	switch p.peek() {
+	case 0, ')', ']', '}':
+		return true
+	case ',':
+		return p.isBoundary(ctx, p.pos)
+	}
+	return false
+}
+
+// isBoundary 位于 i 处的逗号是否分隔了当前值和下一个值
+func (p *javaParser) isBoundary(ctx javaContext, i int) bool {
+	rest := p.data[i+1:]
+	switch ctx {
+	case javaField:
+		return javaFieldPattern.MatchString(rest)
+	case javaMapValue:
+		return javaMapKeyPattern.MatchString(rest)
+	}
+	return true
+}
+
+// parseBare 解析未加引号的值，值中成对出现的括号会原样保留
+func (p *javaParser) parseBare(ctx javaContext) (*jsonNode, error) {
+	start := p.pos
+	depth := 0
+scan:
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				break scan
+			}
+			depth--
+		case ',':
+			if depth == 0 && p.isBoundary(ctx, p.pos) {
+				break scan
+			}
+		}
+		p.pos++
+	}
+
+	token := strings.TrimSpace(p.data[start:p.pos])
+	p.pos = start + len(token)
+	return javaScalar(token), nil
+}
+
+// javaScalar 推断未加引号的值的类型
+func javaScalar(token string) *jsonNode {
+	switch token {
+	case "null", "true", "false":
+		return newLiteralNode(token)
+	case "NaN", "Infinity", "-Infinity", "Optional.empty":
+		return newLiteralNode("null")
+	}
+	if node, err := parseJSONTree(token); err == nil && node.kind == nodeNumber {
+		return node
+	}
+	return newStringNode(token)
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isoDatePattern util.inspect 输出的 Date，如 2024-01-01T00:00:00.000Z
+var isoDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z$`)
+
+// emptyItemsPattern 稀疏数组中的空位，如 <2 empty items>
+var emptyItemsPattern = regexp.MustCompile(`^<(\d+) empty items?>`)
+
+// jsParser Node util.inspect / console.log 输出及 JavaScript 对象字面量的解码器
+type jsParser struct {
+	literalParser
+}
+
+// decodeJavaScriptLiteral 解码 JavaScript 值：未加引号的键、单引号和反引号字符串、undefined、
+// [Object]、[Function: f]、Map(1) { 'a' => 1 }、Set(1) { 1 }、BigInt 以及 <ref *1> 等标记
+func decodeJavaScriptLiteral(text string) (*jsonNode, error) {
+	p := &jsParser{literalParser: newLiteralParser(text)}
+	p.skipSpace()
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	return p.finish(node)
+}
+
+// skipSpace 跳过空白和注释
+func (p *jsParser) skipSpace() {
+	for {
+		p.literalParser.skipSpace()
+		switch {
+		case strings.HasPrefix(p.data[p.pos:], "//"):
+			if end := strings.IndexByte(p.data[p.pos:], '\n'); end >= 0 {
+				p.pos += end
+			} else {
+				p.pos = len(p.data)
+			}
+		case strings.HasPrefix(p.data[p.pos:], "/*"):
+			if end := strings.Index(p.data[p.pos+2:], "*/"); end >= 0 {
+				p.pos += end + 4
+			} else {
+				p.pos = len(p.data)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsParser) parseValue() (*jsonNode, error) {
+	// 循环引用的标记，如 <ref *1> { a: [Circular *1] }
+	if p.consume("<ref *") {
+		p.readUntil(">")
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+	}
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("JavaScript值")
+	}
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.parseObject(false)
+	case c == '[':
+		if isLetter(p.peekAt(1)) {
+			return p.parseTag()
+		}
+		return p.parseArray()
+	case c == '\'' || c == '"' || c == '`':
+		value, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return newStringNode(value), nil
+	case c == '<':
+		// <pending>、<Buffer 68 69> 等
+		raw, err := p.readBalanced('<', '>')
+		if err != nil {
+			return nil, err
+		}
+		return newStringNode(raw), nil
+	case c == '-' || c == '+' || c == '.' || isDigit(c):
+		return p.parseNumber()
+	}
+
+	start := p.pos
+	name := p.readIdent()
+	switch name {
+	case "":
+		return nil, p.unexpected("JavaScript值")
+	case "true", "false", "null":
+		return newLiteralNode(name), nil
+	case "undefined", "NaN", "Infinity":
+		return newLiteralNode("null"), nil
+	case "Symbol":
+		if p.peek() == '(' {
+			if _, err := p.readBalanced('(', ')'); err != nil {
+				return nil, err
+			}
+			return newStringNode(p.data[start:p.pos]), nil
+		}
+	}
+	return p.parseInstance(start, name)
+}
+
+// parseInstance 解析带类名前缀的值，如 Foo { a: 1 }、Map(2) { 'a' => 1 }、Uint8Array(2) [ 1, 2 ]
+func (p *jsParser) parseInstance(start int, name string) (*jsonNode, error) {
+	// 元素个数，如 Map(2)
+	if p.peek() == '(' {
+		if _, err := p.readBalanced('(', ')'); err != nil {
+			return nil, err
+		}
+	}
+	// 原型标记，如 Foo [Object: null prototype] {}
+	p.skipSpace()
+	if p.peek() == '[' && isLetter(p.peekAt(1)) {
+		if _, err := p.readBalanced('[', ']'); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+	}
+
+	switch p.peek() {
+	case '{':
+		switch name {
+		case "Map":
+			return p.parseObject(true)
+		case "Set":
+			return p.parseSet()
+		}
+		return p.parseObject(false)
+	case '[':
+		return p.parseArray()
+	}
+
+	p.pos = start
+	return nil, p.unexpected("JavaScript值")
+}
+
+// parseTag 解析 [Object]、[Function: f]、[Circular *1] 等标记，标记后紧跟对象时取对象内容
+func (p *jsParser) parseTag() (*jsonNode, error) {
+	tag, err := p.readBalanced('[', ']')
+	if err != nil {
+		return nil, err
+	}
+
+	save := p.pos
+	p.skipSpace()
+	if p.peek() == '{' {
+		// [Object: null prototype] { a: 1 }、[Function: f] { prop: 1 }
+		return p.parseObject(false)
+	}
+	p.pos = save
+	return newStringNode(tag), nil
+}
+
+// parseObject 解析对象，isMap 为 true 时按 Map 的 key => value 解析
+func (p *jsParser) parseObject(isMap bool) (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	node := &jsonNode{kind: nodeObject}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			return node, nil
+		}
+		if p.skipMoreItems() {
+			continue
+		}
+
+		var key string
+		if isMap {
+			keyNode, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			key = nodeKeyText(keyNode)
+			p.skipSpace()
+			if err := p.expect("=>"); err != nil {
+				return nil, err
+			}
+		} else {
+			var err error
+			if key, err = p.parseKey(); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+		}
+
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		appendMember(node, key, value)
+
+		if done, err := p.separator('}'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// parseKey 解析对象的键：标识符、字符串、数字或 [Symbol(x)]
+func (p *jsParser) parseKey() (string, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"' || c == '`':
+		return p.readQuoted()
+	case c == '[':
+		return p.readBalanced('[', ']')
+	case isDigit(c):
+		start := p.pos
+		p.skipDigits()
+		return p.data[start:p.pos], nil
+	}
+	if key := p.readIdent(); key != "" {
+		return key, nil
+	}
+	return "", p.unexpected("对象的键")
+}
+
+// parseArray 解析数组，稀疏数组的空位输出为 null
+func (p *jsParser) parseArray() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // [
+	node := &jsonNode{kind: nodeArray}
+	for {
+		p.skipSpace()
+		if p.consume("]") {
+			return node, nil
+		}
+		if p.skipMoreItems() {
+			continue
+		}
+
+		if m := emptyItemsPattern.FindStringSubmatch(p.data[p.pos:]); m != nil {
+			p.pos += len(m[0])
+			n, _ := strconv.Atoi(m[1])
+			for i := 0; i < n; i++ {
+				node.elements = append(node.elements, newLiteralNode("null"))
+			}
+		} else {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			node.elements = append(node.elements, value)
+		}
+
+		if done, err := p.separator(']'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// parseSet 解析 Set(n) { 1, 2 }，输出为数组
+func (p *jsParser) parseSet() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	node := &jsonNode{kind: nodeArray}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			return node, nil
+		}
+		if p.skipMoreItems() {
+			continue
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+
+		if done, err := p.separator('}'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// skipMoreItems 跳过被截断的提示，如 ... 95 more items
+func (p *jsParser) skipMoreItems() bool {
+	if !p.consume("...") {
+		return false
+	}
+	p.readUntil(",}]")
+	p.consume(",")
+	return true
+}
+
+// separator 处理元素之间的逗号，允许尾随逗号，返回容器是否已结束
+func (p *jsParser) separator(close byte) (bool, error) {
+	p.skipSpace()
+	if p.consume(string(close)) {
+		return true, nil
+	}
+	if !p.consume(",") {
+		return false, p.unexpected("',' 或 '" + string(close) + "'")
+	}
+	return false, nil
+}
+
+// parseNumber 解析数字、BigInt（123n）、-Infinity 以及 ISO 格式的 Date
+func (p *jsParser) parseNumber() (*jsonNode, error) {
+	start := p.pos
+	token := p.readUntil(",}]) \t\r\n")
+	switch {
+	case token == "-Infinity" || token == "+Infinity" || token == "-NaN":
+		return newLiteralNode("null"), nil
+	case isoDatePattern.MatchString(token):
+		return newStringNode(token), nil
+	}
+
+	if node, ok := newNumberNode(strings.TrimSuffix(token, "n")); ok {
+		return node, nil
+	}
+	p.pos = start
+	return nil, p.unexpected("数字")
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"strings"
+)
+
+// pythonContainerCalls 参数即为内容的容器类型，如 OrderedDict([('a', 1)])、defaultdict(<class 'list'>, {...})
+var pythonContainerCalls = map[string]bool{
+	"dict":        true,
+	"list":        true,
+	"tuple":       true,
+	"set":         true,
+	"frozenset":   true,
+	"OrderedDict": true,
+	"defaultdict": true,
+	"Counter":     true,
+	"deque":       true,
+}
+
+// pythonParser Python repr 解码器
+type pythonParser struct {
+	literalParser
+}
+
+// decodePythonLiteral 解码 Python repr 输出：dict、list、tuple、set，
+// True/False/None，单双引号及带 b、u、r 前缀的字符串，以及 Decimal('1.5') 等调用表达式
+func decodePythonLiteral(text string) (*jsonNode, error) {
+	p := &pythonParser{literalParser: newLiteralParser(text)}
+	p.skipSpace()
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(node)
+}
+
+func (p *pythonParser) parseValue() (*jsonNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("Python值")
+	}
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.parseDictOrSet()
+	case c == '[':
+		return p.parseSequence('[', ']', false)
+	case c == '(':
+		return p.parseSequence('(', ')', true)
+	case c == '\'' || c == '"':
+		return p.parseString(false)
+	case c == '<':
+		// <object at 0x7f...>、<class 'list'> 等无法表示的对象
+		raw, err := p.readBalanced('<', '>')
+		if err != nil {
+			return nil, err
+		}
+		return newStringNode(raw), nil
+	case c == '-' || c == '+' || c == '.' || isDigit(c):
+		return p.parseNumber()
+	}
+
+	start := p.pos
+	name := p.readIdent()
+	if name == "" {
+		return nil, p.unexpected("Python值")
+	}
+
+	// 字符串前缀，如 b'..'、u'..'、r'..'、rb'..'
+	if len(name) <= 2 && strings.Trim(strings.ToLower(name), "rbuf") == "" && (p.peek() == '\'' || p.peek() == '"') {
+		return p.parseString(strings.ContainsAny(name, "rR"))
+	}
+
+	for p.peek() == '.' {
+		p.pos++
+		if p.readIdent() == "" {
+			return nil, p.unexpected("标识符")
+		}
+	}
+	name = p.data[start:p.pos]
+
+	switch name {
+	case "True":
+		return newLiteralNode("true"), nil
+	case "False":
+		return newLiteralNode("false"), nil
+	case "None":
+		return newLiteralNode("null"), nil
+	case "inf", "nan", "Ellipsis":
+		return newLiteralNode("null"), nil
+	}
+
+	if p.peek() != '(' {
+		p.pos = start
+		return nil, p.unexpected("Python值")
+	}
+	return p.parseCall(start, name)
+}
+
+// parseCall 解析调用表达式：容器类型取其内容，单个字符串参数（Decimal、UUID、datetime 的 ISO 形式等）取该参数，
+// 其余输出调用的原文
+func (p *pythonParser) parseCall(start int, name string) (*jsonNode, error) {
+	argsStart := p.pos
+	args, err := p.parseSequence('(', ')', false)
+	if err == nil {
+		short := name[strings.LastIndexByte(name, '.')+1:]
+		switch {
+		case pythonContainerCalls[short]:
+			if len(args.elements) == 0 {
+				if short == "dict" || short == "OrderedDict" || short == "defaultdict" || short == "Counter" {
+					return &jsonNode{kind: nodeObject}, nil
+				}
+				return &jsonNode{kind: nodeArray}, nil
+			}
+			content := args.elements[len(args.elements)-1]
+			if short == "OrderedDict" || short == "dict" {
+				if obj, ok := pairsToObject(content); ok {
+					return obj, nil
+				}
+			}
+			return content, nil
+		case len(args.elements) == 1 && args.elements[0].kind == nodeString:
+			value := args.elements[0].stringValue()
+			if short == "Decimal" || short == "Fraction" {
+				if number, ok := newNumberNode(value); ok {
+					return number, nil
+				}
+			}
+			return newStringNode(value), nil
+		}
+	}
+
+	// 带关键字参数等无法按值解析的调用，保留原文
+	p.pos = argsStart
+	if _, err := p.readBalanced('(', ')'); err != nil {
+		return nil, err
+	}
+	return newStringNode(p.data[start:p.pos]), nil
+}
+
+// parseDictOrSet 解析 dict 或 set，set 转换为数组
+func (p *pythonParser) parseDictOrSet() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	p.skipSpace()
+	if p.consume("}") {
+		return &jsonNode{kind: nodeObject}, nil
+	}
+
+	var obj, set *jsonNode
+	for {
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+
+		if obj == nil && set == nil {
+			if p.peek() == ':' {
+				obj = &jsonNode{kind: nodeObject}
+			} else {
+				set = &jsonNode{kind: nodeArray}
+			}
+		}
+
+		if obj != nil {
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			appendMember(obj, nodeKeyText(item), value)
+			p.skipSpace()
+		} else {
+			set.elements = append(set.elements, item)
+		}
+
+		done, err := p.separator('}')
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			if obj != nil {
+				return obj, nil
+			}
+			return set, nil
+		}
+	}
+}
+
+// parseSequence 解析 list、tuple 或调用参数，unwrap 为 true 时单个元素且没有逗号的括号表达式返回元素本身
+func (p *pythonParser) parseSequence(open byte, close byte, unwrap bool) (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // [ 或 (
+	node := &jsonNode{kind: nodeArray}
+	p.skipSpace()
+	if p.consume(string(close)) {
+		return node, nil
+	}
+
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+		p.skipSpace()
+
+		if unwrap && len(node.elements) == 1 && p.peek() == ')' {
+			p.pos++
+			return value, nil
+		}
+		done, err := p.separator(close)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return node, nil
+		}
+	}
+}
+
+// separator 处理元素之间的逗号，允许尾随逗号，返回容器是否已结束
+func (p *pythonParser) separator(close byte) (bool, error) {
+	if p.consume(string(close)) {
+		return true, nil
+	}
+	if !p.consume(",") {
+		return false, p.unexpected("',' 或 '" + string(close) + "'")
+	}
+	p.skipSpace()
+	return p.consume(string(close)), nil
+}
+
+// parseString 解析字符串，相邻的字符串字面量会拼接在一起
+func (p *pythonParser) parseString(raw bool) (*jsonNode, error) {
+	var sb strings.Builder
+	for {
+		if raw {
+			quote := p.peek()
+			end := strings.IndexByte(p.data[p.pos+1:], quote)
+			if end < 0 {
+				return nil, p.errorf("字符串未结束")
+			}
+			sb.WriteString(p.data[p.pos+1 : p.pos+1+end])
+			p.pos += end + 2
+		} else {
+			value, err := p.readQuoted()
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteString(value)
+		}
+
+		// 相邻字符串，如 pprint 折行输出的 ('abc'\n 'def')
+		save := p.pos
+		p.skipSpace()
+		prefix := p.pos
+		p.readIdent()
+		if p.pos-prefix > 2 || (p.peek() != '\'' && p.peek() != '"') {
+			p.pos = save
+			return newStringNode(sb.String()), nil
+		}
+		raw = strings.ContainsAny(p.data[prefix:p.pos], "rR")
+	}
+}
+
+// parseNumber 解析数字，复数等无法表示为JSON数字的值保留原文
+func (p *pythonParser) parseNumber() (*jsonNode, error) {
+	start := p.pos
+	if p.peek() == '-' || p.peek() == '+' {
+		p.pos++
+	}
+	if strings.HasPrefix(p.data[p.pos:], "inf") || strings.HasPrefix(p.data[p.pos:], "nan") {
+		p.pos += 3
+		return newLiteralNode("null"), nil
+	}
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if isDigit(c) || c == '.' || c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+			((c == '+' || c == '-') && (p.data[p.pos-1] == 'e' || p.data[p.pos-1] == 'E')) {
+			p.pos++
+			continue
+		}
+		break
+	}
+
+	token := p.data[start:p.pos]
+	if node, ok := newNumberNode(token); ok {
+		return node, nil
+	}
+	if strings.HasSuffix(token, "j") || strings.HasSuffix(token, "J") {
+		return newStringNode(token), nil
+	}
+	p.pos = start
+	return nil, p.unexpected("数字")
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"strings"
+)
+
+// rubyParser Ruby inspect 输出的解码器
+type rubyParser struct {
+	literalParser
+}
+
+// decodeRubyLiteral 解码 Ruby inspect 输出：{:a=>1}、{a: 1}、{"a" => nil}、符号、
+// #<struct Point x=1>、#<Foo:0x000 @a=1>、#<Set: {1, 2}> 等
+func decodeRubyLiteral(text string) (*jsonNode, error) {
+	p := &rubyParser{literalParser: newLiteralParser(text)}
+	p.skipSpace()
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(node)
+}
+
+func (p *rubyParser) parseValue() (*jsonNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("Ruby值")
+	}
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.parseHash()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || c == '\'':
+		value, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return newStringNode(value), nil
+	case c == ':' && p.peekAt(1) != ':':
+		return p.parseSymbol()
+	case c == '#' && p.peekAt(1) == '<':
+		return p.parseObject()
+	case c == '(':
+		// Rational 和 Complex，如 (1/3)、(1+2i)
+		raw, err := p.readBalanced('(', ')')
+		if err != nil {
+			return nil, err
+		}
+		return newStringNode(raw), nil
+	case c == '-' || c == '+' || isDigit(c):
+		return p.parseBare()
+	}
+
+	start := p.pos
+	switch name := p.readIdent(); name {
+	case "nil":
+		return newLiteralNode("null"), nil
+	case "true", "false":
+		return newLiteralNode(name), nil
+	case "Infinity", "NaN":
+		return newLiteralNode("null"), nil
+	}
+	p.pos = start
+	return nil, p.unexpected("Ruby值")
+}
+
+// parseHash 解析 Hash，键可以是 key => value、label: value 或 "label": value
+func (p *rubyParser) parseHash() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	node := &jsonNode{kind: nodeObject}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			return node, nil
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		appendMember(node, key, value)
+
+		if done, err := p.separator('}'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// parseKey 解析 Hash 的键以及之后的 => 或 :
+func (p *rubyParser) parseKey() (string, error) {
+	// label: value
+	start := p.pos
+	if label := p.readIdent(); label != "" {
+		if p.consume("?") || p.consume("!") {
+			label = p.data[start:p.pos]
+		}
+		if p.peek() == ':' && p.peekAt(1) != ':' {
+			p.pos++
+			return label, nil
+		}
+		p.pos = start
+	}
+
+	keyNode, err := p.parseValue()
+	if err != nil {
+		return "", err
+	}
+	// "label": value
+	if keyNode.kind == nodeString && p.peek() == ':' && p.peekAt(1) != ':' {
+		p.pos++
+		return keyNode.stringValue(), nil
+	}
+
+	p.skipSpace()
+	if err := p.expect("=>"); err != nil {
+		return "", err
+	}
+	return nodeKeyText(keyNode), nil
+}
+
+func (p *rubyParser) parseArray() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // [
+	return p.parseElements(']')
+}
+
+// parseElements 解析以逗号分隔、以 close 结尾的元素列表
+func (p *rubyParser) parseElements(close byte) (*jsonNode, error) {
+	node := &jsonNode{kind: nodeArray}
+	for {
+		p.skipSpace()
+		if p.consume(string(close)) {
+			return node, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+
+		if done, err := p.separator(close); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// separator 处理元素之间的逗号，返回容器是否已结束
+func (p *rubyParser) separator(close byte) (bool, error) {
+	p.skipSpace()
+	if p.consume(string(close)) {
+		return true, nil
+	}
+	if !p.consume(",") {
+		return false, p.unexpected("',' 或 '" + string(close) + "'")
+	}
+	return false, nil
+}
+
+// parseSymbol 解析符号，如 :name、:"with space"、:empty?
+func (p *rubyParser) parseSymbol() (*jsonNode, error) {
+	p.pos++ // :
+	if c := p.peek(); c == '"' || c == '\'' {
+		value, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return newStringNode(value), nil
+	}
+
+	start := p.pos
+	if p.readIdent() == "" {
+		// 运算符符号，如 :+、:<=>
+		p.readUntil(",}]) \t\r\n")
+		if p.pos == start {
+			return nil, p.unexpected("符号")
+		}
+	}
+	if c := p.peek(); c == '?' || c == '!' || (c == '=' && p.peekAt(1) != '>') {
+		p.pos++
+	}
+	return newStringNode(p.data[start:p.pos]), nil
+}
+
+// parseBare 解析以数字开头的值：数字输出为数字，Time、Date、Range 等输出为字符串
+func (p *rubyParser) parseBare() (*jsonNode, error) {
+	start := p.pos
+	for p.pos < len(p.data) && strings.IndexByte(",}]>\r\n", p.data[p.pos]) < 0 && !strings.HasPrefix(p.data[p.pos:], "=>") {
+		p.pos++
+	}
+	token := strings.TrimSpace(p.data[start:p.pos])
+	p.pos = start + len(token)
+
+	switch token {
+	case "-Infinity", "+Infinity":
+		return newLiteralNode("null"), nil
+	case "":
+		return nil, p.unexpected("Ruby值")
+	}
+	if node, ok := newNumberNode(token); ok {
+		return node, nil
+	}
+	return newStringNode(token), nil
+}
+
+// parseObject 解析 #<...> 形式的对象，实例变量或成员输出为对象的字段，
+// 无法识别的内容整体输出为字符串
+func (p *rubyParser) parseObject() (*jsonNode, error) {
+	start := p.pos
+	node, err := p.parseObjectFields()
+	if err == nil {
+		return node, nil
+	}
+
+	p.pos = start
+	raw, err := p.readObjectSource()
+	if err != nil {
+		return nil, err
+	}
+	return newStringNode(raw), nil
+}
+
+// parseObjectFields 解析 #<struct Point x=1, y=2>、#<Point:0x000 @x=1>、#<Set: {1, 2}>
+func (p *rubyParser) parseObjectFields() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos += 2 // #<
+	if !p.consume("struct ") {
+		p.consume("data ")
+	}
+	for p.readIdent() != "" && p.consume("::") {
+	}
+
+	// 对象地址，如 :0x000055d5c0a8
+	if p.peek() == ':' && p.peekAt(1) != ' ' {
+		p.pos++
+		p.readIdent()
+		p.readUntil(" ,>")
+	}
+
+	// #<Set: {1, 2}>
+	if p.consume(": {") {
+		set, err := p.parseElements('}')
+		if err != nil {
+			return nil, err
+		}
+		return set, p.expect(">")
+	}
+
+	node := &jsonNode{kind: nodeObject}
+	for {
+		p.skipSpace()
+		if p.consume(">") {
+			return node, nil
+		}
+		p.consume("@")
+		name := p.readIdent()
+		if name == "" {
+			return nil, p.unexpected("字段名")
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		appendMember(node, name, value)
+
+		if done, err := p.separator('>'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// readObjectSource 读取 #<...> 的原文，支持嵌套并跳过字符串和 =>
+func (p *rubyParser) readObjectSource() (string, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.data) {
+		switch {
+		case p.consume("#<"):
+			depth++
+		case p.consume("=>"), p.consume("->"):
+		case p.peek() == '"':
+			if _, err := p.readQuoted(); err != nil {
+				return "", err
+			}
+		case p.consume(">"):
+			depth--
+			if depth == 0 {
+				return p.data[start:p.pos], nil
+			}
+		default:
+			p.pos++
+		}
+	}
+	p.pos = start
+	return "", p.errorf("缺少与 '#<' 匹配的 '>'")
+}
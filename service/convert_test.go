@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConvertToJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		lang     SourceLanguage
+		input    string
+		expected string
+	}{
+		// Python
+		{
+			name:     "Python字典",
+			lang:     LanguagePython,
+			input:    `{'a': True, 'b': None, 'c': [1, 2.5, -3], "d": 'it\'s', 'e': (1, 2), 'f': (1,), 'g': {1, 2}, 3: False}`,
+			expected: `{"a":true,"b":null,"c":[1,2.5,-3],"d":"it's","e":[1,2],"f":[1],"g":[1,2],"3":false}`,
+		},
+		{
+			name:     "Python特殊值",
+			lang:     LanguagePython,
+			input:    `{'price': Decimal('1.50'), 'id': UUID('6ba7b810-9dad-11d1-80b4-00c04fd430c8'), 'at': datetime.datetime(2024, 1, 1, 0, 0), 'raw': b'\x00ab', 'big': 1_000_000, 'inf': inf, 'obj': <object object at 0x7f>}`,
+			expected: `{"price":1.50,"id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","at":"datetime.datetime(2024, 1, 1, 0, 0)","raw":"\u0000ab","big":1000000,"inf":null,"obj":"<object object at 0x7f>"}`,
+		},
+		{
+			name:     "Python容器调用",
+			lang:     LanguagePython,
+			input:    `OrderedDict([('b', 1), ('a', defaultdict(<class 'list'>, {'x': [1]}))])`,
+			expected: `{"b":1,"a":{"x":[1]}}`,
+		},
+		// JavaScript
+		{
+			name:     "Node util.inspect",
+			lang:     LanguageJavaScript,
+			input:    "{\n  id: 1,\n  'user-name': 'bob',\n  tags: [ 'a', \"b\", `c` ],\n  nested: [Object],\n  list: [Array],\n  fn: [Function: handler],\n  missing: undefined,\n  big: 123n,\n  at: 2024-01-01T00:00:00.000Z\n}",
+			expected: `{"id":1,"user-name":"bob","tags":["a","b","c"],"nested":"[Object]","list":"[Array]","fn":"[Function: handler]","missing":null,"big":123,"at":"2024-01-01T00:00:00.000Z"}`,
+		},
+		{
+			name:     "Node的Map、Set和类实例",
+			lang:     LanguageJavaScript,
+			input:    `<ref *1> User { m: Map(2) { 'a' => 1, 'b' => { x: 2 } }, s: Set(2) { 1, 2 }, arr: [ <2 empty items>, 3, ... 10 more items ], self: [Circular *1], [Symbol(k)]: Symbol(v) }`,
+			expected: `{"m":{"a":1,"b":{"x":2}},"s":[1,2],"arr":[null,null,3],"self":"[Circular *1]","[Symbol(k)]":"Symbol(v)"}`,
+		},
+		// Ruby
+		{
+			name:     "Ruby哈希",
+			lang:     LanguageRuby,
+			input:    `{:a=>1, "b"=>nil, c: true, "d": [1.5, :sym, "x\ty"], 1=>2, :"with space"=>-3}`,
+			expected: `{"a":1,"b":null,"c":true,"d":[1.5,"sym","x\ty"],"1":2,"with space":-3}`,
+		},
+		{
+			name:     "Ruby对象",
+			lang:     LanguageRuby,
+			input:    `[#<struct Point x=1, y=2>, #<User:0x000055d5c0a8 @name="bob", @tags=[:a]>, #<Set: {1, 2}>, #<Date: 2024-01-01 ((2460311j,0s,0n),+0s,2299161j)>, 2024-01-01 00:00:00 +0000]`,
+			expected: `[{"x":1,"y":2},{"name":"bob","tags":["a"]},[1,2],"#<Date: 2024-01-01 ((2460311j,0s,0n),+0s,2299161j)>","2024-01-01 00:00:00 +0000"]`,
+		},
+		// Go
+		{
+			name:     "Go的%+v",
+			lang:     LanguageGo,
+			input:    `&{Name:Alice Smith Age:30 Tags:[a b] Meta:map[k:v n:1] Inner:{X:1} Ptr:<nil> CreatedAt:2024-01-01 08:00:00 +0800 CST Addr:0xc000012345}`,
+			expected: `{"Name":"Alice Smith","Age":30,"Tags":["a","b"],"Meta":{"k":"v","n":1},"Inner":{"X":1},"Ptr":null,"CreatedAt":"2024-01-01 08:00:00 +0800 CST","Addr":"0xc000012345"}`,
+		},
+		{
+			name:     "Go的%v",
+			lang:     LanguageGo,
+			input:    `[{Alice 30} {Bob 25}]`,
+			expected: `[["Alice",30],["Bob",25]]`,
+		},
+		{
+			name:     "Go的%#v",
+			lang:     LanguageGo,
+			input:    `&main.User{Name:"Alice", Age:30, Tags:[]string{"a", "b"}, Empty:[]int{}, Scores:map[string]int{"x":1}, Status:main.Status(2), Next:(*main.User)(nil), Wall:0x10}`,
+			expected: `{"Name":"Alice","Age":30,"Tags":["a","b"],"Empty":[],"Scores":{"x":1},"Status":2,"Next":null,"Wall":16}`,
+		},
+		// Java
+		{
+			name:     "Lombok toString",
+			lang:     LanguageJava,
+			input:    `User(id=1, name=Alice Smith, active=true, score=9.5, email=null, tags=[a, b], addr=Address(city=SH, zip=200000), empty=, note=a, b (c))`,
+			expected: `{"id":1,"name":"Alice Smith","active":true,"score":9.5,"email":null,"tags":["a","b"],"addr":{"city":"SH","zip":200000},"empty":"","note":"a, b (c)"}`,
+		},
+		{
+			name:     "Lombok callSuper和Map",
+			lang:     LanguageJava,
+			input:    `Admin(super=User(id=1), perms={read=true, write=false, limit=10}, roles={})`,
+			expected: `{"id":1,"perms":{"read":true,"write":false,"limit":10},"roles":{}}`,
+		},
+		{
+			name:     "record和IDE生成的toString",
+			lang:     LanguageJava,
+			input:    `[Point[x=1, y=2], User{id=2, name='it, is'}, Optional[5], com.example.Foo@1b6d3586]`,
+			expected: `[{"x":1,"y":2},{"id":2,"name":"it, is"},5,"com.example.Foo@1b6d3586"]`,
+		},
+		// 自动识别
+		{
+			name:     "自动识别Python",
+			lang:     LanguageAuto,
+			input:    `{'ok': True}`,
+			expected: `{"ok":true}`,
+		},
+		{
+			name:     "自动识别Ruby",
+			lang:     LanguageAuto,
+			input:    `{:ok=>nil}`,
+			expected: `{"ok":null}`,
+		},
+		{
+			name:     "自动识别Go",
+			lang:     LanguageAuto,
+			input:    `map[a:1 b:true]`,
+			expected: `{"a":1,"b":true}`,
+		},
+		{
+			name:     "自动识别JavaScript",
+			lang:     LanguageAuto,
+			input:    `{ a: undefined, b: [Object] }`,
+			expected: `{"a":null,"b":"[Object]"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.ConvertToJSON(ctx, tt.input, tt.lang, FormatOptions{})
+			if err != nil {
+				t.Fatalf("ConvertToJSON() unexpected error = %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("ConvertToJSON() =\n%s\nwant:\n%s", result.Result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertToJSONDetectedLanguage(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		input    string
+		expected SourceLanguage
+	}{
+		{input: `{"a": 1}`, expected: LanguageJSON},
+		{input: `{'a': None}`, expected: LanguagePython},
+		{input: `{ a: undefined }`, expected: LanguageJavaScript},
+		{input: `{"a"=>nil}`, expected: LanguageRuby},
+		{input: `{Name:Alice Age:30}`, expected: LanguageGo},
+		{input: `User(id=1, name=foo)`, expected: LanguageJava},
+		{input: `{k=v, n=1}`, expected: LanguageJava},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.expected), func(t *testing.T) {
+			result, err := JSONProcessorService.ConvertToJSON(ctx, tt.input, LanguageAuto, FormatOptions{})
+			if err != nil {
+				t.Fatalf("ConvertToJSON() unexpected error = %v", err)
+			}
+			if result.Language != tt.expected {
+				t.Errorf("ConvertToJSON() language = %s, want %s", result.Language, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertToJSONInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		lang  SourceLanguage
+		input string
+	}{
+		{name: "Python未知标识符", lang: LanguagePython, input: `{'a': foo}`},
+		{name: "JavaScript缺少冒号", lang: LanguageJavaScript, input: `{ a 1 }`},
+		{name: "Ruby缺少=>", lang: LanguageRuby, input: `{"a" 1}`},
+		{name: "Go缺少右括号", lang: LanguageGo, input: `map[a:1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := JSONProcessorService.ConvertToJSON(ctx, tt.input, tt.lang, FormatOptions{}); err == nil {
+				t.Error("ConvertToJSON() expected error")
+			}
+		})
+	}
+
+	if _, err := ParseSourceLanguage("cobol"); err == nil {
+		t.Error("ParseSourceLanguage() expected error for unknown language")
+	}
+}
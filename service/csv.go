@@ -0,0 +1,492 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"sojson/zlog"
+)
+
+// CSVFormat 表格的分隔符格式
+type CSVFormat string
+
+const (
+	CSVComma CSVFormat = "csv" // 逗号分隔
+	CSVTab   CSVFormat = "tsv" // 制表符分隔
+)
+
+// ParseCSVFormat 解析表格格式名称，空字符串表示 csv
+func ParseCSVFormat(name string) (CSVFormat, error) {
+	switch CSVFormat(name) {
+	case "", CSVComma:
+		return CSVComma, nil
+	case CSVTab:
+		return CSVTab, nil
+	}
+	return "", fmt.Errorf("不支持的表格格式: %s，可选 csv、tsv", name)
+}
+
+// comma 格式对应的分隔符
+func (f CSVFormat) comma() rune {
+	if f == CSVTab {
+		return '\t'
+	}
+	return ','
+}
+
+// ArrayEncoding 嵌套数组在单元格中的写法
+type ArrayEncoding string
+
+const (
+	ArraysJSON  ArrayEncoding = "json"  // 写为JSON文本，如 ["a","b"]
+	ArraysJoin  ArrayEncoding = "join"  // 元素都是标量时以分隔符连接，如 a;b，否则写为JSON文本
+	ArraysIndex ArrayEncoding = "index" // 按下标展开为多列，如 tags.0、tags.1
+)
+
+// ParseArrayEncoding 解析数组写法名称，空字符串表示 json
+func ParseArrayEncoding(name string) (ArrayEncoding, error) {
+	switch ArrayEncoding(name) {
+	case "", ArraysJSON:
+		return ArraysJSON, nil
+	case ArraysJoin, ArraysIndex:
+		return ArrayEncoding(name), nil
+	}
+	return "", fmt.Errorf("不支持的数组写法: %s，可选 json、join、index", name)
+}
+
+// CSVOptions CSV/TSV转换选项
+type CSVOptions struct {
+	FormatOptions
+	Format       CSVFormat
+	Pointer      string        // 要转换的数组的 JSON Pointer，如 /data/items，为空时为整个文档
+	Arrays       ArrayEncoding // 嵌套数组的写法
+	Separator    string        // join 写法的分隔符，默认为 ;
+	RawStrings   bool          // CSV转换为JSON时不推断类型，所有值保持为字符串
+	BOM          bool          // 输出带 UTF-8 BOM，便于 Excel 识别编码
+	KeepFormulas bool          // 以 = + - @ 开头的单元格保持原样，默认在前面加单引号，防止在表格软件中作为公式执行
+}
+
+// csvFormulaPrefixes 表格软件会作为公式执行的单元格开头的字符
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvValueColumn 数组元素不是对象时所在的列
+const csvValueColumn = "value"
+
+// JSONToCSV 把对象数组转换为CSV/TSV，嵌套的对象展开为以点分隔的列，列为所有行中出现过的键的并集
+func (s *jsonProcessorService) JSONToCSV(ctx context.Context, text string, opts CSVOptions) (*ConversionResult, error) {
+	if err := opts.Dialect.check(); err != nil {
+		return nil, err
+	}
+	if opts.Format == "" {
+		opts.Format = CSVComma
+	}
+
+	tree, err := parseDialectTree(text, opts.Dialect)
+	if err != nil {
+		zlog.Errorf(ctx, "JSONToCSV: parseDialectTree failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+	rows, path, err := resolvePointer(tree, opts.Pointer)
+	if err != nil {
+		zlog.Errorf(ctx, "JSONToCSV: resolvePointer failed, pointer: %s, error: %v", opts.Pointer, err)
+		return nil, err
+	}
+	if opts.KeyOrder != KeyOrderOriginal {
+		sortMembers(rows, opts.KeyOrder)
+	}
+
+	t := &csvTable{text: text, index: map[string]int{}, arrays: opts.Arrays, separator: opts.Separator, keepFormulas: opts.KeepFormulas}
+	if t.separator == "" {
+		t.separator = ";"
+	}
+	switch rows.kind {
+	case nodeArray:
+		for _, el := range rows.elements {
+			t.addRow(el)
+		}
+	case nodeObject:
+		// 单个对象作为一行
+		t.addRow(rows)
+	default:
+		err := &ConversionError{Position: newPosition(text, rows.offset), Path: path, Target: strings.ToUpper(string(opts.Format)), Reason: "需要是对象数组或对象"}
+		zlog.Errorf(ctx, "JSONToCSV: convert failed, error: %v", err)
+		return nil, err
+	}
+
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Comma = opts.Format.comma()
+	w.UseCRLF = opts.CRLF
+	if len(t.columns) > 0 {
+		w.Write(t.columns)
+	}
+	for _, row := range t.rows {
+		for len(row) < len(t.columns) {
+			row = append(row, "")
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		zlog.Errorf(ctx, "JSONToCSV: write failed, error: %v", err)
+		return nil, err
+	}
+
+	result := sb.String()
+	if !opts.TrailingNewline {
+		result = strings.TrimSuffix(strings.TrimSuffix(result, "\n"), "\r")
+	}
+	if opts.BOM {
+		result = "\ufeff" + result
+	}
+
+	zlog.Infof(ctx, "JSONToCSV: successfully converted, rows: %d, columns: %d, output length: %d, notes: %d", len(t.rows), len(t.columns), len(result), len(t.notes))
+	return &ConversionResult{Result: result, Notes: t.notes}, nil
+}
+
+// csvTable 展开后的表格
+type csvTable struct {
+	text         string
+	columns      []string
+	index        map[string]int // 列名到列下标
+	rows         [][]string     // 每行的长度可能小于列数，缺少的列为空
+	arrays       ArrayEncoding
+	separator    string
+	keepFormulas bool
+	filled       map[int]*jsonNode // 当前行中已经填写的列及其值，用于发现展开后重名的列
+	notes        []ConversionNote
+}
+
+// addRow 展开一个数组元素作为一行
+func (t *csvTable) addRow(node *jsonNode) {
+	var row []string
+	t.filled = make(map[int]*jsonNode)
+	t.flatten(&row, "", node)
+	t.rows = append(t.rows, row)
+}
+
+// note 在值 node 的位置记录一条说明
+func (t *csvTable) note(node *jsonNode, format string, args ...interface{}) {
+	pos := newPosition(t.text, node.offset)
+	t.notes = append(t.notes, ConversionNote{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+// flatten 把节点展开到以 column 为前缀的列中
+func (t *csvTable) flatten(row *[]string, column string, node *jsonNode) {
+	switch node.kind {
+	case nodeObject:
+		if len(node.members) > 0 {
+			for _, m := range node.members {
+				t.flatten(row, joinColumn(column, m.keyValue()), m.value)
+			}
+			return
+		}
+	case nodeArray:
+		switch {
+		case t.arrays == ArraysIndex && len(node.elements) > 0:
+			for i, el := range node.elements {
+				t.flatten(row, joinColumn(column, strconv.Itoa(i)), el)
+			}
+			return
+		case t.arrays == ArraysJoin && onlyScalars(node):
+			parts := make([]string, 0, len(node.elements))
+			for _, el := range node.elements {
+				parts = append(parts, csvCell(el))
+			}
+			t.set(row, column, node, strings.Join(parts, t.separator))
+			return
+		}
+	}
+	t.set(row, column, node, csvCell(node))
+}
+
+// set 设置一行中某一列的值 value，node 为值所在的节点，新出现的列追加到最后；
+// 键中带点的属性与嵌套的属性展开后可能重名，如 "a.b" 与 "a": {"b"}，此时保留后面的值并记录说明
+func (t *csvTable) set(row *[]string, column string, node *jsonNode, value string) {
+	if column == "" {
+		column = csvValueColumn
+	}
+	i, ok := t.index[column]
+	if !ok {
+		i = len(t.columns)
+		t.index[column] = i
+		t.columns = append(t.columns, column)
+	}
+	for len(*row) <= i {
+		*row = append(*row, "")
+	}
+	if prev := t.filled[i]; prev != nil {
+		pos := newPosition(t.text, prev.offset)
+		t.note(node, "展开后的列 %s 与第 %d 行第 %d 列的值重名，保留后面的值", column, pos.Line, pos.Column)
+	}
+	t.filled[i] = node
+
+	if !t.keepFormulas && node.kind != nodeNumber && value != "" && strings.IndexByte(csvFormulaPrefixes, value[0]) >= 0 {
+		value = "'" + value
+		t.note(node, "列 %s 的值以 %q 开头，已在前面加上单引号，防止在表格软件中作为公式执行", column, value[1:2])
+	}
+	(*row)[i] = value
+}
+
+func joinColumn(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// csvCell 单元格的文本：字符串取其内容，null 为空，空对象、空数组及未展开的数组写为JSON文本
+func csvCell(node *jsonNode) string {
+	switch node.kind {
+	case nodeString:
+		return node.stringValue()
+	case nodeNumber:
+		return node.raw
+	case nodeLiteral:
+		if node.raw == "null" {
+			return ""
+		}
+		return node.raw
+	}
+	return formatJSONTree(node, FormatOptions{})
+}
+
+// resolvePointer 按 RFC 6901 的 JSON Pointer 查找节点，返回节点及其路径
+func resolvePointer(tree *jsonNode, pointer string) (*jsonNode, string, error) {
+	if pointer == "" {
+		return tree, "$", nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, "", fmt.Errorf("JSON Pointer 需要以 / 开头: %s", pointer)
+	}
+
+	node, path := tree, "$"
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		var next *jsonNode
+		switch node.kind {
+		case nodeObject:
+			for _, m := range node.members {
+				if m.keyValue() == token {
+					next = m.value
+				}
+			}
+			path += "." + token
+		case nodeArray:
+			i, err := strconv.Atoi(token)
+			if err == nil && i >= 0 && i < len(node.elements) && strconv.Itoa(i) == token {
+				next = node.elements[i]
+			}
+			path += "[" + token + "]"
+		}
+		if next == nil {
+			return nil, "", fmt.Errorf("JSON Pointer %s 指向的 %s 不存在", pointer, path)
+		}
+		node = next
+	}
+	return node, path, nil
+}
+
+// CSVToJSON 把CSV/TSV转换为对象数组，第一行为表头，以点分隔的列名还原为嵌套的对象
+func (s *jsonProcessorService) CSVToJSON(ctx context.Context, text string, opts CSVOptions) (*ConversionResult, error) {
+	// Excel 导出的 UTF-8 文件带 BOM
+	body := strings.TrimPrefix(text, "\ufeff")
+	p := &csvParser{text: text, shift: len(text) - len(body), lineStarts: []int{0}}
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\n' {
+			p.lineStarts = append(p.lineStarts, i+1)
+		}
+	}
+
+	r := csv.NewReader(strings.NewReader(body))
+	r.Comma = opts.Format.comma()
+	r.FieldsPerRecord = -1
+	// TSV 中的引号通常不作为转义
+	r.LazyQuotes = opts.Format == CSVTab
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("输入中没有表头")
+	}
+	if err != nil {
+		err = p.error(err)
+		zlog.Errorf(ctx, "CSVToJSON: read header failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+	columns, err := p.columns(r, header)
+	if err != nil {
+		zlog.Errorf(ctx, "CSVToJSON: parse header failed, error: %v", err)
+		return nil, err
+	}
+
+	tree := &jsonNode{kind: nodeArray}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			err = p.error(err)
+			zlog.Errorf(ctx, "CSVToJSON: read record failed, error: %v", err)
+			return nil, err
+		}
+		line, column := r.FieldPos(0)
+		offset := p.offset(line, column)
+		if len(record) != len(columns) {
+			err := &SyntaxError{Position: newPosition(text, offset), Message: fmt.Sprintf("该行有 %d 个字段，与表头的 %d 列不一致", len(record), len(columns))}
+			zlog.Errorf(ctx, "CSVToJSON: field count mismatch, error: %v", err)
+			return nil, err
+		}
+
+		row := &jsonNode{kind: nodeObject, offset: offset}
+		for i, cell := range record {
+			setPath(row, columns[i], csvValue(cell, opts.RawStrings))
+		}
+		indexedArrays(row)
+		tree.elements = append(tree.elements, row)
+	}
+
+	result := formatJSONTree(tree, opts.FormatOptions)
+	zlog.Infof(ctx, "CSVToJSON: successfully converted, rows: %d, columns: %d, output length: %d", len(tree.elements), len(columns), len(result))
+	return &ConversionResult{Result: result, Notes: p.notes}, nil
+}
+
+// csvParser 记录行的位置，把 encoding/csv 的行列转换为原文中的位置
+type csvParser struct {
+	text       string
+	shift      int   // BOM 的长度
+	lineStarts []int // 去掉 BOM 后每行开始的偏移
+	notes      []ConversionNote
+}
+
+// offset 行号和列号（从1开始的字节下标）对应的原文偏移
+func (p *csvParser) offset(line, column int) int {
+	if line < 1 || line > len(p.lineStarts) {
+		return len(p.text)
+	}
+	return min(p.shift+p.lineStarts[line-1]+max(column-1, 0), len(p.text))
+}
+
+// error 把 encoding/csv 的错误转换为带位置的语法错误
+func (p *csvParser) error(err error) error {
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) {
+		return fmt.Errorf("CSV格式错误: %v", err)
+	}
+	message := parseErr.Err.Error()
+	switch {
+	case errors.Is(parseErr.Err, csv.ErrBareQuote):
+		message = "未加引号的字段中不能出现引号，字段中的引号需要写为两个引号并给字段加上引号"
+	case errors.Is(parseErr.Err, csv.ErrQuote):
+		message = "引号没有正确结束"
+	}
+	return &SyntaxError{Position: newPosition(p.text, p.offset(parseErr.Line, parseErr.Column)), Message: message}
+}
+
+// columns 把表头拆分为每一列的键路径；
+// 列名与其他列的路径冲突（如同时有 a 和 a.b）或含有空的部分时，整个列名作为一个键
+func (p *csvParser) columns(r *csv.Reader, header []string) ([][]string, error) {
+	names := make(map[string]bool, len(header))
+	for i, name := range header {
+		line, column := r.FieldPos(i)
+		if name == "" {
+			name = fmt.Sprintf("column%d", i+1)
+			header[i] = name
+			p.notes = append(p.notes, ConversionNote{Line: line, Column: column, Message: fmt.Sprintf("第 %d 列没有列名，使用 %s", i+1, name)})
+		}
+		if names[name] {
+			return nil, &SyntaxError{Position: newPosition(p.text, p.offset(line, column)), Message: fmt.Sprintf("列名 %s 重复", name)}
+		}
+		names[name] = true
+	}
+
+	columns := make([][]string, len(header))
+	for i, name := range header {
+		parts := strings.Split(name, ".")
+		for j := 1; j < len(parts); j++ {
+			if names[strings.Join(parts[:j], ".")] {
+				parts = []string{name}
+				line, column := r.FieldPos(i)
+				p.notes = append(p.notes, ConversionNote{Line: line, Column: column, Message: fmt.Sprintf("列 %s 与列 %s 冲突，不再展开为嵌套的对象", name, strings.Join(strings.Split(name, ".")[:j], "."))})
+				break
+			}
+		}
+		for _, part := range parts {
+			if part == "" {
+				parts = []string{name}
+				break
+			}
+		}
+		columns[i] = parts
+	}
+	return columns, nil
+}
+
+// setPath 按键路径设置对象中的值，缺少的中间对象自动创建
+func setPath(obj *jsonNode, path []string, value *jsonNode) {
+	for _, key := range path[:len(path)-1] {
+		var next *jsonNode
+		for _, m := range obj.members {
+			if m.keyValue() == key {
+				next = m.value
+			}
+		}
+		if next == nil {
+			next = &jsonNode{kind: nodeObject}
+			appendMember(obj, key, next)
+		}
+		obj = next
+	}
+	appendMember(obj, path[len(path)-1], value)
+}
+
+// indexedArrays 把键依次为 0、1、2…的嵌套对象还原为数组，对应 index 写法展开的列
+func indexedArrays(node *jsonNode) {
+	for _, m := range node.members {
+		if m.value.kind != nodeObject || len(m.value.members) == 0 {
+			continue
+		}
+		indexedArrays(m.value)
+		indexed := true
+		for i, member := range m.value.members {
+			if member.keyValue() != strconv.Itoa(i) {
+				indexed = false
+				break
+			}
+		}
+		if indexed {
+			array := &jsonNode{kind: nodeArray}
+			for _, member := range m.value.members {
+				array.elements = append(array.elements, member.value)
+			}
+			m.value = array
+		}
+	}
+}
+
+// csvValue 推断单元格的类型：空为 null，JSON数字、true、false、null 及JSON数组和对象转换为对应的值，其余为字符串
+func csvValue(cell string, raw bool) *jsonNode {
+	if raw {
+		return newStringNode(cell)
+	}
+	if cell == "" {
+		return newLiteralNode("null")
+	}
+	if isJSONScalar(cell) {
+		if cell == "true" || cell == "false" || cell == "null" {
+			return newLiteralNode(cell)
+		}
+		return &jsonNode{kind: nodeNumber, raw: cell}
+	}
+	if cell[0] == '[' || cell[0] == '{' {
+		if node, err := parseJSONTree(cell); err == nil {
+			return node
+		}
+	}
+	return newStringNode(cell)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONToCSV(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    CSVOptions
+		want    string
+		notes   []string // 每条说明中应包含的文字
+		errPath string   // 期望 ConversionError 的路径
+		wantErr string
+	}{
+		{
+			name:  "展开嵌套对象并合并所有行的列",
+			input: `[{"id":1,"user":{"name":"a","tags":["x","y"]}},{"id":2,"ok":true,"user":{"name":"b, c"}},{"id":3,"user":null}]`,
+			want:  "id,user.name,user.tags,ok,user\n1,a,\"[\"\"x\"\",\"\"y\"\"]\",,\n2,\"b, c\",,true,\n3,,,,",
+		},
+		{
+			name:  "按 JSON Pointer 选择数组并连接数组元素",
+			input: `{"data":{"a/b":[{"tags":["x","y"],"n":[{"k":1}]}]}}`,
+			opts:  CSVOptions{Pointer: "/data/a~1b", Arrays: ArraysJoin},
+			want:  "tags,n\nx;y,\"[{\"\"k\"\":1}]\"",
+		},
+		{
+			name:  "数组按下标展开为多列",
+			input: `[{"tags":["x","y"],"e":[],"o":{}}]`,
+			opts:  CSVOptions{Arrays: ArraysIndex, Format: CSVTab},
+			want:  "tags.0\ttags.1\te\to\nx\ty\t[]\t{}",
+		},
+		{
+			name:  "标量元素",
+			input: `[1,"a"]`,
+			want:  "value\n1\na",
+		},
+		{
+			name:  "BOM和CRLF",
+			input: `{"a":1}`,
+			opts:  CSVOptions{BOM: true, FormatOptions: FormatOptions{CRLF: true, TrailingNewline: true}},
+			want:  "\ufeffa\r\n1\r\n",
+		},
+		{
+			name:  "带点的键与嵌套的属性展开后重名",
+			input: `[{"a.b":1,"a":{"b":2}}]`,
+			want:  "a.b\n2",
+			notes: []string{"展开后的列 a.b 与第 1 行第 9 列的值重名"},
+		},
+		{
+			name:  "可能作为公式执行的单元格加单引号",
+			input: `[{"f":"=SUM(A1)","n":-1,"s":"-1","at":"@x","ok":"a=b"}]`,
+			want:  "f,n,s,at,ok\n'=SUM(A1),-1,'-1,'@x,a=b",
+			notes: []string{"列 f 的值以 \"=\" 开头", "列 s 的值以 \"-\" 开头", "列 at 的值以 \"@\" 开头"},
+		},
+		{
+			name:  "保留公式",
+			input: `[{"f":"=SUM(A1)"}]`,
+			opts:  CSVOptions{KeepFormulas: true},
+			want:  "f\n=SUM(A1)",
+		},
+		{
+			name:    "不是数组或对象",
+			input:   `{"a":"x"}`,
+			opts:    CSVOptions{Pointer: "/a"},
+			errPath: "$.a",
+		},
+		{
+			name:    "JSON Pointer 不存在",
+			input:   `{"a":[1]}`,
+			opts:    CSVOptions{Pointer: "/a/1"},
+			wantErr: "$.a[1] 不存在",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.JSONToCSV(ctx, tt.input, tt.opts)
+			if tt.errPath != "" {
+				var convErr *ConversionError
+				if !errors.As(err, &convErr) {
+					t.Fatalf("JSONToCSV() error = %v, want ConversionError", err)
+				}
+				if convErr.Path != tt.errPath {
+					t.Errorf("ConversionError.Path = %s, want %s", convErr.Path, tt.errPath)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("JSONToCSV() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONToCSV() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("JSONToCSV() = %q, want %q", result.Result, tt.want)
+			}
+			if len(result.Notes) != len(tt.notes) {
+				t.Fatalf("JSONToCSV() notes = %v, want %d notes", result.Notes, len(tt.notes))
+			}
+			for i, note := range result.Notes {
+				if !strings.Contains(note.Message, tt.notes[i]) {
+					t.Errorf("note %d = %q, want containing %q", i, note.Message, tt.notes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCSVToJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    CSVOptions
+		want    string
+		notes   int
+		wantErr string
+	}{
+		{
+			name:  "推断类型并还原嵌套对象",
+			input: "\ufeffid,user.name,user.tags,zip,ok,note\n1,a,\"[\"\"x\"\"]\",007,true,\n2.5,\"b, c\",[x,false,null,",
+			want:  `[{"id":1,"user":{"name":"a","tags":["x"]},"zip":"007","ok":true,"note":null},{"id":2.5,"user":{"name":"b, c","tags":"[x"},"zip":false,"ok":null,"note":null}]`,
+		},
+		{
+			name:  "下标列还原为数组",
+			input: "tags.0\ttags.1\tp.x\nx\ty\t1",
+			opts:  CSVOptions{Format: CSVTab},
+			want:  `[{"tags":["x","y"],"p":{"x":1}}]`,
+		},
+		{
+			name:  "不推断类型",
+			input: "a,b\n1,",
+			opts:  CSVOptions{RawStrings: true},
+			want:  `[{"a":"1","b":""}]`,
+		},
+		{
+			name:  "冲突的列名和空列名",
+			input: "a,a.b,\n1,2,3",
+			want:  `[{"a":1,"a.b":2,"column3":3}]`,
+			notes: 2,
+		},
+		{
+			name:    "字段数与表头不一致",
+			input:   "a,b\n1,2\n3",
+			wantErr: "第 3 行第 1 列",
+		},
+		{
+			name:    "重复的列名",
+			input:   "a,b,a\n1,2,3",
+			wantErr: "列名 a 重复",
+		},
+		{
+			name:    "未加引号的字段中有引号",
+			input:   "a\nx\"y",
+			wantErr: "第 2 行第 2 列",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.CSVToJSON(ctx, tt.input, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("CSVToJSON() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CSVToJSON() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("CSVToJSON() = %s, want %s", result.Result, tt.want)
+			}
+			if len(result.Notes) != tt.notes {
+				t.Errorf("CSVToJSON() notes = %v, want %d", result.Notes, tt.notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"sojson/zlog"
+)
+
+// maxExpandLayers 最多去除的转义层数，防止异常输入导致死循环
+const maxExpandLayers = 32
+
+// DeepExpandResult 深度展开结果
+type DeepExpandResult struct {
+	Result        string   // 格式化后的JSON
+	Layers        int      // 去除的转义层数
+	ExpandedPaths []string // 被展开为JSON结构的字符串字段路径
+}
+
+// DeepExpandJSON 深度展开：反复去除转义直到文本可以解析，
+// 并把内容为JSON对象或数组的字符串字段替换为解析后的结构
+func (s *jsonProcessorService) DeepExpandJSON(ctx context.Context, text string, opts ProcessOptions) (*DeepExpandResult, error) {
+	fixed, quoted, err := s.quoteValues(text, opts.QuoteRules, DialectJSON)
+	if err != nil {
+		zlog.Errorf(ctx, "DeepExpandJSON: QuoteBareValues failed, rules: %d, error: %v", len(opts.QuoteRules), err)
+		return nil, err
+	}
+
+	tree, layers, err := s.unwrapLayers(fixed)
+	if err != nil {
+		zlog.Errorf(ctx, "DeepExpandJSON: unwrapLayers failed, input text length: %d, layers: %d, error: %v", len(text), layers, err)
+		return nil, relocate(err, text, quoted)
+	}
+
+	var paths []string
+	tree = expandEmbedded(tree, "$", &paths)
+	result := formatJSONTree(tree, opts.FormatOptions)
+
+	zlog.Infof(ctx, "DeepExpandJSON: successfully expanded JSON, input length: %d, layers: %d, expanded paths: %d", len(text), layers, len(paths))
+	return &DeepExpandResult{
+		Result:        result,
+		Layers:        layers,
+		ExpandedPaths: paths,
+	}, nil
+}
+
+// unwrapLayers 逐层去除转义，直到得到非字符串的JSON值；错误的位置对应输入 text
+func (s *jsonProcessorService) unwrapLayers(text string) (*jsonNode, int, error) {
+	original := text
+	maps := []offsetMap{{{out: 0, in: len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))}}}
+	text = strings.TrimSpace(text)
+
+	for layers := 0; layers <= maxExpandLayers; layers++ {
+		tree, err := parseJSONTree(text)
+		if err == nil && tree.kind != nodeString {
+			return tree, layers, nil
+		}
+
+		// 既不能解析也没有可去除的转义
+		if err != nil && !strings.Contains(text, `\`) && !strings.HasPrefix(text, `"`) {
+			return nil, layers, relocate(err, original, maps...)
+		}
+
+		// 整体是一个合法的JSON字符串时取出其内容，否则去除转义，再继续展开
+		unescaped, escaped, err := unescapeJSON(text)
+		if err != nil {
+			return nil, layers, relocate(err, original, maps...)
+		}
+		trimmed := strings.TrimLeftFunc(unescaped, unicode.IsSpace)
+		shift := len(unescaped) - len(trimmed)
+		trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+		if trimmed == text {
+			return nil, layers, fmt.Errorf("去除 %d 层转义后仍无法解析JSON", layers)
+		}
+		maps = append(maps, escaped, offsetMap{{out: 0, in: shift}})
+		text = trimmed
+	}
+
+	return nil, maxExpandLayers, fmt.Errorf("转义层数超过上限 %d", maxExpandLayers)
+}
+
+// expandEmbedded 递归地把内容为JSON对象或数组的字符串替换为解析后的结构，
+// path 为当前值的路径，被展开的路径追加到 paths
+func expandEmbedded(node *jsonNode, path string, paths *[]string) *jsonNode {
+	switch node.kind {
+	case nodeObject:
+		for _, m := range node.members {
+			m.value = expandEmbedded(m.value, path+"."+m.keyValue(), paths)
+		}
+	case nodeArray:
+		for i, e := range node.elements {
+			node.elements[i] = expandEmbedded(e, fmt.Sprintf("%s[%d]", path, i), paths)
+		}
+	case nodeString:
+		if parsed, ok := parseEmbedded(node.stringValue()); ok {
+			*paths = append(*paths, path)
+			return expandEmbedded(parsed, path, paths)
+		}
+	}
+	return node
+}
+
+// parseEmbedded 尝试把字符串解析为JSON对象或数组，支持多次字符串化的内容
+func parseEmbedded(str string) (*jsonNode, bool) {
+	for i := 0; i < maxExpandLayers; i++ {
+		str = strings.TrimSpace(str)
+		if str == "" || (str[0] != '{' && str[0] != '[' && str[0] != '"') {
+			return nil, false
+		}
+		tree, err := parseJSONTree(str)
+		if err != nil {
+			return nil, false
+		}
+		if tree.kind != nodeString {
+			return tree, true
+		}
+		str = tree.stringValue()
+	}
+	return nil, false
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDeepExpandJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		layers   int
+		paths    []string
+	}{
+		{
+			name:     "未转义的JSON",
+			input:    `{"a":1}`,
+			expected: `{"a":1}`,
+			layers:   0,
+		},
+		{
+			name:     "一层字符串化",
+			input:    `"{\"a\":1}"`,
+			expected: `{"a":1}`,
+			layers:   1,
+		},
+		{
+			name:     "两层字符串化",
+			input:    `"\"{\\\"a\\\":1}\""`,
+			expected: `{"a":1}`,
+			layers:   2,
+		},
+		{
+			name:     "没有外层引号的转义文本",
+			input:    `{\"a\":[1,2]}`,
+			expected: `{"a":[1,2]}`,
+			layers:   1,
+		},
+		{
+			name:     "内嵌的JSON字符串字段",
+			input:    `{"payload":"{\"b\":\"[1,{\\\"c\\\":true}]\"}","msg":"{not json"}`,
+			expected: `{"payload":{"b":[1,{"c":true}]},"msg":"{not json"}`,
+			layers:   0,
+			paths:    []string{"$.payload", "$.payload.b"},
+		},
+		{
+			name:     "多次字符串化的字段",
+			input:    `{"data":["\"{\\\"x\\\":1}\""]}`,
+			expected: `{"data":[{"x":1}]}`,
+			layers:   0,
+			paths:    []string{"$.data[0]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.DeepExpandJSON(ctx, tt.input, ProcessOptions{})
+			if err != nil {
+				t.Fatalf("DeepExpandJSON() unexpected error = %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("DeepExpandJSON() result = %s, want %s", result.Result, tt.expected)
+			}
+			if result.Layers != tt.layers {
+				t.Errorf("DeepExpandJSON() layers = %d, want %d", result.Layers, tt.layers)
+			}
+			if !reflect.DeepEqual(result.ExpandedPaths, tt.paths) {
+				t.Errorf("DeepExpandJSON() paths = %v, want %v", result.ExpandedPaths, tt.paths)
+			}
+		})
+	}
+}
+
+func TestDeepExpandJSONInvalid(t *testing.T) {
+	_, err := JSONProcessorService.DeepExpandJSON(context.Background(), `{"a":}`, ProcessOptions{FormatOptions: FormatOptions{Indent: 2}})
+	if err == nil {
+		t.Error("DeepExpandJSON() expected error but got none")
+	}
+}
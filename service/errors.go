@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// snippetRadius 错误上下文片段在出错位置前后各保留的字符数
+const snippetRadius = 20
+
+// Position 错误在输入文本中的位置
+type Position struct {
+	Offset  int    // 字节偏移，从 0 开始
+	Line    int    // 行号，从 1 开始
+	Column  int    // 列号（按 UTF-16 码元计，与浏览器和 Monaco 编辑器一致），从 1 开始
+	Snippet string // 出错位置所在行的上下文片段
+}
+
+// PositionError 带位置信息的错误
+type PositionError interface {
+	error
+	Pos() Position
+}
+
+// newPosition 计算 offset 在 text 中的行列号和上下文片段
+func newPosition(text string, offset int) Position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+	lineEnd := strings.IndexByte(text[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(text)
+	} else {
+		lineEnd += offset
+	}
+
+	before := []rune(text[lineStart:offset])
+	after := []rune(strings.TrimRight(text[offset:lineEnd], "\r"))
+	if len(before) > snippetRadius {
+		before = before[len(before)-snippetRadius:]
+	}
+	if len(after) > snippetRadius {
+		after = after[:snippetRadius]
+	}
+
+	return Position{
+		Offset:  offset,
+		Line:    strings.Count(text[:offset], "\n") + 1,
+		Column:  utf16Len(text[lineStart:offset]) + 1,
+		Snippet: string(before) + string(after),
+	}
+}
+
+// utf16Len 文本按 UTF-16 编码的码元数，emoji 等辅助平面的字符占 2 个
+func utf16Len(text string) int {
+	n := 0
+	for _, r := range text {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// offsetMap 文本改写后的偏移到改写前偏移的对应关系，按改写后的偏移排列；
+// 用于把在加引号、去除转义后的文本中得到的位置映射回用户输入的原始文本
+type offsetMap []offsetAnchor
+
+// offsetAnchor 改写后文本中从 out 开始的内容对应改写前文本中从 in 开始的内容
+type offsetAnchor struct {
+	out int
+	in  int
+}
+
+// add 追加一个对应点，与上一个对应点重合时忽略
+func (m *offsetMap) add(out int, in int) {
+	if n := len(*m); n > 0 && (*m)[n-1] == (offsetAnchor{out, in}) {
+		return
+	}
+	*m = append(*m, offsetAnchor{out, in})
+}
+
+// original 改写后文本中的偏移对应的改写前偏移，被改写的片段内的偏移不会超出该片段
+func (m offsetMap) original(offset int) int {
+	i := sort.Search(len(m), func(i int) bool { return m[i].out > offset }) - 1
+	if i < 0 {
+		return offset
+	}
+	in := m[i].in + offset - m[i].out
+	if i+1 < len(m) && in > m[i+1].in {
+		in = m[i+1].in
+	}
+	return in
+}
+
+// relocate 把在改写后的文本中得到的错误位置换算为原始文本 text 中的位置，maps 按改写的先后排列；
+// 不带位置的错误原样返回
+func relocate(err error, text string, maps ...offsetMap) error {
+	var posErr PositionError
+	if !errors.As(err, &posErr) {
+		return err
+	}
+	offset := posErr.Pos().Offset
+	for i := len(maps) - 1; i >= 0; i-- {
+		offset = maps[i].original(offset)
+	}
+	pos := newPosition(text, offset)
+
+	switch e := err.(type) {
+	case *SyntaxError:
+		relocated := *e
+		relocated.Position = pos
+		return &relocated
+	case *UnescapeError:
+		relocated := *e
+		relocated.Position = pos
+		return &relocated
+	case *ConversionError:
+		relocated := *e
+		relocated.Position = pos
+		return &relocated
+	}
+	return err
+}
+
+// SyntaxError JSON语法错误
+type SyntaxError struct {
+	Position
+	Expected string // 期望出现的内容
+	Message  string // 错误描述
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("第 %d 行第 %d 列语法错误: %s", e.Line, e.Column, e.Message)
+}
+
+// Pos 返回错误位置
+func (e *SyntaxError) Pos() Position {
+	return e.Position
+}
+
+// ConversionError 值无法转换为目标格式的错误，如 TOML 中的 null
+type ConversionError struct {
+	Position
+	Path   string // 值的路径，如 $.server.ports[0]
+	Target string // 目标格式
+	Reason string // 错误原因
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("第 %d 行第 %d 列的 %s 无法转换为%s: %s", e.Line, e.Column, e.Path, e.Target, e.Reason)
+}
+
+// Pos 返回错误位置
+func (e *ConversionError) Pos() Position {
+	return e.Position
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateJSONErrorPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		line     int
+		column   int
+		expected string
+		snippet  string
+	}{
+		{
+			name:     "多行输入中缺少值",
+			input:    "{\n  \"a\": 1,\n  \"b\": }",
+			line:     3,
+			column:   8,
+			expected: "JSON值",
+			snippet:  `  "b": }`,
+		},
+		{
+			name:     "中文字符按字符计列",
+			input:    `{"名称":"值" "x":1}`,
+			line:     1,
+			column:   11,
+			expected: "',' 或 '}'",
+			snippet:  `{"名称":"值" "x":1}`,
+		},
+		{
+			name:     "emoji按UTF-16码元计为两列",
+			input:    `{"😀":1 "x":2}`,
+			line:     1,
+			column:   9,
+			expected: "',' 或 '}'",
+			snippet:  `{"😀":1 "x":2}`,
+		},
+		{
+			name:     "文本提前结束",
+			input:    "[1,\r\n2",
+			line:     2,
+			column:   2,
+			expected: "',' 或 ']'",
+			snippet:  "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := JSONProcessorService.ValidateJSON(tt.input)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("ValidateJSON() error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column {
+				t.Errorf("ValidateJSON() position = %d:%d, want %d:%d", syntaxErr.Line, syntaxErr.Column, tt.line, tt.column)
+			}
+			if syntaxErr.Expected != tt.expected {
+				t.Errorf("ValidateJSON() expected = %q, want %q", syntaxErr.Expected, tt.expected)
+			}
+			if syntaxErr.Snippet != tt.snippet {
+				t.Errorf("ValidateJSON() snippet = %q, want %q", syntaxErr.Snippet, tt.snippet)
+			}
+		})
+	}
+}
+
+func TestUnescapeErrorPosition(t *testing.T) {
+	_, err := JSONProcessorService.UnescapeJSON("line1\nab\\q")
+	var posErr PositionError
+	if !errors.As(err, &posErr) {
+		t.Fatalf("UnescapeJSON() error = %v, want PositionError", err)
+	}
+	if pos := posErr.Pos(); pos.Line != 2 || pos.Column != 3 || pos.Offset != 8 {
+		t.Errorf("UnescapeJSON() position = %+v, want line 2 column 3 offset 8", pos)
+	}
+}
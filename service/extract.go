@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sojson/zlog"
+)
+
+// ExtractedFragment 从文本中提取出的JSON片段
+type ExtractedFragment struct {
+	Position        // 片段在原文中的开始位置
+	End      int    // 片段在原文中的结束字节偏移（不含）
+	Escaped  bool   // 片段在原文中是否经过转义，如 msg="{\"a\":1}"
+	Result   string // 格式化后的片段
+}
+
+// ExtractResult 提取结果
+type ExtractResult struct {
+	Fragments []ExtractedFragment // 按出现顺序排列的片段
+	Result    string              // inline 时为把片段替换为格式化结果后的全文
+}
+
+// fragmentSpan 片段在原文中的范围
+type fragmentSpan struct {
+	start   int
+	end     int
+	tree    *jsonNode
+	escaped bool
+}
+
+// ExtractJSON 找出混在日志等任意文本中的JSON对象和数组，包括带引号的字符串中转义过的JSON；
+// inline 为 true 时把格式化后的片段放回原文，转义过的片段重新转义后放回
+func (s *jsonProcessorService) ExtractJSON(ctx context.Context, text string, inline bool, opts FormatOptions) (*ExtractResult, error) {
+	spans := scanFragments(text)
+	if len(spans) == 0 {
+		zlog.Errorf(ctx, "ExtractJSON: no fragment found, input text length: %d", len(text))
+		return nil, fmt.Errorf("文本中没有找到JSON对象或数组")
+	}
+
+	result := &ExtractResult{Fragments: make([]ExtractedFragment, 0, len(spans))}
+	var sb strings.Builder
+	last := 0
+	for _, span := range spans {
+		formatted := formatJSONTree(span.tree, opts)
+		result.Fragments = append(result.Fragments, ExtractedFragment{
+			Position: newPosition(text, span.start),
+			End:      span.end,
+			Escaped:  span.escaped,
+			Result:   formatted,
+		})
+		if inline {
+			sb.WriteString(text[last:span.start])
+			if span.escaped {
+				// 放回外层字符串中时重新转义，保持原文的字符串完整
+				quoted := encodeJSONString(formatted, false, false)
+				sb.WriteString(quoted[1 : len(quoted)-1])
+			} else {
+				sb.WriteString(formatted)
+			}
+			last = span.end
+		}
+	}
+	if inline {
+		sb.WriteString(text[last:])
+		result.Result = sb.String()
+	}
+
+	zlog.Infof(ctx, "ExtractJSON: successfully extracted fragments, input length: %d, fragments: %d", len(text), len(result.Fragments))
+	return result, nil
+}
+
+// scanFragments 从左到右查找能完整解析的对象和数组，找到后从片段末尾继续查找
+func scanFragments(text string) []fragmentSpan {
+	sc := &fragmentScanner{
+		text:          text,
+		rawFailed:     make(map[int]bool),
+		escapedEnd:    make(map[int]int),
+		escapedFailed: make(map[int]bool),
+	}
+	var spans []fragmentSpan
+	for i := 0; i < len(text); i++ {
+		if c := text[i]; c != '{' && c != '[' {
+			continue
+		}
+		if span, ok := sc.raw(i); ok {
+			spans = append(spans, span)
+			i = span.end - 1
+			continue
+		}
+		if span, ok := sc.escaped(i); ok {
+			spans = append(spans, span)
+			i = span.end - 1
+		}
+	}
+	return spans
+}
+
+// fragmentScanner 记录查找过程中已经确定的结果。从某个括号开始解析失败时，失败位置之前仍未闭合的括号
+// 从自身开始解析也会在同一处失败，记录下来不再重复尝试，使查找的耗时与文本长度成线性关系，
+// 避免大量不成对的括号拖慢请求；超过嵌套深度限制的片段因此整体放弃
+type fragmentScanner struct {
+	text          string
+	rawFailed     map[int]bool // 按原文解析会失败的括号
+	escapedEnd    map[int]int  // 当作转义过一层的JSON时与括号匹配的括号之后的位置，-1 表示没有匹配的括号
+	escapedFailed map[int]bool // 去掉一层转义后解析会失败的括号
+}
+
+// raw 按原文从 start 开始解析一个对象或数组
+func (sc *fragmentScanner) raw(start int) (fragmentSpan, bool) {
+	if sc.rawFailed[start] {
+		return fragmentSpan{}, false
+	}
+	p := &jsonParser{data: sc.text, pos: start, offsetOnly: true}
+	tree, err := p.parseValue()
+	if err == nil {
+		return fragmentSpan{start: start, end: p.pos, tree: tree}, true
+	}
+	for _, open := range unclosedBrackets(sc.text, start, p.pos) {
+		sc.rawFailed[open] = true
+	}
+	return fragmentSpan{}, false
+}
+
+// escaped 把 start 处开始的文本当作转义过一层的JSON，去掉转义后解析
+func (sc *fragmentScanner) escaped(start int) (fragmentSpan, bool) {
+	if sc.escapedFailed[start] {
+		return fragmentSpan{}, false
+	}
+	end := sc.escapedMatch(start)
+	if end < 0 {
+		return fragmentSpan{}, false
+	}
+
+	decoded, err := decodeJSONString(sc.text[start:end], start)
+	if err == nil {
+		var tree *jsonNode
+		if tree, err = parseJSONTree(decoded); err == nil {
+			return fragmentSpan{start: start, end: end, tree: tree, escaped: true}, true
+		}
+	}
+	var posErr PositionError
+	if !errors.As(err, &posErr) {
+		return fragmentSpan{}, false
+	}
+	failAt := posErr.Pos().Offset
+	if _, ok := err.(*UnescapeError); !ok {
+		// 解析错误的位置在去掉转义后的文本中
+		failAt = escapeOffsets(sc.text[start:end], start).original(failAt)
+	}
+
+	// 在失败位置之前开始、之后结束的括号去掉转义后同样会在该处失败
+	for i := start; i <= failAt && i < end; i++ {
+		if e, ok := sc.escapedEnd[i]; ok && e > failAt {
+			sc.escapedFailed[i] = true
+		}
+	}
+	return fragmentSpan{}, false
+}
+
+// escapedMatch 把 start 处开始的文本当作转义过一层的JSON，返回与开头括号匹配的括号之后的位置，没有时返回 -1；
+// 遇到未转义的引号说明外层字符串已经结束。扫描中经过的字符串以外的括号从自身开始扫描的结果相同，一并记录
+func (sc *fragmentScanner) escapedMatch(start int) int {
+	if end, ok := sc.escapedEnd[start]; ok {
+		return end
+	}
+
+	text := sc.text
+	var stack []int
+	fail := func() int {
+		for _, open := range stack {
+			sc.escapedEnd[open] = -1
+		}
+		return -1
+	}
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		// 去掉一层转义后的字符，\n、\uXXXX 等对括号匹配没有影响，用 x 代替
+		pos := i
+		c := text[i]
+		switch c {
+		case '"':
+			return fail()
+		case '\\':
+			if i+1 >= len(text) {
+				return fail()
+			}
+			i++
+			switch text[i] {
+			case '"', '\\', '/':
+				c = text[i]
+			case 'u':
+				i += 4
+				c = 'x'
+			default:
+				c = 'x'
+			}
+		}
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			stack = append(stack, pos)
+		case '}', ']':
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			sc.escapedEnd[open] = i + 1
+			if len(stack) == 0 {
+				return i + 1
+			}
+		}
+	}
+	return fail()
+}
+
+// unclosedBrackets 返回 text[start:end] 中没有闭合的括号的位置，跳过JSON字符串中的括号
+func unclosedBrackets(text string, start int, end int) []int {
+	var stack []int
+	inString := false
+	for i := start; i < end && i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			stack = append(stack, i)
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return stack
+}
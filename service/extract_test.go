@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		input     string
+		fragments []string
+		escaped   []bool
+		offsets   []int
+	}{
+		{
+			name:      "pino日志",
+			input:     `2025-08-18 08:04:19 INFO [svc] {"level":30,"msg":"ok"} extra`,
+			fragments: []string{`{"level":30,"msg":"ok"}`},
+			escaped:   []bool{false},
+			offsets:   []int{31},
+		},
+		{
+			name:      "多个片段和数组",
+			input:     "a={\"x\":1} b=[1, {\"y\": [2]}]\nc={bad} d=[]",
+			fragments: []string{`{"x":1}`, `[1,{"y":[2]}]`, `[]`},
+			escaped:   []bool{false, false, false},
+			offsets:   []int{2, 12, 38},
+		},
+		{
+			name:      "引号中转义的JSON",
+			input:     `level=info msg="{\"user\":\"bob\",\"tags\":[\"a\\\"b\",\"}\"]}" end`,
+			fragments: []string{`{"user":"bob","tags":["a\"b","}"]}`},
+			escaped:   []bool{true},
+			offsets:   []int{16},
+		},
+		{
+			name:      "未闭合的片段中完整的部分",
+			input:     `a=[1, {"b":[2]} oops c="[\"x\", {\"d\":1} oops"`,
+			fragments: []string{`{"b":[2]}`, `{"d":1}`},
+			escaped:   []bool{false, true},
+			offsets:   []int{6, 32},
+		},
+		{
+			name:      "嵌入JSON字符串中的片段整体提取",
+			input:     `log: {"payload":"{\"a\":1}"}`,
+			fragments: []string{`{"payload":"{\"a\":1}"}`},
+			escaped:   []bool{false},
+			offsets:   []int{5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.ExtractJSON(ctx, tt.input, false, FormatOptions{})
+			if err != nil {
+				t.Fatalf("ExtractJSON() unexpected error = %v", err)
+			}
+			if len(result.Fragments) != len(tt.fragments) {
+				t.Fatalf("ExtractJSON() fragments = %d, want %d", len(result.Fragments), len(tt.fragments))
+			}
+			for i, f := range result.Fragments {
+				if f.Result != tt.fragments[i] {
+					t.Errorf("fragment %d = %s, want %s", i, f.Result, tt.fragments[i])
+				}
+				if f.Escaped != tt.escaped[i] {
+					t.Errorf("fragment %d escaped = %t, want %t", i, f.Escaped, tt.escaped[i])
+				}
+				if f.Offset != tt.offsets[i] {
+					t.Errorf("fragment %d offset = %d, want %d", i, f.Offset, tt.offsets[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractJSONInline(t *testing.T) {
+	ctx := context.Background()
+	input := "08:04:19 INFO {\"a\":1,\"b\":[2]} done\n08:04:20 WARN msg=\"{\\\"c\\\":true}\""
+	expected := "08:04:19 INFO {\n  \"a\": 1,\n  \"b\": [\n    2\n  ]\n} done\n08:04:20 WARN msg=\"{\\n  \\\"c\\\": true\\n}\""
+
+	result, err := JSONProcessorService.ExtractJSON(ctx, input, true, FormatOptions{Indent: 2})
+	if err != nil {
+		t.Fatalf("ExtractJSON() unexpected error = %v", err)
+	}
+	if result.Result != expected {
+		t.Errorf("ExtractJSON() =\n%s\nwant:\n%s", result.Result, expected)
+	}
+	if result.Fragments[1].Line != 2 || result.Fragments[1].End != len(input)-1 {
+		t.Errorf("ExtractJSON() second fragment position = %+v, end %d", result.Fragments[1].Position, result.Fragments[1].End)
+	}
+}
+
+func TestExtractJSONNotFound(t *testing.T) {
+	ctx := context.Background()
+	for _, input := range []string{"", "INFO [svc] started", `msg="{\"a\":}"`} {
+		if _, err := JSONProcessorService.ExtractJSON(ctx, input, false, FormatOptions{}); err == nil {
+			t.Errorf("ExtractJSON(%q) expected error", input)
+		}
+	}
+}
+
+func TestExtractJSONUnbalanced(t *testing.T) {
+	ctx := context.Background()
+	const n = 100000
+	inputs := []string{
+		strings.Repeat("[", n),
+		strings.Repeat(`{"a":`, n),
+		strings.Repeat("[", n) + "x" + strings.Repeat("]", n),
+		strings.Repeat(`{\"a\":`, n),
+		strings.Repeat(`[\"`, n),
+	}
+	for _, input := range inputs {
+		// 每个括号都重新解析到文本末尾时耗时与长度的平方成正比，这里的输入需要几分钟
+		result, err := JSONProcessorService.ExtractJSON(ctx, input+` {"ok":true}`, false, FormatOptions{})
+		if err != nil {
+			t.Fatalf("ExtractJSON(%.20q...) unexpected error = %v", input, err)
+		}
+		if last := result.Fragments[len(result.Fragments)-1]; last.Result != `{"ok":true}` {
+			t.Errorf("ExtractJSON(%.20q...) last fragment = %s", input, last.Result)
+		}
+	}
+}
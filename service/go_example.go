@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"sojson/zlog"
+)
+
+// goPackagePrefix 粘贴的代码没有 package 子句时在第一行前补上，不改变行号
+const goPackagePrefix = "package p; "
+
+// goExampleTime time.Time 的示例值，取Go的参考时间
+const goExampleTime = "2006-01-02T15:04:05Z"
+
+// ExampleOptions 由Go结构体生成示例JSON的选项
+type ExampleOptions struct {
+	FormatOptions
+	TypeName  string // 作为顶层的类型名，默认为没有被其他类型引用的第一个类型
+	OmitEmpty bool   // 省略带 omitempty、omitzero 的字段，得到最小的示例
+}
+
+// GoExample 解析Go类型声明并生成示例JSON：按 encoding/json 的规则处理 json 标签、omitempty、-、string 选项和嵌入的结构体，
+// 同一段代码中声明的类型会展开，time.Time 等常用的外部类型输出为对应的写法，无法解析的类型输出为 null 并在 Notes 中说明
+func (s *jsonProcessorService) GoExample(ctx context.Context, text string, opts ExampleOptions) (*ConversionResult, error) {
+	e, err := newGoExampler(text, opts)
+	if err != nil {
+		zlog.Errorf(ctx, "GoExample: parse failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+
+	spec, err := e.rootType(opts.TypeName)
+	if err != nil {
+		zlog.Errorf(ctx, "GoExample: rootType failed, type name: %s, error: %v", opts.TypeName, err)
+		return nil, err
+	}
+	root := e.named(spec, spec.Name.Pos())
+
+	result := formatJSONTree(root, opts.FormatOptions)
+	zlog.Infof(ctx, "GoExample: successfully generated, type: %s, input length: %d, output length: %d, notes: %d", spec.Name.Name, len(text), len(result), len(e.notes))
+	return &ConversionResult{Result: result, Notes: e.notes}, nil
+}
+
+// goExampler 按类型声明生成示例值
+type goExampler struct {
+	text      string
+	prefix    int // 补上的 package 子句的长度
+	fset      *token.FileSet
+	omitEmpty bool
+	specs     []*ast.TypeSpec          // 按声明顺序排列的类型
+	types     map[string]*ast.TypeSpec // 按名称索引的类型
+	expanding map[string]bool          // 正在展开的类型，用于发现递归引用
+	notes     []ConversionNote
+	noted     map[string]bool // 已说明过的位置和内容
+}
+
+// newGoExampler 解析代码并收集顶层的类型声明，没有 package 子句时自动补上
+func newGoExampler(text string, opts ExampleOptions) (*goExampler, error) {
+	e := &goExampler{
+		text:      text,
+		fset:      token.NewFileSet(),
+		omitEmpty: opts.OmitEmpty,
+		types:     make(map[string]*ast.TypeSpec),
+		expanding: make(map[string]bool),
+		noted:     make(map[string]bool),
+	}
+
+	file, err := parser.ParseFile(e.fset, "", text, parser.SkipObjectResolution)
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 && strings.Contains(list[0].Msg, "expected 'package'") {
+		e.prefix = len(goPackagePrefix)
+		file, err = parser.ParseFile(e.fset, "", goPackagePrefix+text, parser.SkipObjectResolution)
+	}
+	if err != nil {
+		if errors.As(err, &list) && len(list) > 0 {
+			return nil, &SyntaxError{Position: newPosition(text, max(list[0].Pos.Offset-e.prefix, 0)), Message: list[0].Msg}
+		}
+		return nil, fmt.Errorf("Go代码解析失败: %v", err)
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range gen.Specs {
+			spec := s.(*ast.TypeSpec)
+			e.specs = append(e.specs, spec)
+			e.types[spec.Name.Name] = spec
+		}
+	}
+	return e, nil
+}
+
+// rootType 按名称查找顶层类型，未指定时取没有被其他类型引用的第一个类型
+func (e *goExampler) rootType(name string) (*ast.TypeSpec, error) {
+	if len(e.specs) == 0 {
+		return nil, errors.New("没有找到类型声明")
+	}
+	if name != "" {
+		spec, ok := e.types[name]
+		if !ok {
+			return nil, fmt.Errorf("没有找到类型 %s", name)
+		}
+		return spec, nil
+	}
+
+	referenced := make(map[string]bool)
+	for _, spec := range e.specs {
+		ast.Inspect(spec.Type, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && ident.Name != spec.Name.Name {
+				referenced[ident.Name] = true
+			}
+			return true
+		})
+	}
+	for _, spec := range e.specs {
+		if !referenced[spec.Name.Name] {
+			return spec, nil
+		}
+	}
+	return e.specs[0], nil
+}
+
+// note 记录一条说明，同一位置的相同说明只记录一次
+func (e *goExampler) note(pos token.Pos, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	p := newPosition(e.text, max(e.fset.Position(pos).Offset-e.prefix, 0))
+	key := fmt.Sprintf("%d:%s", p.Offset, message)
+	if e.noted[key] {
+		return
+	}
+	e.noted[key] = true
+	e.notes = append(e.notes, ConversionNote{Line: p.Line, Column: p.Column, Message: message})
+}
+
+// named 展开命名类型，递归引用自身时输出为 null 并在引用的位置说明
+func (e *goExampler) named(spec *ast.TypeSpec, pos token.Pos) *jsonNode {
+	name := spec.Name.Name
+	if e.expanding[name] {
+		e.note(pos, "%s 递归引用自身，示例中为 null", name)
+		return newLiteralNode("null")
+	}
+	e.expanding[name] = true
+	defer delete(e.expanding, name)
+	return e.value(spec.Type)
+}
+
+// recursive 类型是否引用了正在展开的类型，作为数组元素或 map 的值时输出为空的数组或对象
+func (e *goExampler) recursive(expr ast.Expr) bool {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.Ident:
+			return e.expanding[t.Name]
+		default:
+			return false
+		}
+	}
+}
+
+// value 类型的示例值
+func (e *goExampler) value(expr ast.Expr) *jsonNode {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return e.ident(t)
+	case *ast.StarExpr:
+		// 指针按指向的值输出
+		return e.value(t.X)
+	case *ast.ParenExpr:
+		return e.value(t.X)
+	case *ast.ArrayType:
+		return e.array(t)
+	case *ast.MapType:
+		obj := &jsonNode{kind: nodeObject}
+		if !e.recursive(t.Value) {
+			appendMember(obj, e.mapKey(t.Key), e.value(t.Value))
+		}
+		return obj
+	case *ast.StructType:
+		return e.object(t)
+	case *ast.SelectorExpr:
+		return e.external(t)
+	case *ast.IndexExpr:
+		// 泛型类型的实例按原类型展开，类型参数在 ident 中说明
+		return e.value(t.X)
+	case *ast.IndexListExpr:
+		return e.value(t.X)
+	case *ast.InterfaceType:
+		return newLiteralNode("null")
+	case *ast.ChanType, *ast.FuncType:
+		e.note(expr.Pos(), "encoding/json 不支持 chan 和 func 类型，示例中为 null")
+		return newLiteralNode("null")
+	}
+	e.note(expr.Pos(), "无法识别的类型，示例中为 null")
+	return newLiteralNode("null")
+}
+
+// ident 预声明的类型或同一段代码中声明的类型
+func (e *goExampler) ident(t *ast.Ident) *jsonNode {
+	switch t.Name {
+	case "bool":
+		return newLiteralNode("false")
+	case "string":
+		return newStringNode("string")
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+		return &jsonNode{kind: nodeNumber, raw: "0"}
+	case "float32", "float64":
+		return &jsonNode{kind: nodeNumber, raw: "0.5"}
+	case "any", "error":
+		return newLiteralNode("null")
+	case "complex64", "complex128":
+		e.note(t.Pos(), "encoding/json 不支持复数类型，示例中为 null")
+		return newLiteralNode("null")
+	}
+	if spec, ok := e.types[t.Name]; ok {
+		return e.named(spec, t.Pos())
+	}
+	e.note(t.Pos(), "类型 %s 没有在代码中声明，示例中为 null", t.Name)
+	return newLiteralNode("null")
+}
+
+// maxExampleArrayLen 数组示例最多输出的元素个数，更长的数组截断并在 Notes 中说明
+const maxExampleArrayLen = 3
+
+// array 切片输出一个元素，数组按长度输出（最多 maxExampleArrayLen 个），[]byte 按 base64 字符串输出
+func (e *goExampler) array(t *ast.ArrayType) *jsonNode {
+	if elem, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && (elem.Name == "byte" || elem.Name == "uint8") {
+		return newStringNode("c3RyaW5n")
+	}
+
+	arr := &jsonNode{kind: nodeArray}
+	if e.recursive(t.Elt) {
+		return arr
+	}
+	n := 1
+	if lit, ok := t.Len.(*ast.BasicLit); ok && lit.Kind == token.INT {
+		// 超出 int64 范围时 ParseInt 返回最大值，同样按截断处理
+		if v, err := strconv.ParseInt(lit.Value, 0, 64); err == nil || isRangeError(err) {
+			n = int(min(v, maxExampleArrayLen+1))
+		}
+	}
+	if n > maxExampleArrayLen {
+		e.note(t.Pos(), "数组长度为 %s，示例中只输出前 %d 个元素", t.Len.(*ast.BasicLit).Value, maxExampleArrayLen)
+		n = maxExampleArrayLen
+	}
+	for i := 0; i < n; i++ {
+		arr.elements = append(arr.elements, e.value(t.Elt))
+	}
+	return arr
+}
+
+// mapKey map 键的示例，整数键按 encoding/json 的规则写为数字字符串
+func (e *goExampler) mapKey(expr ast.Expr) string {
+	if t, ok := expr.(*ast.Ident); ok {
+		if spec, ok := e.types[t.Name]; ok && !e.expanding[t.Name] {
+			return e.mapKey(spec.Type)
+		}
+		if strings.HasPrefix(t.Name, "int") || strings.HasPrefix(t.Name, "uint") {
+			return "0"
+		}
+	}
+	return "key"
+}
+
+// external 其他包中的类型，常用的类型按其 JSON 写法输出
+func (e *goExampler) external(t *ast.SelectorExpr) *jsonNode {
+	pkg, ok := t.X.(*ast.Ident)
+	if !ok {
+		return e.value(t.X)
+	}
+	switch pkg.Name + "." + t.Sel.Name {
+	case "time.Time":
+		return newStringNode(goExampleTime)
+	case "time.Duration", "json.Number":
+		return &jsonNode{kind: nodeNumber, raw: "0"}
+	case "json.RawMessage":
+		return &jsonNode{kind: nodeObject}
+	case "uuid.UUID":
+		return newStringNode("00000000-0000-0000-0000-000000000000")
+	case "decimal.Decimal":
+		return newStringNode("0")
+	}
+	e.note(t.Pos(), "无法解析其他包中的类型 %s.%s，示例中为 null", pkg.Name, t.Sel.Name)
+	return newLiteralNode("null")
+}
+
+// exampleField 结构体中会被编码的字段
+type exampleField struct {
+	name   string
+	tagged bool // 名称来自 json 标签
+	depth  int  // 嵌入的层数，决定同名字段的优先级
+	pos    token.Pos
+	value  *jsonNode
+}
+
+// object 结构体的示例对象，同名字段按 encoding/json 的规则取层数最浅的，层数相同时取有标签的，否则都省略
+func (e *goExampler) object(t *ast.StructType) *jsonNode {
+	fields := e.fields(t, 0)
+	byName := make(map[string][]*exampleField, len(fields))
+	for _, f := range fields {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	obj := &jsonNode{kind: nodeObject}
+	for _, f := range fields {
+		dominant, ok := dominantField(byName[f.name])
+		if dominant != f {
+			if !ok && f == byName[f.name][0] {
+				e.note(f.pos, "嵌入的结构体中有多个同名的字段 %s，按 encoding/json 的规则省略", f.name)
+			}
+			continue
+		}
+		appendMember(obj, f.name, f.value)
+	}
+	return obj
+}
+
+// dominantField 同名字段中会被编码的字段，无法确定时返回 false
+func dominantField(fields []*exampleField) (*exampleField, bool) {
+	depth := fields[0].depth
+	for _, f := range fields[1:] {
+		depth = min(depth, f.depth)
+	}
+
+	var shallow []*exampleField
+	for _, f := range fields {
+		if f.depth == depth {
+			shallow = append(shallow, f)
+		}
+	}
+	if len(shallow) == 1 {
+		return shallow[0], true
+	}
+	var tagged []*exampleField
+	for _, f := range shallow {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return nil, false
+}
+
+// fields 收集结构体中会被编码的字段，没有标签名的嵌入结构体的字段提升到外层
+func (e *goExampler) fields(t *ast.StructType, depth int) []*exampleField {
+	var fields []*exampleField
+	for _, field := range t.Fields.List {
+		var tag string
+		if field.Tag != nil {
+			if unquoted, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(unquoted).Get("json")
+			}
+		}
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if e.omitEmpty && (hasTagOption(options, "omitempty") || hasTagOption(options, "omitzero")) {
+			continue
+		}
+
+		var names []string
+		for _, n := range field.Names {
+			if ast.IsExported(n.Name) {
+				names = append(names, n.Name)
+			}
+		}
+		if len(field.Names) == 0 {
+			typeName := embeddedName(field.Type)
+			if name == "" {
+				if st, ok := e.embeddedStruct(field.Type); ok {
+					e.expanding[typeName] = true
+					fields = append(fields, e.fields(st, depth+1)...)
+					delete(e.expanding, typeName)
+					continue
+				}
+			}
+			if ast.IsExported(typeName) {
+				names = append(names, typeName)
+			}
+		}
+
+		for _, n := range names {
+			f := &exampleField{name: n, depth: depth, pos: field.Pos(), value: e.value(field.Type)}
+			if name != "" {
+				f.name, f.tagged = name, true
+			}
+			if hasTagOption(options, "string") {
+				f.value = quotedValue(f.value)
+			}
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// embeddedStruct 嵌入的字段是否为同一段代码中声明的结构体或其指针，递归嵌入时不再展开
+func (e *goExampler) embeddedStruct(expr ast.Expr) (*ast.StructType, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok || e.expanding[ident.Name] {
+		return nil, false
+	}
+	spec, ok := e.types[ident.Name]
+	if !ok {
+		return nil, false
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	return st, ok
+}
+
+// embeddedName 嵌入字段的名称，即去掉指针、包名和类型参数后的类型名
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// hasTagOption 标签选项中是否含有 option
+func hasTagOption(options string, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// quotedValue string 选项把数字、布尔值和字符串再编码为JSON字符串，其他值不受影响
+func quotedValue(node *jsonNode) *jsonNode {
+	switch node.kind {
+	case nodeNumber, nodeString:
+		return newStringNode(node.raw)
+	case nodeLiteral:
+		if node.raw != "null" {
+			return newStringNode(node.raw)
+		}
+	}
+	return node
+}
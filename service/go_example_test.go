@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGoExample(t *testing.T) {
+	ctx := context.Background()
+
+	const order = "type Order struct {\n" +
+		"\tBase\n" +
+		"\tID      int64     `json:\"id,string\"`\n" +
+		"\tItems   []Item    `json:\"items,omitempty\"`\n" +
+		"\tCreated time.Time `json:\"created\"`\n" +
+		"\tSecret  string    `json:\"-\"`\n" +
+		"\tParent  *Order    `json:\"parent,omitempty\"`\n" +
+		"\tnote    string\n" +
+		"}\n\n" +
+		"type Base struct {\n" +
+		"\tCreatedBy string `json:\"created_by\"`\n" +
+		"}\n\n" +
+		"type Item struct {\n" +
+		"\tSku   Status\n" +
+		"\tPrice float64 `json:\"price\"`\n" +
+		"\tData  []byte  `json:\"data\"`\n" +
+		"}\n\n" +
+		"type Status string\n"
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    ExampleOptions
+		want    string
+		notes   []string // 每条说明中应包含的文字
+		wantErr string
+	}{
+		{
+			name:  "标签、嵌入的结构体和同一段代码中声明的类型",
+			input: order,
+			want: `{"created_by":"string","id":"0","items":[{"Sku":"string","price":0.5,"data":"c3RyaW5n"}],` +
+				`"created":"2006-01-02T15:04:05Z","parent":null}`,
+			notes: []string{"Order 递归引用自身"},
+		},
+		{
+			name:  "省略omitempty的字段并指定顶层类型",
+			input: "package model\n\n" + order,
+			opts:  ExampleOptions{TypeName: "Order", OmitEmpty: true},
+			want:  `{"created_by":"string","id":"0","created":"2006-01-02T15:04:05Z"}`,
+		},
+		{
+			name: "同名字段取层数浅的，层数相同时取有标签的",
+			input: "type A struct {\n\tB\n\tC\n\tName string\n}\n" +
+				"type B struct {\n\tName string\n\tX int `json:\"x\"`\n\tY int\n\tZ int\n}\n" +
+				"type C struct {\n\tX bool\n\tY bool `json:\"Y\"`\n\tZ bool\n}\n",
+			want:  `{"x":0,"X":false,"Y":false,"Name":"string"}`,
+			notes: []string{"多个同名的字段 Z"},
+		},
+		{
+			name:  "map、数组、递归的切片和无法解析的类型",
+			input: "type Tree struct {\n\tLabels map[int]string\n\tPair [2]bool\n\tChildren []Tree\n\tID uuid.UUID\n\tV sql.NullString\n\tAny any\n}",
+			want:  `{"Labels":{"0":"string"},"Pair":[false,false],"Children":[],"ID":"00000000-0000-0000-0000-000000000000","V":null,"Any":null}`,
+			notes: []string{"无法解析其他包中的类型 sql.NullString"},
+		},
+		{
+			name:  "很长的数组只输出前几个元素",
+			input: "type A struct {\n\tBig [2000000]int\n\tGrid [300][300][30]int\n\tHuge [99999999999999999999]bool\n}",
+			want:  `{"Big":[0,0,0],"Grid":[[[0,0,0],[0,0,0],[0,0,0]],[[0,0,0],[0,0,0],[0,0,0]],[[0,0,0],[0,0,0],[0,0,0]]],"Huge":[false,false,false]}`,
+			notes: []string{"数组长度为 2000000，示例中只输出前 3 个元素", "数组长度为 300", "数组长度为 300", "数组长度为 30，", "数组长度为 99999999999999999999"},
+		},
+		{
+			name:    "语法错误的位置",
+			input:   "type A struct {\n\tB int `json:\"b\"\n}",
+			wantErr: "第 2 行第 8 列",
+		},
+		{
+			name:    "没有找到指定的类型",
+			input:   "type A struct{}",
+			opts:    ExampleOptions{TypeName: "B"},
+			wantErr: "没有找到类型 B",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.GoExample(ctx, tt.input, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("GoExample() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GoExample() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("GoExample() =\n%s\nwant\n%s", result.Result, tt.want)
+			}
+			if len(result.Notes) != len(tt.notes) {
+				t.Fatalf("GoExample() notes = %v, want %d notes", result.Notes, len(tt.notes))
+			}
+			for i, note := range result.Notes {
+				if !strings.Contains(note.Message, tt.notes[i]) {
+					t.Errorf("note %d = %q, want containing %q", i, note.Message, tt.notes[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,638 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dialect JSON方言
+type Dialect string
+
+const (
+	// DialectJSON 严格的 RFC 8259 JSON
+	DialectJSON Dialect = "json"
+	// DialectJSONC 带注释的JSON（VS Code、tsconfig），允许注释和尾随逗号
+	DialectJSONC Dialect = "jsonc"
+	// DialectJSON5 JSON5，额外允许单引号字符串、未加引号的键、十六进制数字、NaN、Infinity 等
+	DialectJSON5 Dialect = "json5"
+)
+
+// check 校验方言名称，空字符串表示严格JSON
+func (d Dialect) check() error {
+	switch d {
+	case "", DialectJSON, DialectJSONC, DialectJSON5:
+		return nil
+	}
+	return fmt.Errorf("不支持的方言: %s", string(d))
+}
+
+// parseDialectTree 按方言把文本解析为语法树，JSONC 和 JSON5 会保留注释
+func parseDialectTree(text string, dialect Dialect) (*jsonNode, error) {
+	if dialect == "" || dialect == DialectJSON {
+		return parseJSONTree(text)
+	}
+
+	p := &json5Parser{jsonParser: jsonParser{data: text}, json5: dialect == DialectJSON5}
+	leading, err := p.comments()
+	if err != nil {
+		return nil, err
+	}
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	node.leading = leading
+	if node.trailing, err = p.sameLineComments(); err != nil {
+		return nil, err
+	}
+	if node.footer, err = p.comments(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.data) {
+		return nil, p.unexpected("文本结尾")
+	}
+	return node, nil
+}
+
+// json5Parser JSONC/JSON5 解析器，把非严格写法规范化为严格JSON，
+// 同时在 source 中保留原文，并把注释挂到相邻的节点上
+type json5Parser struct {
+	jsonParser
+	json5 bool // 是否允许 JSON5 语法，否则只允许注释和尾随逗号
+}
+
+// isJSON5Space JSON5 允许的额外空白字符
+func isJSON5Space(r rune) bool {
+	switch r {
+	case '\v', '\f', 0xA0, 0xFEFF, 0x2028, 0x2029:
+		return true
+	}
+	return unicode.Is(unicode.Zs, r)
+}
+
+// skipBlank 跳过空白，onlyInline 为 true 时不跨越换行
+func (p *json5Parser) skipBlank(onlyInline bool) {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '\n' || c == '\r':
+			if onlyInline {
+				return
+			}
+			p.pos++
+		case c >= utf8.RuneSelf && p.json5:
+			r, size := utf8.DecodeRuneInString(p.data[p.pos:])
+			if !isJSON5Space(r) || (onlyInline && (r == 0x2028 || r == 0x2029)) {
+				return
+			}
+			p.pos += size
+		case (c == '\v' || c == '\f') && p.json5:
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// readComment 读取当前位置的注释，不是注释时返回空字符串
+func (p *json5Parser) readComment() (string, error) {
+	if strings.HasPrefix(p.data[p.pos:], "//") {
+		end := strings.IndexAny(p.data[p.pos:], "\r\n")
+		if end < 0 {
+			end = len(p.data) - p.pos
+		}
+		comment := p.data[p.pos : p.pos+end]
+		p.pos += end
+		return comment, nil
+	}
+	if strings.HasPrefix(p.data[p.pos:], "/*") {
+		end := strings.Index(p.data[p.pos+2:], "*/")
+		if end < 0 {
+			return "", p.errorf("块注释未结束")
+		}
+		comment := p.data[p.pos : p.pos+end+4]
+		p.pos += end + 4
+		return comment, nil
+	}
+	return "", nil
+}
+
+// comments 跳过空白并收集其间的注释
+func (p *json5Parser) comments() ([]string, error) {
+	var result []string
+	for {
+		p.skipBlank(false)
+		comment, err := p.readComment()
+		if err != nil {
+			return nil, err
+		}
+		if comment == "" {
+			return result, nil
+		}
+		result = append(result, comment)
+	}
+}
+
+// sameLineComments 收集与上一个值位于同一行的注释
+func (p *json5Parser) sameLineComments() ([]string, error) {
+	var result []string
+	for {
+		p.skipBlank(true)
+		comment, err := p.readComment()
+		if err != nil {
+			return nil, err
+		}
+		if comment == "" {
+			return result, nil
+		}
+		result = append(result, comment)
+		if strings.HasPrefix(comment, "//") {
+			return result, nil
+		}
+	}
+}
+
+func (p *json5Parser) parseValue() (*jsonNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("JSON值")
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || (c == '\'' && p.json5):
+		return p.parseString()
+	case !p.json5:
+		return p.jsonParser.parseValue()
+	case c == '-' || c == '+' || c == '.' || isDigit(c) || c == 'I' || c == 'N':
+		return p.parseNumber()
+	case c == 't':
+		return p.parseLiteral("true")
+	case c == 'f':
+		return p.parseLiteral("false")
+	case c == 'n':
+		return p.parseLiteral("null")
+	default:
+		return nil, p.unexpected("JSON值")
+	}
+}
+
+func (p *json5Parser) parseObject() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	node := &jsonNode{kind: nodeObject, offset: p.pos}
+	p.pos++ // {
+
+	for {
+		leading, err := p.comments()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			node.dangling = leading
+			p.pos++
+			return node, nil
+		}
+
+		member, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		// 键与冒号、冒号与值之间的注释并入前置注释
+		more, err := p.comments()
+		if err != nil {
+			return nil, err
+		}
+		leading = append(leading, more...)
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.unexpected("':'")
+		}
+		p.pos++
+		if more, err = p.comments(); err != nil {
+			return nil, err
+		}
+		leading = append(leading, more...)
+
+		if member.value, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		member.value.leading = leading
+		node.members = append(node.members, member)
+
+		done, err := p.afterElement(node, member.value, '}')
+		if err != nil || done {
+			return node, err
+		}
+	}
+}
+
+func (p *json5Parser) parseArray() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	node := &jsonNode{kind: nodeArray, offset: p.pos}
+	p.pos++ // [
+
+	for {
+		leading, err := p.comments()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			node.dangling = leading
+			p.pos++
+			return node, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		value.leading = leading
+		node.elements = append(node.elements, value)
+
+		done, err := p.afterElement(node, value, ']')
+		if err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// afterElement 处理成员之后的注释和分隔符，返回容器是否已结束
+// 同一行的注释是该成员的行尾注释，最后一个成员之后另起一行的注释留在容器内
+func (p *json5Parser) afterElement(container *jsonNode, value *jsonNode, closer byte) (bool, error) {
+	trailing, err := p.sameLineComments()
+	if err != nil {
+		return false, err
+	}
+	more, err := p.comments()
+	if err != nil {
+		return false, err
+	}
+
+	if p.pos < len(p.data) && p.data[p.pos] == ',' {
+		p.pos++
+		// 逗号写在注释之后的下一行时，注释仍属于该成员
+		trailing = append(trailing, more...)
+		// 逗号之后的注释只有独占行尾时才属于该成员，否则是下一个成员的前置注释
+		start := p.pos
+		if more, err = p.sameLineComments(); err != nil {
+			return false, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+			p.pos, more = start, nil
+		}
+		value.trailing = append(trailing, more...)
+		return false, nil
+	}
+
+	value.trailing = trailing
+	if p.pos < len(p.data) && p.data[p.pos] == closer {
+		container.dangling = more
+		p.pos++
+		return true, nil
+	}
+	return false, p.unexpected(fmt.Sprintf("',' 或 '%c'", closer))
+}
+
+// parseKey 解析对象的键，JSON5 允许单引号和标识符
+func (p *json5Parser) parseKey() (*jsonMember, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("字符串类型的键")
+	}
+
+	c := p.data[p.pos]
+	if c == '"' || (c == '\'' && p.json5) {
+		node, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonMember{key: node.raw, keySource: node.source}, nil
+	}
+
+	if p.json5 {
+		start := p.pos
+		for p.pos < len(p.data) {
+			r, size := utf8.DecodeRuneInString(p.data[p.pos:])
+			if !isIdentRune(r, p.pos == start) {
+				break
+			}
+			p.pos += size
+		}
+		if p.pos > start {
+			name := p.data[start:p.pos]
+			return &jsonMember{key: encodeJSONString(name, false, false), keySource: name}, nil
+		}
+	}
+	return nil, p.unexpected("字符串类型的键")
+}
+
+// isIdentRune 是否可以出现在 ECMAScript 标识符中
+func isIdentRune(r rune, first bool) bool {
+	if r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) {
+		return true
+	}
+	return !first && (unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) || unicode.Is(unicode.Pc, r) || r == 0x200C || r == 0x200D)
+}
+
+// isIdentifier 文本是否是合法的 ECMAScript 标识符
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !isIdentRune(r, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseString 解析字符串，JSON5 的单引号字符串和扩展转义被规范化为严格JSON
+func (p *json5Parser) parseString() (*jsonNode, error) {
+	start := p.pos
+	if !p.json5 {
+		raw, err := p.jsonParser.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonNode{kind: nodeString, raw: raw, offset: start}, nil
+	}
+
+	quote := p.data[p.pos]
+	p.pos++
+	var sb strings.Builder
+	strict := quote == '"'
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			source := p.data[start:p.pos]
+			if strict {
+				return &jsonNode{kind: nodeString, raw: source, offset: start}, nil
+			}
+			return &jsonNode{kind: nodeString, raw: encodeJSONString(sb.String(), false, false), source: source, offset: start}, nil
+		case c == '\n' || c == '\r':
+			return nil, p.errorf("字符串中存在未转义的换行")
+		case c < 0x20:
+			strict = false
+			sb.WriteByte(c)
+			p.pos++
+		case c == '\\':
+			decoded, isStrict, err := p.readEscape()
+			if err != nil {
+				return nil, err
+			}
+			strict = strict && isStrict
+			sb.WriteString(decoded)
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	p.pos = start
+	return nil, p.errorf("字符串未结束")
+}
+
+// readEscape 解析 JSON5 转义序列，返回解码后的文本以及该转义在严格JSON中是否合法
+func (p *json5Parser) readEscape() (string, bool, error) {
+	if p.pos+1 >= len(p.data) {
+		return "", false, p.errorf("字符串未结束")
+	}
+
+	start := p.pos
+	c := p.data[p.pos+1]
+	switch c {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		decoded, _ := decodeJSONString(p.data[p.pos:p.pos+2], 0)
+		p.pos += 2
+		return decoded, true, nil
+	case 'u':
+		r, n, err := decodeUnicodeEscape(p.data, p.pos, 0)
+		if err != nil {
+			if _, ok := parseHex4(p.data, p.pos+2); !ok {
+				return "", false, p.errorf(`\u 后需要 4 位十六进制数字`)
+			}
+			// 孤立的代理项
+			p.pos += 6
+			return "�", true, nil
+		}
+		p.pos += n
+		return string(r), true, nil
+	case 'x':
+		if p.pos+4 > len(p.data) || !isHexDigit(p.data[p.pos+2]) || !isHexDigit(p.data[p.pos+3]) {
+			return "", false, p.errorf(`\x 后需要 2 位十六进制数字`)
+		}
+		r, _ := parseHex4("00"+p.data[p.pos+2:p.pos+4], 0)
+		p.pos += 4
+		return string(r), false, nil
+	case 'v':
+		p.pos += 2
+		return "\v", false, nil
+	case '0':
+		if p.pos+2 < len(p.data) && isDigit(p.data[p.pos+2]) {
+			return "", false, p.errorf(`\0 后不能紧跟数字`)
+		}
+		p.pos += 2
+		return "\x00", false, nil
+	case '\r':
+		// 续行：反斜杠后紧跟换行
+		p.pos += 2
+		if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+			p.pos++
+		}
+		return "", false, nil
+	case '\n':
+		p.pos += 2
+		return "", false, nil
+	}
+
+	if isDigit(c) {
+		return "", false, p.errorf("字符串中存在无效的转义字符 %q", p.data[start:start+2])
+	}
+	r, size := utf8.DecodeRuneInString(p.data[p.pos+1:])
+	p.pos += 1 + size
+	if r == 0x2028 || r == 0x2029 {
+		return "", false, nil
+	}
+	return string(r), false, nil
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// parseNumber 解析 JSON5 数字，规范化为严格JSON的写法
+func (p *json5Parser) parseNumber() (*jsonNode, error) {
+	start := p.pos
+	sign := ""
+	if c := p.data[p.pos]; c == '+' || c == '-' {
+		if c == '-' {
+			sign = "-"
+		}
+		p.pos++
+	}
+
+	node := &jsonNode{kind: nodeNumber, offset: start}
+	rest := p.data[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, "Infinity"):
+		p.pos += len("Infinity")
+		node.raw = sign + "Infinity"
+		node.nonFinite = true
+	case strings.HasPrefix(rest, "NaN"):
+		p.pos += len("NaN")
+		node.raw = "NaN"
+		node.nonFinite = true
+	case strings.HasPrefix(rest, "0x") || strings.HasPrefix(rest, "0X"):
+		p.pos += 2
+		digits := p.pos
+		for p.pos < len(p.data) && isHexDigit(p.data[p.pos]) {
+			p.pos++
+		}
+		if p.pos == digits {
+			return nil, p.unexpected("十六进制数字")
+		}
+		value, _ := new(big.Int).SetString(p.data[digits:p.pos], 16)
+		node.raw = sign + value.String()
+	default:
+		intStart := p.pos
+		p.skipDigits()
+		intPart := p.data[intStart:p.pos]
+		if len(intPart) > 1 && intPart[0] == '0' {
+			p.pos = intStart + 1
+			return nil, p.unexpected("',' 或结束括号")
+		}
+
+		fracPart, hasFrac := "", false
+		if p.pos < len(p.data) && p.data[p.pos] == '.' {
+			hasFrac = true
+			p.pos++
+			fracStart := p.pos
+			p.skipDigits()
+			fracPart = p.data[fracStart:p.pos]
+		}
+		if intPart == "" && fracPart == "" {
+			p.pos = intStart
+			return nil, p.unexpected("数字")
+		}
+
+		expPart := ""
+		if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+			expStart := p.pos
+			p.pos++
+			if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+				p.pos++
+			}
+			if !p.skipDigits() {
+				return nil, p.unexpected("指数部分的数字")
+			}
+			expPart = p.data[expStart:p.pos]
+		}
+
+		if intPart == "" {
+			intPart = "0"
+		}
+		node.raw = sign + intPart
+		if hasFrac {
+			if fracPart == "" {
+				fracPart = "0"
+			}
+			node.raw += "." + fracPart
+		}
+		node.raw += expPart
+	}
+
+	if source := p.data[start:p.pos]; source != node.raw {
+		node.source = source
+	}
+
+	// 数字之后不能紧跟标识符字符，如 123abc
+	if p.pos < len(p.data) {
+		if r, _ := utf8.DecodeRuneInString(p.data[p.pos:]); isIdentRune(r, false) {
+			return nil, p.unexpected("',' 或结束括号")
+		}
+	}
+	return node, nil
+}
+
+// checkStrict 检查语法树能否输出为严格JSON，text 为解析时的输入，用于定位错误
+func checkStrict(text string, node *jsonNode) error {
+	switch node.kind {
+	case nodeObject:
+		for _, m := range node.members {
+			if err := checkStrict(text, m.value); err != nil {
+				return err
+			}
+		}
+	case nodeArray:
+		for _, e := range node.elements {
+			if err := checkStrict(text, e); err != nil {
+				return err
+			}
+		}
+	case nodeNumber:
+		if node.nonFinite {
+			return &SyntaxError{Position: newPosition(text, node.offset), Message: fmt.Sprintf("严格JSON不支持 %s", node.raw)}
+		}
+	}
+	return nil
+}
+
+// unquoteKeys 转换为 JSON5 时去掉标识符键的引号
+func unquoteKeys(node *jsonNode) {
+	switch node.kind {
+	case nodeObject:
+		for _, m := range node.members {
+			if m.keySource == "" {
+				if name := m.keyValue(); isIdentifier(name) && encodeJSONString(name, false, false) == m.key {
+					m.keySource = name
+				}
+			}
+			unquoteKeys(m.value)
+		}
+	case nodeArray:
+		for _, e := range node.elements {
+			unquoteKeys(e)
+		}
+	}
+}
+
+// hasComments 节点及其子节点是否带有注释
+func hasComments(node *jsonNode) bool {
+	return len(node.leading) > 0 || len(node.trailing) > 0 || hasInnerComments(node)
+}
+
+// hasInnerComments 容器内部是否带有注释，不包括节点自身的前置和行尾注释
+func hasInnerComments(node *jsonNode) bool {
+	if len(node.dangling) > 0 {
+		return true
+	}
+	for _, m := range node.members {
+		if hasComments(m.value) {
+			return true
+		}
+	}
+	for _, e := range node.elements {
+		if hasComments(e) {
+			return true
+		}
+	}
+	return false
+}
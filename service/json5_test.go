@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFormatJSONC(t *testing.T) {
+	ctx := context.Background()
+	input := "// 编辑器设置\n{\n  // 字号\n  \"editor.fontSize\": 14, // 像素\n  \"files.exclude\": {\n    \"**/.git\": true,\n    /* 构建产物 */ \"dist\": true,\n  },\n  \"empty\": [\n    // 暂无\n  ],\n  \"last\": 1\n  // 结尾\n}\n// 文件结束\n"
+
+	tests := []struct {
+		name     string
+		opts     FormatOptions
+		expected string
+	}{
+		{
+			name:     "保留注释的位置",
+			opts:     FormatOptions{Indent: 2, Dialect: DialectJSONC},
+			expected: "// 编辑器设置\n{\n  // 字号\n  \"editor.fontSize\": 14, // 像素\n  \"files.exclude\": {\n    \"**/.git\": true,\n    /* 构建产物 */\n    \"dist\": true\n  },\n  \"empty\": [\n    // 暂无\n  ],\n  \"last\": 1\n  // 结尾\n}\n// 文件结束",
+		},
+		{
+			name:     "带注释的结构不按行宽折叠",
+			opts:     FormatOptions{Indent: 2, LineWidth: 200, Dialect: DialectJSONC},
+			expected: "// 编辑器设置\n{\n  // 字号\n  \"editor.fontSize\": 14, // 像素\n  \"files.exclude\": {\n    \"**/.git\": true,\n    /* 构建产物 */\n    \"dist\": true\n  },\n  \"empty\": [\n    // 暂无\n  ],\n  \"last\": 1\n  // 结尾\n}\n// 文件结束",
+		},
+		{
+			name:     "压缩时行注释改为块注释",
+			opts:     FormatOptions{Dialect: DialectJSONC},
+			expected: `/* 编辑器设置*/{/* 字号*/"editor.fontSize":14,/* 像素*/"files.exclude":{"**/.git":true,/* 构建产物 */"dist":true},"empty":[/* 暂无*/],"last":1/* 结尾*/}/* 文件结束*/`,
+		},
+		{
+			name:     "转换为严格JSON时去掉注释",
+			opts:     FormatOptions{Indent: 2, LineWidth: 80, Dialect: DialectJSONC, TargetDialect: DialectJSON},
+			expected: "{\n  \"editor.fontSize\": 14,\n  \"files.exclude\": { \"**/.git\": true, \"dist\": true },\n  \"empty\": [],\n  \"last\": 1\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.FormatJSONWithOptions(ctx, input, tt.opts)
+			if err != nil {
+				t.Fatalf("FormatJSONWithOptions() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("FormatJSONWithOptions() =\n%s\nwant:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatJSON5(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     FormatOptions
+		expected string
+	}{
+		{
+			name:     "JSON5转换为严格JSON",
+			input:    `{unquoted: 'it\'s', hex: 0xFF, half: .5, whole: 5., plus: +1, 'single': "a\x41\v", $id: -0x10,}`,
+			opts:     FormatOptions{Dialect: DialectJSON5, TargetDialect: DialectJSON},
+			expected: `{"unquoted":"it's","hex":255,"half":0.5,"whole":5.0,"plus":1,"single":"aA\u000b","$id":-16}`,
+		},
+		{
+			name:     "JSON5保留原始写法",
+			input:    "{unquoted: 'it\\'s', hex: 0xFF, 'quoted': .5, nan: NaN, inf: -Infinity, /* 注释 */ list: [1, 2,],}",
+			opts:     FormatOptions{Indent: 2, Dialect: DialectJSON5},
+			expected: "{\n  unquoted: 'it\\'s',\n  hex: 0xFF,\n  'quoted': .5,\n  nan: NaN,\n  inf: -Infinity,\n  /* 注释 */\n  list: [\n    1,\n    2\n  ]\n}",
+		},
+		{
+			name:     "严格JSON转换为JSON5",
+			input:    `{"name":"sojson","a-b":1,"nested":{"_ok":true,"1st":null}}`,
+			opts:     FormatOptions{Indent: 2, LineWidth: 40, TargetDialect: DialectJSON5},
+			expected: "{\n  name: \"sojson\",\n  \"a-b\": 1,\n  nested: { _ok: true, \"1st\": null }\n}",
+		},
+		{
+			name:     "JSONC允许尾随逗号",
+			input:    `[1, 2, /* 三 */ 3,]`,
+			opts:     FormatOptions{Dialect: DialectJSONC},
+			expected: `[1,2,/* 三 */3]`,
+		},
+		{
+			name:     "续行和空白字符",
+			input:    "\uFEFF{a: 'line\\\n continued'}",
+			opts:     FormatOptions{Dialect: DialectJSON5, TargetDialect: DialectJSON},
+			expected: `{"a":"line continued"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.FormatJSONWithOptions(ctx, tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("FormatJSONWithOptions() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("FormatJSONWithOptions() =\n%s\nwant:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatJSON5Invalid(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		input  string
+		opts   FormatOptions
+		offset int
+	}{
+		{name: "严格JSON不支持注释", input: `{"a": 1 /* x */}`, opts: FormatOptions{}, offset: 8},
+		{name: "JSONC不支持单引号", input: `{'a': 1}`, opts: FormatOptions{Dialect: DialectJSONC}, offset: 1},
+		{name: "块注释未结束", input: `{"a": 1 /* x }`, opts: FormatOptions{Dialect: DialectJSONC}, offset: 8},
+		{name: "NaN不能输出为严格JSON", input: `{a: NaN}`, opts: FormatOptions{Dialect: DialectJSON5, TargetDialect: DialectJSON}, offset: 4},
+		{name: "数字后紧跟标识符", input: `[12abc]`, opts: FormatOptions{Dialect: DialectJSON5}, offset: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := JSONProcessorService.FormatJSONWithOptions(ctx, tt.input, tt.opts)
+			syntaxErr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("FormatJSONWithOptions() error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Offset != tt.offset {
+				t.Errorf("FormatJSONWithOptions() offset = %d, want %d", syntaxErr.Offset, tt.offset)
+			}
+		})
+	}
+
+	if _, err := JSONProcessorService.FormatJSONWithOptions(ctx, `{}`, FormatOptions{Dialect: "yaml"}); err == nil {
+		t.Error("FormatJSONWithOptions() expected error for unknown dialect")
+	}
+}
+
+func TestProcessJSON5(t *testing.T) {
+	ctx := context.Background()
+
+	// JSON5 中合法的十六进制数和 Infinity 不按裸值加引号，其余裸值仍按默认规则处理
+	input := `{"id": 0xDEADBEEF, "inf": -Infinity, "sha": 1a2b3c4d5e}`
+	expected := `{"id":0xDEADBEEF,"inf":-Infinity,"sha":"1a2b3c4d5e"}`
+	result, err := JSONProcessorService.ProcessJSONWithOptions(ctx, input, ProcessOptions{FormatOptions: FormatOptions{Dialect: DialectJSON5}})
+	if err != nil {
+		t.Fatalf("ProcessJSONWithOptions() unexpected error = %v", err)
+	}
+	if result != expected {
+		t.Errorf("ProcessJSONWithOptions() =\n%s\nwant:\n%s", result, expected)
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxNestingDepth 最大嵌套深度，与 encoding/json 保持一致
+const maxNestingDepth = 10000
+
+// nodeKind JSON节点类型
+type nodeKind int
+
+const (
+	nodeObject nodeKind = iota
+	nodeArray
+	nodeString
+	nodeNumber
+	nodeLiteral // true、false、null
+)
+
+// jsonNode 保留原始顺序和字面量文本的JSON语法树节点
+type jsonNode struct {
+	kind      nodeKind
+	raw       string        // 标量的原始文本，字符串包含引号和原始转义
+	members   []*jsonMember // 对象成员，按出现顺序保存，允许重复的键
+	elements  []*jsonNode   // 数组元素
+	offset    int           // 节点在输入文本中的字节偏移
+	source    string        // JSON5 写法的原文，与 raw 相同时为空
+	nonFinite bool          // 是否为 NaN、Infinity，严格JSON无法表示
+	leading   []string      // 节点之前的注释
+	trailing  []string      // 节点之后同一行的注释
+	dangling  []string      // 空容器内或最后一个成员之后的注释
+	footer    []string      // 根节点之后的注释
+}
+
+// jsonMember 对象成员
+type jsonMember struct {
+	key       string // 键的原始文本，包含引号
+	keySource string // JSON5 写法的键原文，如未加引号的标识符，与 key 相同时为空
+	value     *jsonNode
+}
+
+// jsonParser 严格遵循 RFC 8259 的递归下降解析器
+type jsonParser struct {
+	data  string
+	pos   int
+	depth int
+	// offsetOnly 错误只记录字节偏移，不计算行列号；在长文本中反复尝试解析时避免每次都从头数行
+	offsetOnly bool
+}
+
+// parseJSONTree 把文本解析为语法树，要求整个输入恰好是一个JSON值
+func parseJSONTree(text string) (*jsonNode, error) {
+	p := &jsonParser{data: text}
+	p.skipSpace()
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.data) {
+		return nil, p.unexpected("文本结尾")
+	}
+	return node, nil
+}
+
+func (p *jsonParser) errorf(format string, args ...interface{}) *SyntaxError {
+	if p.offsetOnly {
+		return &SyntaxError{Position: Position{Offset: p.pos}, Message: fmt.Sprintf(format, args...)}
+	}
+	return &SyntaxError{Position: newPosition(p.data, p.pos), Message: fmt.Sprintf(format, args...)}
+}
+
+// unexpected 生成“期望 X，遇到 Y”形式的错误
+func (p *jsonParser) unexpected(expected string) *SyntaxError {
+	err := p.errorf("期望 %s，遇到 %s", expected, p.describe())
+	err.Expected = expected
+	return err
+}
+
+// describe 描述当前位置的字符，用于错误信息
+func (p *jsonParser) describe() string {
+	if p.pos >= len(p.data) {
+		return "文本结尾"
+	}
+	r, _ := utf8.DecodeRuneInString(p.data[p.pos:])
+	return fmt.Sprintf("%q", r)
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) parseValue() (*jsonNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("JSON值")
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"':
+		start := p.pos
+		raw, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonNode{kind: nodeString, raw: raw, offset: start}, nil
+	case c == '-' || ('0' <= c && c <= '9'):
+		return p.parseNumber()
+	case c == 't':
+		return p.parseLiteral("true")
+	case c == 'f':
+		return p.parseLiteral("false")
+	case c == 'n':
+		return p.parseLiteral("null")
+	default:
+		return nil, p.unexpected("JSON值")
+	}
+}
+
+func (p *jsonParser) enter() error {
+	p.depth++
+	if p.depth > maxNestingDepth {
+		return p.errorf("嵌套深度超过 %d", maxNestingDepth)
+	}
+	return nil
+}
+
+func (p *jsonParser) parseObject() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	node := &jsonNode{kind: nodeObject, offset: p.pos}
+	p.pos++ // {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		return node, nil
+	}
+
+	for {
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return nil, p.unexpected("字符串类型的键")
+		}
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.unexpected("':'")
+		}
+		p.pos++
+		p.skipSpace()
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.members = append(node.members, &jsonMember{key: key, value: value})
+
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, p.unexpected("',' 或 '}'")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+			p.skipSpace()
+		case '}':
+			p.pos++
+			return node, nil
+		default:
+			return nil, p.unexpected("',' 或 '}'")
+		}
+	}
+}
+
+func (p *jsonParser) parseArray() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	node := &jsonNode{kind: nodeArray, offset: p.pos}
+	p.pos++ // [
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		return node, nil
+	}
+
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, p.unexpected("',' 或 ']'")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+			p.skipSpace()
+		case ']':
+			p.pos++
+			return node, nil
+		default:
+			return nil, p.unexpected("',' 或 ']'")
+		}
+	}
+}
+
+// parseString 解析字符串并返回包含引号的原始文本
+func (p *jsonParser) parseString() (string, error) {
+	start := p.pos
+	p.pos++ // "
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return p.data[start:p.pos], nil
+		case c == '\\':
+			if p.pos+1 >= len(p.data) {
+				return "", p.errorf("字符串未结束")
+			}
+			switch p.data[p.pos+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				p.pos += 2
+			case 'u':
+				if _, ok := parseHex4(p.data, p.pos+2); !ok {
+					err := p.errorf(`\u 后需要 4 位十六进制数字`)
+					err.Expected = "4 位十六进制数字"
+					return "", err
+				}
+				p.pos += 6
+			default:
+				return "", p.errorf("字符串中存在无效的转义字符 %q", p.data[p.pos:p.pos+2])
+			}
+		case c < 0x20:
+			return "", p.errorf("字符串中存在未转义的控制字符 %q", c)
+		default:
+			p.pos++
+		}
+	}
+
+	p.pos = start
+	return "", p.errorf("字符串未结束")
+}
+
+func (p *jsonParser) parseNumber() (*jsonNode, error) {
+	start := p.pos
+	if p.data[p.pos] == '-' {
+		p.pos++
+	}
+
+	// 整数部分：0 或非 0 开头的数字串
+	switch {
+	case p.pos < len(p.data) && p.data[p.pos] == '0':
+		p.pos++
+	case p.pos < len(p.data) && '1' <= p.data[p.pos] && p.data[p.pos] <= '9':
+		p.skipDigits()
+	default:
+		return nil, p.unexpected("数字")
+	}
+
+	// 小数部分
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		p.pos++
+		if !p.skipDigits() {
+			return nil, p.unexpected("小数部分的数字")
+		}
+	}
+
+	// 指数部分
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if !p.skipDigits() {
+			return nil, p.unexpected("指数部分的数字")
+		}
+	}
+
+	return &jsonNode{kind: nodeNumber, raw: p.data[start:p.pos], offset: start}, nil
+}
+
+// skipDigits 跳过连续的数字，返回是否至少跳过一个
+func (p *jsonParser) skipDigits() bool {
+	start := p.pos
+	for p.pos < len(p.data) && '0' <= p.data[p.pos] && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *jsonParser) parseLiteral(literal string) (*jsonNode, error) {
+	if !strings.HasPrefix(p.data[p.pos:], literal) {
+		return nil, p.unexpected(literal)
+	}
+	node := &jsonNode{kind: nodeLiteral, raw: literal, offset: p.pos}
+	p.pos += len(literal)
+	return node, nil
+}
+
+// stringValue 返回字符串节点解码后的内容
+func (n *jsonNode) stringValue() string {
+	value, err := decodeJSONString(n.raw[1:len(n.raw)-1], n.offset+1)
+	if err != nil {
+		// 孤立的代理项按 encoding/json 的方式替换为 U+FFFD
+		_ = json.Unmarshal([]byte(n.raw), &value)
+	}
+	return value
+}
+
+// keyValue 返回成员键解码后的内容
+func (m *jsonMember) keyValue() string {
+	value, err := decodeJSONString(m.key[1:len(m.key)-1], 0)
+	if err != nil {
+		_ = json.Unmarshal([]byte(m.key), &value)
+	}
+	return value
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFormatJSONPreservesSource(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     FormatOptions
+		expected string
+	}{
+		{
+			name:     "保持键的原始顺序",
+			input:    `{"b":1,"a":2,"c":3}`,
+			opts:     FormatOptions{},
+			expected: `{"b":1,"a":2,"c":3}`,
+		},
+		{
+			name:     "64位整数不丢失精度",
+			input:    `{"id":9007199254740993123}`,
+			opts:     FormatOptions{},
+			expected: `{"id":9007199254740993123}`,
+		},
+		{
+			name:     "保持数字原始写法",
+			input:    `[1.0, 1e5, -0.50, 2E-3]`,
+			opts:     FormatOptions{},
+			expected: `[1.0,1e5,-0.50,2E-3]`,
+		},
+		{
+			name:     "保留重复的键",
+			input:    `{"a":1,"a":2}`,
+			opts:     FormatOptions{},
+			expected: `{"a":1,"a":2}`,
+		},
+		{
+			name:     "保持字符串原始转义",
+			input:    `{"s":"é\/"}`,
+			opts:     FormatOptions{},
+			expected: `{"s":"é\/"}`,
+		},
+		{
+			name:     "按需递归排序键",
+			input:    `{"b":{"y":1,"x":2},"a":[{"d":1,"c":2}]}`,
+			opts:     FormatOptions{KeyOrder: KeyOrderLexical},
+			expected: `{"a":[{"c":2,"d":1}],"b":{"x":2,"y":1}}`,
+		},
+		{
+			name:     "缩进输出",
+			input:    `{"a":[1,{}],"b":[]}`,
+			opts:     FormatOptions{Indent: 2},
+			expected: "{\n  \"a\": [\n    1,\n    {}\n  ],\n  \"b\": []\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.FormatJSONWithOptions(ctx, tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("FormatJSONWithOptions() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("FormatJSONWithOptions() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseJSONTreeInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+	}{
+		{name: "缺少值", input: `{"a":}`, offset: 5},
+		{name: "尾随逗号", input: `[1,]`, offset: 3},
+		{name: "前导零", input: `01`, offset: 1},
+		{name: "小数点后无数字", input: `1.`, offset: 2},
+		{name: "无效转义", input: `"\x"`, offset: 1},
+		{name: "未加引号的键", input: `{a:1}`, offset: 1},
+		{name: "多余内容", input: `{} {}`, offset: 3},
+		{name: "字符串未结束", input: `["abc`, offset: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSONTree(tt.input)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("parseJSONTree() error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Offset != tt.offset {
+				t.Errorf("parseJSONTree() offset = %d, want %d", syntaxErr.Offset, tt.offset)
+			}
+		})
+	}
+}
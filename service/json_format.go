@@ -0,0 +1,406 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// tabWidth 计算行宽时一个制表符占用的列数
+const tabWidth = 4
+
+// ColonSpacing 冒号后的空格策略
+type ColonSpacing int
+
+const (
+	// ColonSpaceAuto 缩进输出时加空格，压缩输出时不加
+	ColonSpaceAuto ColonSpacing = iota
+	// ColonSpaceAlways 总是加空格
+	ColonSpaceAlways
+	// ColonSpaceNever 从不加空格
+	ColonSpaceNever
+)
+
+// KeyOrder 对象键的排序方式
+type KeyOrder int
+
+const (
+	// KeyOrderOriginal 保持原始顺序
+	KeyOrderOriginal KeyOrder = iota
+	// KeyOrderLexical 按字典序排序
+	KeyOrderLexical
+	// KeyOrderNatural 按自然顺序排序，数字部分按数值比较（如 item2 排在 item10 之前）
+	KeyOrderNatural
+)
+
+// FormatOptions 格式化选项
+type FormatOptions struct {
+	Indent          int          // 缩进空格数，<=0 且不使用制表符时压缩输出
+	UseTabs         bool         // 使用制表符缩进
+	LineWidth       int          // 行宽，>0 时能放进一行的短数组和对象会折叠为单行
+	KeyOrder        KeyOrder     // 对象键的排序方式，递归生效
+	ColonSpace      ColonSpacing // 冒号后的空格策略
+	TrailingNewline bool         // 输出末尾追加换行
+	CRLF            bool         // 使用 CRLF 换行
+	Dialect         Dialect      // 输入的方言，为空时按严格JSON解析
+	TargetDialect   Dialect      // 输出的方言，为空时与输入相同
+}
+
+// compact 是否压缩输出
+func (o FormatOptions) compact() bool {
+	return !o.UseTabs && o.Indent <= 0
+}
+
+// target 输出的方言
+func (o FormatOptions) target() Dialect {
+	switch {
+	case o.TargetDialect != "":
+		return o.TargetDialect
+	case o.Dialect != "":
+		return o.Dialect
+	}
+	return DialectJSON
+}
+
+// jsonWriter 按格式化选项输出语法树
+type jsonWriter struct {
+	sb         strings.Builder
+	opts       FormatOptions
+	indent     string // 每层缩进的文本
+	indentCols int    // 每层缩进占用的列数
+	colon      string // 键值分隔符
+	comments   bool   // 是否输出注释，严格JSON不支持注释
+	json5      bool   // 是否按 JSON5 写法输出
+}
+
+func newJSONWriter(opts FormatOptions) *jsonWriter {
+	target := opts.target()
+	w := &jsonWriter{opts: opts, comments: target != DialectJSON, json5: target == DialectJSON5}
+
+	switch {
+	case opts.UseTabs:
+		w.indent, w.indentCols = "\t", tabWidth
+	case opts.Indent > 0:
+		w.indent, w.indentCols = strings.Repeat(" ", opts.Indent), opts.Indent
+	}
+
+	w.colon = ":"
+	if opts.ColonSpace == ColonSpaceAlways || (opts.ColonSpace == ColonSpaceAuto && !opts.compact()) {
+		w.colon = ": "
+	}
+	return w
+}
+
+// formatJSONTree 按选项输出语法树
+func formatJSONTree(tree *jsonNode, opts FormatOptions) string {
+	if opts.KeyOrder != KeyOrderOriginal {
+		sortMembers(tree, opts.KeyOrder)
+	}
+
+	// 严格JSON转换为 JSON5 时去掉标识符键的引号
+	if opts.target() == DialectJSON5 && opts.Dialect != DialectJSON5 {
+		unquoteKeys(tree)
+	}
+
+	w := newJSONWriter(opts)
+	w.writeLeading(tree, 0)
+	w.write(tree, 0, 0)
+	w.writeTrailing(tree)
+	if w.comments {
+		for _, c := range tree.footer {
+			w.commentBreak(0)
+			w.writeComment(c)
+		}
+	}
+	if opts.TrailingNewline {
+		w.sb.WriteByte('\n')
+	}
+
+	result := w.sb.String()
+	// 字符串中的换行一定是转义过的，注释中的换行也应一并替换
+	if opts.CRLF {
+		result = strings.ReplaceAll(result, "\n", "\r\n")
+	}
+	return result
+}
+
+// write 输出节点，prefix 为节点在当前行之前已占用的列数（缩进、键和尾随逗号）
+// 节点自身的前置和行尾注释由调用方输出
+func (w *jsonWriter) write(node *jsonNode, depth int, prefix int) {
+	if node.kind != nodeObject && node.kind != nodeArray {
+		w.sb.WriteString(w.scalar(node))
+		return
+	}
+	if w.opts.compact() {
+		w.writeFlat(node, "")
+		return
+	}
+	// 带注释的容器不折叠，否则行注释会吞掉后面的内容
+	if w.opts.LineWidth > 0 && !(w.comments && hasInnerComments(node)) {
+		budget := w.opts.LineWidth - prefix
+		if w.measure(node, budget) <= budget {
+			w.writeFlat(node, " ")
+			return
+		}
+	}
+
+	open, close, n := byte('['), byte(']'), len(node.elements)
+	if node.kind == nodeObject {
+		open, close, n = '{', '}', len(node.members)
+	}
+	if n == 0 && !(w.comments && len(node.dangling) > 0) {
+		w.sb.WriteByte(open)
+		w.sb.WriteByte(close)
+		return
+	}
+
+	w.sb.WriteByte(open)
+	for i := 0; i < n; i++ {
+		w.newline(depth + 1)
+		cols := (depth+1)*w.indentCols + w.trailing(i, n)
+		var value *jsonNode
+		if node.kind == nodeObject {
+			m := node.members[i]
+			value = m.value
+			w.writeLeading(value, depth+1)
+			key := w.key(m)
+			w.sb.WriteString(key)
+			w.sb.WriteString(w.colon)
+			cols += utf8.RuneCountInString(key) + len(w.colon)
+		} else {
+			value = node.elements[i]
+			w.writeLeading(value, depth+1)
+		}
+		w.write(value, depth+1, cols)
+		if i < n-1 {
+			w.sb.WriteByte(',')
+		}
+		w.writeTrailing(value)
+	}
+	w.writeDangling(node, depth+1)
+	w.newline(depth)
+	w.sb.WriteByte(close)
+}
+
+// trailing 返回成员后面逗号占用的列数
+func (w *jsonWriter) trailing(i int, n int) int {
+	if i < n-1 {
+		return 1
+	}
+	return 0
+}
+
+// writeFlat 在一行内输出节点，pad 为括号内侧和逗号后的空白
+// 只有压缩输出时才会遇到注释，此时行注释会改写为块注释
+func (w *jsonWriter) writeFlat(node *jsonNode, pad string) {
+	switch node.kind {
+	case nodeObject:
+		if len(node.members) == 0 && !(w.comments && len(node.dangling) > 0) {
+			w.sb.WriteString("{}")
+			return
+		}
+		w.sb.WriteByte('{')
+		w.sb.WriteString(pad)
+		for i, m := range node.members {
+			if i > 0 {
+				w.sb.WriteString(pad)
+			}
+			w.writeLeading(m.value, 0)
+			w.sb.WriteString(w.key(m))
+			w.sb.WriteString(w.colon)
+			w.writeFlat(m.value, pad)
+			if i < len(node.members)-1 {
+				w.sb.WriteByte(',')
+			}
+			w.writeTrailing(m.value)
+		}
+		w.writeDangling(node, 0)
+		w.sb.WriteString(pad)
+		w.sb.WriteByte('}')
+	case nodeArray:
+		w.sb.WriteByte('[')
+		for i, e := range node.elements {
+			if i > 0 {
+				w.sb.WriteString(pad)
+			}
+			w.writeLeading(e, 0)
+			w.writeFlat(e, pad)
+			if i < len(node.elements)-1 {
+				w.sb.WriteByte(',')
+			}
+			w.writeTrailing(e)
+		}
+		w.writeDangling(node, 0)
+		w.sb.WriteByte(']')
+	default:
+		w.sb.WriteString(w.scalar(node))
+	}
+}
+
+// measure 计算节点单行输出的宽度，超过 budget 时提前返回一个大于 budget 的值
+func (w *jsonWriter) measure(node *jsonNode, budget int) int {
+	switch node.kind {
+	case nodeObject:
+		if len(node.members) == 0 {
+			return 2
+		}
+		// "{ " 与 " }" 以及成员间的 ", "
+		width := 4 + 2*(len(node.members)-1)
+		for _, m := range node.members {
+			width += utf8.RuneCountInString(w.key(m)) + len(w.colon)
+			if width > budget {
+				return width
+			}
+			width += w.measure(m.value, budget-width)
+			if width > budget {
+				return width
+			}
+		}
+		return width
+	case nodeArray:
+		width := 2
+		if len(node.elements) > 1 {
+			width += 2 * (len(node.elements) - 1)
+		}
+		for _, e := range node.elements {
+			width += w.measure(e, budget-width)
+			if width > budget {
+				return width
+			}
+		}
+		return width
+	default:
+		return utf8.RuneCountInString(w.scalar(node))
+	}
+}
+
+// key 返回成员键的输出文本
+func (w *jsonWriter) key(m *jsonMember) string {
+	if w.json5 && m.keySource != "" {
+		return m.keySource
+	}
+	return m.key
+}
+
+// scalar 返回标量的输出文本
+func (w *jsonWriter) scalar(node *jsonNode) string {
+	if w.json5 && node.source != "" {
+		return node.source
+	}
+	return node.raw
+}
+
+// writeLeading 输出节点的前置注释，每条注释独占一行
+func (w *jsonWriter) writeLeading(node *jsonNode, depth int) {
+	if !w.comments {
+		return
+	}
+	for _, c := range node.leading {
+		w.writeComment(c)
+		w.commentBreak(depth)
+	}
+}
+
+// writeTrailing 输出节点的行尾注释
+func (w *jsonWriter) writeTrailing(node *jsonNode) {
+	if !w.comments {
+		return
+	}
+	for _, c := range node.trailing {
+		if !w.opts.compact() {
+			w.sb.WriteByte(' ')
+		}
+		w.writeComment(c)
+	}
+}
+
+// writeDangling 输出容器内最后一个成员之后的注释
+func (w *jsonWriter) writeDangling(node *jsonNode, depth int) {
+	if !w.comments {
+		return
+	}
+	for _, c := range node.dangling {
+		w.commentBreak(depth)
+		w.writeComment(c)
+	}
+}
+
+// writeComment 输出一条注释，压缩输出时行注释改写为块注释
+func (w *jsonWriter) writeComment(c string) {
+	if w.opts.compact() && strings.HasPrefix(c, "//") {
+		c = "/*" + strings.ReplaceAll(c[2:], "*/", "* /") + "*/"
+	}
+	w.sb.WriteString(c)
+}
+
+// commentBreak 注释之间的换行，压缩输出时不换行
+func (w *jsonWriter) commentBreak(depth int) {
+	if !w.opts.compact() {
+		w.newline(depth)
+	}
+}
+
+// newline 换行并缩进到指定层级
+func (w *jsonWriter) newline(depth int) {
+	w.sb.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		w.sb.WriteString(w.indent)
+	}
+}
+
+// sortMembers 递归地按键排序对象成员，键相同时保持原有顺序
+func sortMembers(node *jsonNode, order KeyOrder) {
+	switch node.kind {
+	case nodeObject:
+		less := func(a, b string) bool { return a < b }
+		if order == KeyOrderNatural {
+			less = naturalLess
+		}
+		sort.SliceStable(node.members, func(i, j int) bool {
+			return less(node.members[i].keyValue(), node.members[j].keyValue())
+		})
+		for _, m := range node.members {
+			sortMembers(m.value, order)
+		}
+	case nodeArray:
+		for _, e := range node.elements {
+			sortMembers(e, order)
+		}
+	}
+}
+
+// naturalLess 自然顺序比较，连续的数字按数值大小比较
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// 取出两边完整的数字串，去掉前导零后先比长度再比字典序
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na := strings.TrimLeft(a[si:i], "0")
+			nb := strings.TrimLeft(b[sj:j], "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+	return len(a)-i < len(b)-j
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
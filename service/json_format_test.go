@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFormatJSONStyleOptions(t *testing.T) {
+	ctx := context.Background()
+	input := `{"name":"sojson","tags":["a","b"],"nested":{"x":1,"y":[1,2,3]},"empty":{}}`
+
+	tests := []struct {
+		name     string
+		opts     FormatOptions
+		expected string
+	}{
+		{
+			name:     "制表符缩进",
+			opts:     FormatOptions{UseTabs: true},
+			expected: "{\n\t\"name\": \"sojson\",\n\t\"tags\": [\n\t\t\"a\",\n\t\t\"b\"\n\t],\n\t\"nested\": {\n\t\t\"x\": 1,\n\t\t\"y\": [\n\t\t\t1,\n\t\t\t2,\n\t\t\t3\n\t\t]\n\t},\n\t\"empty\": {}\n}",
+		},
+		{
+			name:     "按行宽折叠短结构",
+			opts:     FormatOptions{Indent: 2, LineWidth: 40},
+			expected: "{\n  \"name\": \"sojson\",\n  \"tags\": [\"a\", \"b\"],\n  \"nested\": { \"x\": 1, \"y\": [1, 2, 3] },\n  \"empty\": {}\n}",
+		},
+		{
+			name:     "行宽不足时展开",
+			opts:     FormatOptions{Indent: 2, LineWidth: 30},
+			expected: "{\n  \"name\": \"sojson\",\n  \"tags\": [\"a\", \"b\"],\n  \"nested\": {\n    \"x\": 1,\n    \"y\": [1, 2, 3]\n  },\n  \"empty\": {}\n}",
+		},
+		{
+			name:     "整体放得下时输出单行",
+			opts:     FormatOptions{Indent: 2, LineWidth: 200},
+			expected: `{ "name": "sojson", "tags": ["a", "b"], "nested": { "x": 1, "y": [1, 2, 3] }, "empty": {} }`,
+		},
+		{
+			name:     "压缩输出且冒号后加空格",
+			opts:     FormatOptions{ColonSpace: ColonSpaceAlways},
+			expected: `{"name": "sojson","tags": ["a","b"],"nested": {"x": 1,"y": [1,2,3]},"empty": {}}`,
+		},
+		{
+			name:     "缩进输出且冒号后不加空格",
+			opts:     FormatOptions{Indent: 1, ColonSpace: ColonSpaceNever, LineWidth: 100},
+			expected: `{ "name":"sojson", "tags":["a", "b"], "nested":{ "x":1, "y":[1, 2, 3] }, "empty":{} }`,
+		},
+		{
+			name:     "CRLF换行和末尾换行",
+			opts:     FormatOptions{Indent: 2, LineWidth: 40, CRLF: true, TrailingNewline: true},
+			expected: "{\r\n  \"name\": \"sojson\",\r\n  \"tags\": [\"a\", \"b\"],\r\n  \"nested\": { \"x\": 1, \"y\": [1, 2, 3] },\r\n  \"empty\": {}\r\n}\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.FormatJSONWithOptions(ctx, input, tt.opts)
+			if err != nil {
+				t.Fatalf("FormatJSONWithOptions() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("FormatJSONWithOptions() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatJSONNaturalKeyOrder(t *testing.T) {
+	input := `{"item10":1,"item2":2,"Item1":3,"item02":4,"a":{"k10":1,"k9":2}}`
+
+	lexical, err := JSONProcessorService.FormatJSONWithOptions(context.Background(), input, FormatOptions{KeyOrder: KeyOrderLexical})
+	if err != nil {
+		t.Fatalf("FormatJSONWithOptions() failed: %v", err)
+	}
+	if expected := `{"Item1":3,"a":{"k10":1,"k9":2},"item02":4,"item10":1,"item2":2}`; lexical != expected {
+		t.Errorf("lexical order = %s, want %s", lexical, expected)
+	}
+
+	natural, err := JSONProcessorService.FormatJSONWithOptions(context.Background(), input, FormatOptions{KeyOrder: KeyOrderNatural})
+	if err != nil {
+		t.Fatalf("FormatJSONWithOptions() failed: %v", err)
+	}
+	if expected := `{"Item1":3,"a":{"k9":2,"k10":1},"item2":2,"item02":4,"item10":1}`; natural != expected {
+		t.Errorf("natural order = %s, want %s", natural, expected)
+	}
+}
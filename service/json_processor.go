@@ -1,10 +1,12 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"regexp"
+	"fmt"
 	"strings"
+	"unicode"
 
 	"sojson/zlog"
 )
@@ -17,111 +19,186 @@ var (
 type jsonProcessorService struct{}
 
 // UnescapeJSON 去除JSON转义
+// 按 JSON 字符串字面量规则单遍解码，遇到非法转义时返回 *UnescapeError
 func (s *jsonProcessorService) UnescapeJSON(text string) (string, error) {
-	text = strings.TrimSpace(text)
+	result, _, err := unescapeJSON(text)
+	return result, err
+}
+
+// unescapeJSON 去除JSON转义，同时返回结果中的偏移到输入中偏移的映射
+func unescapeJSON(text string) (string, offsetMap, error) {
+	original := text
+
+	// 记录去除首部空白后的偏移，保证错误位置对应原始输入
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	base := len(text) - len(trimmed)
+	text = strings.TrimRightFunc(trimmed, unicode.IsSpace)
 
 	// 去除外层引号
 	if strings.HasPrefix(text, `"`) && strings.HasSuffix(text, `"`) && len(text) > 1 {
 		text = text[1 : len(text)-1]
+		base++
+	}
+
+	result, err := decodeJSONString(text, base)
+	if err != nil {
+		if unescapeErr, ok := err.(*UnescapeError); ok {
+			unescapeErr.Position = newPosition(original, unescapeErr.Offset)
+		}
+		return "", nil, err
+	}
+	return result, escapeOffsets(text, base), nil
+}
+
+// maxEscapeLevel 最多转义的层数
+const maxEscapeLevel = 10
+
+// EscapeOptions 转义选项
+type EscapeOptions struct {
+	Level     int  // 转义次数，<=0 时按 1 处理
+	ASCIIOnly bool // 非 ASCII 字符输出为 \uXXXX
+	HTMLSafe  bool // <、>、& 输出为 \uXXXX
+	Minify    bool // 转义前先压缩JSON
+}
+
+// EscapeJSON 添加JSON转义，把文本编码为JSON字符串字面量，是 UnescapeJSON 的逆操作
+func (s *jsonProcessorService) EscapeJSON(ctx context.Context, text string, opts EscapeOptions) (string, error) {
+	level := opts.Level
+	if level <= 0 {
+		level = 1
+	}
+	if level > maxEscapeLevel {
+		return "", fmt.Errorf("转义次数不能超过 %d", maxEscapeLevel)
 	}
 
-	// 只有在去除外层引号后才进行转义字符的处理
-	text = strings.ReplaceAll(text, `\"`, `"`)
-	text = strings.ReplaceAll(text, `\/`, `/`)
-	text = strings.ReplaceAll(text, `\\`, `\`)
-	text = strings.ReplaceAll(text, `\n`, "\n")
-	text = strings.ReplaceAll(text, `\r`, "\r")
-	text = strings.ReplaceAll(text, `\t`, "\t")
-	text = strings.ReplaceAll(text, `\b`, "\b")
-	text = strings.ReplaceAll(text, `\f`, "\f")
+	if opts.Minify {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(strings.TrimSpace(text))); err != nil {
+			zlog.Errorf(ctx, "EscapeJSON: json.Compact failed, input text length: %d, error: %v", len(text), err)
+			return "", err
+		}
+		text = buf.String()
+	}
 
+	for i := 0; i < level; i++ {
+		text = encodeJSONString(text, opts.ASCIIOnly, opts.HTMLSafe)
+	}
+
+	zlog.Infof(ctx, "EscapeJSON: successfully escaped text, output length: %d, level: %d", len(text), level)
 	return text, nil
 }
 
 // FormatJSON 格式化JSON
 func (s *jsonProcessorService) FormatJSON(ctx context.Context, text string, indent int) (string, error) {
-	var jsonObj interface{}
+	return s.FormatJSONWithOptions(ctx, text, FormatOptions{Indent: indent})
+}
 
-	// 解析JSON
-	if err := json.Unmarshal([]byte(text), &jsonObj); err != nil {
-		zlog.Errorf(ctx, "FormatJSON: json.Unmarshal failed, input text length: %d, error: %v", len(text), err)
+// FormatJSONWithOptions 按选项格式化JSON
+// 基于语法树输出，保留键的原始顺序、重复的键以及数字和字符串的原始写法；
+// 输入为 JSONC 或 JSON5 时保留注释，并可通过 TargetDialect 转换为其他方言
+func (s *jsonProcessorService) FormatJSONWithOptions(ctx context.Context, text string, opts FormatOptions) (string, error) {
+	if err := opts.Dialect.check(); err != nil {
+		return "", err
+	}
+	if err := opts.TargetDialect.check(); err != nil {
 		return "", err
 	}
 
-	// 格式化输出
-	var result []byte
-	var err error
+	// 解析JSON
+	tree, err := parseDialectTree(text, opts.Dialect)
+	if err != nil {
+		zlog.Errorf(ctx, "FormatJSON: parseDialectTree failed, input text length: %d, dialect: %s, error: %v", len(text), opts.Dialect, err)
+		return "", err
+	}
 
-	if indent <= 0 {
-		result, err = json.Marshal(jsonObj)
-		if err != nil {
-			zlog.Errorf(ctx, "FormatJSON: json.Marshal failed, input text length: %d, error: %v", len(text), err)
-			return "", err
-		}
-	} else {
-		indentStr := strings.Repeat(" ", indent)
-		result, err = json.MarshalIndent(jsonObj, "", indentStr)
-		if err != nil {
-			zlog.Errorf(ctx, "FormatJSON: json.MarshalIndent failed, input text length: %d, indent: %d, error: %v", len(text), indent, err)
+	// NaN 和 Infinity 只能输出为 JSON5
+	if opts.target() != DialectJSON5 {
+		if err := checkStrict(text, tree); err != nil {
+			zlog.Errorf(ctx, "FormatJSON: checkStrict failed, target dialect: %s, error: %v", opts.target(), err)
 			return "", err
 		}
 	}
 
-	zlog.Infof(ctx, "FormatJSON: successfully formatted JSON, input length: %d, output length: %d, indent: %d", len(text), len(result), indent)
-	return string(result), nil
+	result := formatJSONTree(tree, opts)
+	zlog.Infof(ctx, "FormatJSON: successfully formatted JSON, input length: %d, output length: %d, indent: %d", len(text), len(result), opts.Indent)
+	return result, nil
+}
+
+// ProcessOptions 完整处理选项
+type ProcessOptions struct {
+	FormatOptions
+	QuoteRules []QuoteRule // 裸值加引号规则，为 nil 时使用默认规则
+	Mongo      MongoMode   // 按 mongo shell 语法解析并转换为 Extended JSON 或展平，为空时按JSON格式化
 }
 
 // ProcessJSON 完整处理：先去除转义，再格式化
 func (s *jsonProcessorService) ProcessJSON(ctx context.Context, text string, indent int) (string, error) {
-	// 先修复未引号包裹的时间字段
-	fixed := s.FixUnquotedTimeFields(text)
-	zlog.Debugf(ctx, "ProcessJSON: FixUnquotedTimeFields, original length: %d, fixed length: %d", len(text), len(fixed))
+	return s.ProcessJSONWithOptions(ctx, text, ProcessOptions{FormatOptions: FormatOptions{Indent: indent}})
+}
+
+// ProcessJSONWithOptions 按选项完整处理：先去除转义，再格式化；错误的位置对应原始输入
+func (s *jsonProcessorService) ProcessJSONWithOptions(ctx context.Context, text string, opts ProcessOptions) (string, error) {
+	// 先为未加引号的裸值加引号，方言中合法的写法保持不变
+	fixed, quoted, err := s.quoteValues(text, opts.QuoteRules, opts.Dialect)
+	if err != nil {
+		zlog.Errorf(ctx, "ProcessJSON: QuoteBareValues failed, rules: %d, error: %v", len(opts.QuoteRules), err)
+		return "", err
+	}
+	zlog.Debugf(ctx, "ProcessJSON: QuoteBareValues, original length: %d, fixed length: %d", len(text), len(fixed))
+
+	// mongo shell 输出中的正则字面量含有反斜杠，只有整体是字符串字面量时才去除转义
+	if opts.Mongo != MongoNone {
+		var escaped offsetMap
+		if trimmed := strings.TrimSpace(fixed); strings.HasPrefix(trimmed, `"`) {
+			if fixed, escaped, err = unescapeJSON(fixed); err != nil {
+				zlog.Errorf(ctx, "ProcessJSON: UnescapeJSON failed, input text length: %d, error: %v", len(text), err)
+				return "", relocate(err, text, quoted)
+			}
+		}
+		converted, err := s.convertMongo(ctx, fixed, opts.Mongo, opts.FormatOptions)
+		if err != nil {
+			zlog.Errorf(ctx, "ProcessJSON: convertMongo failed, input text length: %d, mode: %s, error: %v", len(fixed), opts.Mongo, err)
+			return "", relocate(err, text, quoted, escaped)
+		}
+		return converted, nil
+	}
 
 	// 去除转义
-	unescaped, err := s.UnescapeJSON(fixed)
+	unescaped, escaped, err := unescapeJSON(fixed)
 	if err != nil {
 		zlog.Errorf(ctx, "ProcessJSON: UnescapeJSON failed, input text length: %d, error: %v", len(fixed), err)
-		return "", err
+		return "", relocate(err, text, quoted)
 	}
 
 	// 格式化
-	formatted, err := s.FormatJSON(ctx, unescaped, indent)
+	formatted, err := s.FormatJSONWithOptions(ctx, unescaped, opts.FormatOptions)
 	if err != nil {
-		zlog.Errorf(ctx, "ProcessJSON: FormatJSON failed, unescaped text length: %d, indent: %d, error: %v", len(unescaped), indent, err)
-		return "", err
+		zlog.Errorf(ctx, "ProcessJSON: FormatJSON failed, unescaped text length: %d, indent: %d, error: %v", len(unescaped), opts.Indent, err)
+		return "", relocate(err, text, quoted, escaped)
 	}
 
-	zlog.Infof(ctx, "ProcessJSON: successfully processed JSON, input length: %d, output length: %d, indent: %d", len(text), len(formatted), indent)
+	zlog.Infof(ctx, "ProcessJSON: successfully processed JSON, input length: %d, output length: %d, indent: %d", len(text), len(formatted), opts.Indent)
 	return formatted, nil
 }
 
-// FixUnquotedTimeFields 修复未被引号包裹的时间字段
-func (s *jsonProcessorService) FixUnquotedTimeFields(text string) string {
-	// 修复常见的时间字段格式问题
-	// 匹配模式: "time":2025-08-18T08:04:19.827Z (没有引号的时间值)
-	// 替换为: "time":"2025-08-18T08:04:19.827Z" (加上引号)
-
-	// 时间格式的正则表达式模式
-	// 匹配: "field_name":YYYY-MM-DDTHH:MM:SS.sssZ后面跟着逗号或右大括号
-	timePattern := `("(?:time|timestamp|created_at|updated_at|date)"\s*:\s*)(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d{3})?Z?)(\s*[,}])`
-
-	// 使用正则表达式替换，保留逗号或右大括号
-	re := regexp.MustCompile(timePattern)
-	result := re.ReplaceAllString(text, `${1}"${2}"${3}`)
-
-	return result
-}
-
 // ProcessJSONWithTimeFix 专门用于处理包含未引号时间字段的JSON
 func (s *jsonProcessorService) ProcessJSONWithTimeFix(ctx context.Context, text string, indent int) (string, error) {
 	zlog.Infof(ctx, "ProcessJSONWithTimeFix: processing JSON with time field fix, input length: %d", len(text))
 
-	// 先修复时间字段，然后正常处理
+	// 默认规则包含时间字段，直接正常处理
 	return s.ProcessJSON(ctx, text, indent)
 }
 
-// ValidateJSON 验证JSON格式
+// ValidateJSON 验证JSON格式，语法错误时返回 *SyntaxError
 func (s *jsonProcessorService) ValidateJSON(text string) error {
-	var jsonObj interface{}
-	return json.Unmarshal([]byte(text), &jsonObj)
+	return s.ValidateJSONWithDialect(text, DialectJSON)
+}
+
+// ValidateJSONWithDialect 按方言验证格式，语法错误时返回 *SyntaxError
+func (s *jsonProcessorService) ValidateJSONWithDialect(text string, dialect Dialect) error {
+	if err := dialect.check(); err != nil {
+		return err
+	}
+	_, err := parseDialectTree(text, dialect)
+	return err
 }
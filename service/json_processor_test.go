@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"sojson/zlog"
@@ -12,54 +13,6 @@ func init() {
 	zlog.InitLogger("debug", "")
 }
 
-func TestFixUnquotedTimeFields(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "单个time字段",
-			input:    `{"level":30,"time":2025-08-18T08:04:19.827Z}`,
-			expected: `{"level":30,"time":"2025-08-18T08:04:19.827Z"}`,
-		},
-		{
-			name:     "time字段在中间",
-			input:    `{"level":30,"time":2025-08-18T08:04:19.827Z,"msg":"test"}`,
-			expected: `{"level":30,"time":"2025-08-18T08:04:19.827Z","msg":"test"}`,
-		},
-		{
-			name:     "多个时间字段",
-			input:    `{"created_at":2025-08-18T08:04:19.827Z,"updated_at":2025-08-18T09:04:19.827Z}`,
-			expected: `{"created_at":"2025-08-18T08:04:19.827Z","updated_at":"2025-08-18T09:04:19.827Z"}`,
-		},
-		{
-			name:     "timestamp字段",
-			input:    `{"level":30,"timestamp":2025-08-18T08:04:19Z,"msg":"test"}`,
-			expected: `{"level":30,"timestamp":"2025-08-18T08:04:19Z","msg":"test"}`,
-		},
-		{
-			name:     "已经有引号的时间字段（不应该改变）",
-			input:    `{"level":30,"time":"2025-08-18T08:04:19.827Z","msg":"test"}`,
-			expected: `{"level":30,"time":"2025-08-18T08:04:19.827Z","msg":"test"}`,
-		},
-		{
-			name:     "没有时间字段（不应该改变）",
-			input:    `{"level":30,"msg":"test message"}`,
-			expected: `{"level":30,"msg":"test message"}`,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := JSONProcessorService.FixUnquotedTimeFields(tt.input)
-			if result != tt.expected {
-				t.Errorf("FixUnquotedTimeFields() = %v, want %v", result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestProcessJSONWithUnquotedTime(t *testing.T) {
 	ctx := context.Background()
 
@@ -137,3 +90,114 @@ func TestProcessJSONWithTimeFix(t *testing.T) {
 	t.Logf("原始JSON: %s", problemJSON)
 	t.Logf("处理结果: %s", result)
 }
+
+func TestProcessJSONErrorPosition(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		input  string
+		deep   bool
+		offset int // 错误在原始输入中的偏移
+		column int
+	}{
+		{name: "加引号的裸值之后", input: `{"t": 2025-08-18, "a": }`, offset: 23, column: 24},
+		{name: "去除转义之后", input: `"{\"a\":}"`, offset: 8, column: 9},
+		{name: "深度展开之后", input: ` "{\"😀\":}"`, deep: true, offset: 12, column: 11},
+		{name: "转义错误", input: `"{\"a\":\x}"`, offset: 8, column: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.deep {
+				_, err = JSONProcessorService.DeepExpandJSON(ctx, tt.input, ProcessOptions{})
+			} else {
+				_, err = JSONProcessorService.ProcessJSONWithOptions(ctx, tt.input, ProcessOptions{})
+			}
+			var posErr PositionError
+			if !errors.As(err, &posErr) {
+				t.Fatalf("error = %v, want PositionError", err)
+			}
+			if pos := posErr.Pos(); pos.Offset != tt.offset || pos.Column != tt.column {
+				t.Errorf("error position = %+v, want offset %d column %d", pos, tt.offset, tt.column)
+			}
+		})
+	}
+}
+
+func TestEscapeJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     EscapeOptions
+		expected string
+	}{
+		{
+			name:     "默认转义一次",
+			input:    `{"a":"x\ny"}`,
+			opts:     EscapeOptions{},
+			expected: `"{\"a\":\"x\\ny\"}"`,
+		},
+		{
+			name:     "转义两次",
+			input:    `{"a":1}`,
+			opts:     EscapeOptions{Level: 2},
+			expected: `"\"{\\\"a\\\":1}\""`,
+		},
+		{
+			name:     "先压缩",
+			input:    "{\n  \"a\": [1, 2]\n}",
+			opts:     EscapeOptions{Minify: true},
+			expected: `"{\"a\":[1,2]}"`,
+		},
+		{
+			name:     "仅ASCII",
+			input:    `{"name":"你好😀"}`,
+			opts:     EscapeOptions{ASCIIOnly: true},
+			expected: `"{\"name\":\"\u4f60\u597d\ud83d\ude00\"}"`,
+		},
+		{
+			name:     "HTML安全",
+			input:    `<a href="x">&</a>`,
+			opts:     EscapeOptions{HTMLSafe: true},
+			expected: `"\u003ca href=\"x\"\u003e\u0026\u003c/a\u003e"`,
+		},
+		{
+			name:     "控制字符",
+			input:    "a\x01b",
+			opts:     EscapeOptions{},
+			expected: `"a\u0001b"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.EscapeJSON(ctx, tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("EscapeJSON() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("EscapeJSON() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEscapeUnescapeRoundTrip(t *testing.T) {
+	input := "{\"msg\":\"C:\\\\path\\n\\\"quoted\\\" 😀\"}"
+
+	escaped, err := JSONProcessorService.EscapeJSON(context.Background(), input, EscapeOptions{ASCIIOnly: true})
+	if err != nil {
+		t.Fatalf("EscapeJSON() failed: %v", err)
+	}
+	unescaped, err := JSONProcessorService.UnescapeJSON(escaped)
+	if err != nil {
+		t.Fatalf("UnescapeJSON() failed: %v", err)
+	}
+	if unescaped != input {
+		t.Errorf("round trip = %q, want %q", unescaped, input)
+	}
+}
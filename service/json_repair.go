@@ -0,0 +1,561 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"sojson/zlog"
+)
+
+// 修复类型
+const (
+	FixComment        = "comment"         // 移除注释
+	FixTrailingComma  = "trailing_comma"  // 移除尾随或多余的逗号
+	FixMissingComma   = "missing_comma"   // 补全成员之间缺失的逗号
+	FixMissingColon   = "missing_colon"   // 补全键值之间缺失的冒号
+	FixSingleQuote    = "single_quote"    // 单引号字符串改为双引号
+	FixUnquotedKey    = "unquoted_key"    // 为未加引号的键加引号
+	FixInvalidEscape  = "invalid_escape"  // 修正字符串中的无效转义
+	FixControlChar    = "control_char"    // 转义字符串中的控制字符
+	FixNonFinite      = "non_finite"      // NaN、Infinity 替换为 null
+	FixNumberFormat   = "number_format"   // 修正数字写法
+	FixExtraBracket   = "extra_bracket"   // 移除多余的右括号
+	FixMissingBracket = "missing_bracket" // 补全缺失的右括号
+)
+
+// RepairFix 一次修复记录
+type RepairFix struct {
+	Position
+	Kind    string // 修复类型
+	Message string // 修复说明
+}
+
+// RepairResult 修复结果
+type RepairResult struct {
+	Result string      // 修复并格式化后的JSON
+	Fixes  []RepairFix // 按位置排列的修复记录
+}
+
+// RepairJSON 宽松解析并修复常见的JSON错误，返回修复后的JSON及每一处修复
+func (s *jsonProcessorService) RepairJSON(ctx context.Context, text string, opts FormatOptions) (*RepairResult, error) {
+	p := &repairParser{jsonParser: jsonParser{data: text}}
+	tree, err := p.parse()
+	if err != nil {
+		zlog.Errorf(ctx, "RepairJSON: repair failed, input text length: %d, fixes: %d, error: %v", len(text), len(p.fixes), err)
+		return nil, err
+	}
+
+	result := formatJSONTree(tree, opts)
+	zlog.Infof(ctx, "RepairJSON: successfully repaired JSON, input length: %d, output length: %d, fixes: %d", len(text), len(result), len(p.fixes))
+	return &RepairResult{Result: result, Fixes: p.fixes}, nil
+}
+
+// ProcessJSONWithRepair 完整处理并修复：为裸值加引号，输入是转义后的JSON时去除转义，再宽松解析修复后格式化；
+// 修复记录和错误的位置对应原始输入
+func (s *jsonProcessorService) ProcessJSONWithRepair(ctx context.Context, text string, opts ProcessOptions) (*RepairResult, error) {
+	fixed, quoted, err := s.quoteValues(text, opts.QuoteRules, DialectJSON)
+	if err != nil {
+		zlog.Errorf(ctx, "ProcessJSONWithRepair: QuoteBareValues failed, rules: %d, error: %v", len(opts.QuoteRules), err)
+		return nil, err
+	}
+
+	// 未转义的输入中的反斜杠属于字符串内容，如 {'a': 'it\'s'}，去除转义会破坏原文
+	unescaped, escaped := fixed, offsetMap(nil)
+	if isEscapedJSON(fixed) {
+		if unescaped, escaped, err = unescapeJSON(fixed); err != nil {
+			zlog.Errorf(ctx, "ProcessJSONWithRepair: UnescapeJSON failed, input text length: %d, error: %v", len(fixed), err)
+			return nil, relocate(err, text, quoted)
+		}
+	}
+
+	result, err := s.RepairJSON(ctx, unescaped, opts.FormatOptions)
+	if err != nil {
+		return nil, relocate(err, text, quoted, escaped)
+	}
+	for i := range result.Fixes {
+		result.Fixes[i].Position = newPosition(text, quoted.original(escaped.original(result.Fixes[i].Offset)))
+	}
+	return result, nil
+}
+
+// isEscapedJSON 输入是否是转义后的JSON：整体是字符串字面量，或第一个引号是转义的双引号，如 {\"a\":1}
+func isEscapedJSON(text string) bool {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, `"`) {
+		return true
+	}
+	i := strings.IndexAny(text, `"'`)
+	return i > 0 && text[i] == '"' && text[i-1] == '\\'
+}
+
+// repairParser 宽松的递归下降解析器，边解析边记录修复
+type repairParser struct {
+	jsonParser
+	stack []byte // 尚未闭合的容器的左括号
+	fixes []RepairFix
+}
+
+func (p *repairParser) fix(offset int, kind string, format string, args ...interface{}) {
+	p.fixes = append(p.fixes, RepairFix{
+		Position: newPosition(p.data, offset),
+		Kind:     kind,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (p *repairParser) parse() (*jsonNode, error) {
+	p.skip()
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("JSON值")
+	}
+
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	// 顶层值之后只允许空白、注释和多余的右括号
+	for {
+		p.skip()
+		if p.pos >= len(p.data) {
+			return node, nil
+		}
+		c := p.data[p.pos]
+		if c != '}' && c != ']' {
+			return nil, p.unexpected("文本结尾")
+		}
+		p.fix(p.pos, FixExtraBracket, "移除多余的 '%c'", c)
+		p.pos++
+	}
+}
+
+// skip 跳过空白和注释
+func (p *repairParser) skip() {
+	for {
+		p.skipSpace()
+		if !strings.HasPrefix(p.data[p.pos:], "//") && !strings.HasPrefix(p.data[p.pos:], "/*") {
+			return
+		}
+
+		start := p.pos
+		if p.data[p.pos+1] == '/' {
+			end := strings.IndexByte(p.data[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end
+			}
+			p.fix(start, FixComment, "移除行注释")
+			continue
+		}
+
+		end := strings.Index(p.data[p.pos+2:], "*/")
+		if end < 0 {
+			p.pos = len(p.data)
+		} else {
+			p.pos += end + 4
+		}
+		p.fix(start, FixComment, "移除块注释")
+	}
+}
+
+func (p *repairParser) parseValue() (*jsonNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("JSON值")
+	}
+
+	start := p.pos
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || c == '\'':
+		raw, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonNode{kind: nodeString, raw: raw, offset: start}, nil
+	case c == '-' || c == '+' || c == '.' || isDigit(c):
+		return p.parseNumber()
+	case isIdentStart(c):
+		word := p.readIdent()
+		switch word {
+		case "true", "false", "null":
+			return &jsonNode{kind: nodeLiteral, raw: word, offset: start}, nil
+		case "NaN", "Infinity", "undefined":
+			p.fix(start, FixNonFinite, "%s 替换为 null", word)
+			return &jsonNode{kind: nodeLiteral, raw: "null", offset: start}, nil
+		}
+		p.pos = start
+		return nil, p.unexpected("JSON值")
+	default:
+		return nil, p.unexpected("JSON值")
+	}
+}
+
+// closeContainer 处理容器结尾，返回容器是否已结束。
+// 匹配的右括号直接消费；属于外层容器的右括号说明本容器缺少右括号，补全后不消费；
+// 其余右括号视为多余并移除；文本结尾时补全右括号
+func (p *repairParser) closeContainer(closer byte) bool {
+	for {
+		if p.pos >= len(p.data) {
+			p.fix(p.pos, FixMissingBracket, "补全缺失的 '%c'", closer)
+			return true
+		}
+
+		c := p.data[p.pos]
+		switch {
+		case c == closer:
+			p.pos++
+			return true
+		case c == '}' || c == ']':
+			if p.isOuterOpen(c) {
+				p.fix(p.pos, FixMissingBracket, "补全缺失的 '%c'", closer)
+				return true
+			}
+			p.fix(p.pos, FixExtraBracket, "移除多余的 '%c'", c)
+			p.pos++
+			p.skip()
+		default:
+			return false
+		}
+	}
+}
+
+// isOuterOpen 外层是否有与右括号 c 对应的未闭合容器
+func (p *repairParser) isOuterOpen(c byte) bool {
+	opener := byte('[')
+	if c == '}' {
+		opener = '{'
+	}
+	for i := len(p.stack) - 2; i >= 0; i-- {
+		if p.stack[i] == opener {
+			return true
+		}
+	}
+	return false
+}
+
+// push 记录进入容器
+func (p *repairParser) push(opener byte) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	p.stack = append(p.stack, opener)
+	return nil
+}
+
+// pop 记录离开容器
+func (p *repairParser) pop() {
+	p.depth--
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+func (p *repairParser) parseObject() (*jsonNode, error) {
+	if err := p.push('{'); err != nil {
+		return nil, err
+	}
+	defer p.pop()
+
+	node := &jsonNode{kind: nodeObject, offset: p.pos}
+	p.pos++ // {
+
+	for {
+		p.skip()
+		p.skipExtraCommas()
+		if p.closeContainer('}') {
+			return node, nil
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skip()
+		if p.pos < len(p.data) && p.data[p.pos] == ':' {
+			p.pos++
+		} else if p.pos < len(p.data) && p.startsValue() {
+			p.fix(p.pos, FixMissingColon, "补全缺失的 ':'")
+		} else {
+			return nil, p.unexpected("':'")
+		}
+
+		p.skip()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.members = append(node.members, &jsonMember{key: key, value: value})
+
+		if done, err := p.afterElement('}'); done || err != nil {
+			return node, err
+		}
+	}
+}
+
+func (p *repairParser) parseArray() (*jsonNode, error) {
+	if err := p.push('['); err != nil {
+		return nil, err
+	}
+	defer p.pop()
+
+	node := &jsonNode{kind: nodeArray, offset: p.pos}
+	p.pos++ // [
+
+	for {
+		p.skip()
+		p.skipExtraCommas()
+		if p.closeContainer(']') {
+			return node, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+
+		if done, err := p.afterElement(']'); done || err != nil {
+			return node, err
+		}
+	}
+}
+
+// afterElement 处理成员之后的分隔符，返回容器是否已结束
+func (p *repairParser) afterElement(closer byte) (bool, error) {
+	p.skip()
+	// 先处理右括号，多余的右括号被移除后可能紧跟逗号
+	if p.pos >= len(p.data) || p.data[p.pos] != ',' {
+		if p.closeContainer(closer) {
+			return true, nil
+		}
+	}
+
+	if p.data[p.pos] == ',' {
+		comma := p.pos
+		p.pos++
+		p.skip()
+		if p.pos >= len(p.data) || p.data[p.pos] == '}' || p.data[p.pos] == ']' {
+			p.fix(comma, FixTrailingComma, "移除尾随的逗号")
+			return p.closeContainer(closer), nil
+		}
+		return false, nil
+	}
+
+	if p.startsValue() {
+		p.fix(p.pos, FixMissingComma, "补全缺失的 ','")
+		return false, nil
+	}
+	return false, p.unexpected(fmt.Sprintf("',' 或 '%c'", closer))
+}
+
+// skipExtraCommas 跳过连续或开头多余的逗号
+func (p *repairParser) skipExtraCommas() {
+	for p.pos < len(p.data) && p.data[p.pos] == ',' {
+		p.fix(p.pos, FixTrailingComma, "移除多余的逗号")
+		p.pos++
+		p.skip()
+	}
+}
+
+// startsValue 当前位置是否可能是一个值或键的开头
+func (p *repairParser) startsValue() bool {
+	c := p.data[p.pos]
+	return c == '{' || c == '[' || c == '"' || c == '\'' || c == '-' || c == '+' || c == '.' || isDigit(c) || isIdentStart(c)
+}
+
+// parseKey 解析对象的键，支持单引号和未加引号的键
+func (p *repairParser) parseKey() (string, error) {
+	if p.pos >= len(p.data) {
+		return "", p.unexpected("字符串类型的键")
+	}
+
+	c := p.data[p.pos]
+	if c == '"' || c == '\'' {
+		return p.parseString()
+	}
+	if isIdentStart(c) || isDigit(c) {
+		start := p.pos
+		for p.pos < len(p.data) && (isIdentStart(p.data[p.pos]) || isDigit(p.data[p.pos]) || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		key := p.data[start:p.pos]
+		p.fix(start, FixUnquotedKey, "为键 %s 加引号", key)
+		return encodeJSONString(key, false, false), nil
+	}
+	return "", p.unexpected("字符串类型的键")
+}
+
+// parseString 宽松地解析单引号或双引号字符串，返回合法的双引号字符串原文
+func (p *repairParser) parseString() (string, error) {
+	start := p.pos
+	quote := p.data[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	changed := quote == '\''
+	if changed {
+		p.fix(start, FixSingleQuote, "单引号字符串改为双引号")
+	}
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			if !changed {
+				return p.data[start:p.pos], nil
+			}
+			return encodeJSONString(sb.String(), false, false), nil
+		case c == '\\' && p.pos+1 < len(p.data):
+			escStart := p.pos
+			if quote == '"' && p.data[p.pos+1] == '\'' {
+				p.fix(escStart, FixInvalidEscape, `转义 \' 改为 '`)
+				changed = true
+			}
+			if r, n, ok := p.readEscape(); ok {
+				sb.WriteString(r)
+				p.pos += n
+				continue
+			}
+			// 无效转义按字面量保留反斜杠
+			_, size := utf8.DecodeRuneInString(p.data[p.pos+1:])
+			p.fix(escStart, FixInvalidEscape, "无效的转义 %q 按字面量保留", p.data[p.pos:p.pos+1+size])
+			changed = true
+			sb.WriteByte('\\')
+			p.pos++
+		case c < 0x20:
+			p.fix(p.pos, FixControlChar, "转义字符串中的控制字符 %q", c)
+			changed = true
+			sb.WriteByte(c)
+			p.pos++
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	p.pos = start
+	return "", p.errorf("字符串未结束")
+}
+
+// readEscape 解析当前位置的转义序列，返回解码后的文本和消耗的字节数
+func (p *repairParser) readEscape() (string, int, bool) {
+	switch p.data[p.pos+1] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't', 'u':
+		seq := p.data[p.pos:]
+		n := 2
+		if seq[1] == 'u' {
+			if _, ok := parseHex4(seq, 2); !ok {
+				return "", 0, false
+			}
+			n = 6
+			// 代理对作为一个整体解码
+			if len(seq) >= 12 && seq[6] == '\\' && seq[7] == 'u' {
+				if _, ok := parseHex4(seq, 8); ok {
+					if r, err := decodeJSONString(seq[:12], 0); err == nil {
+						return r, 12, true
+					}
+				}
+			}
+		}
+		r, err := decodeJSONString(seq[:n], 0)
+		if err != nil {
+			// 孤立的代理项
+			return "\uFFFD", n, true
+		}
+		return r, n, true
+	case '\'':
+		return "'", 2, true
+	}
+	return "", 0, false
+}
+
+func (p *repairParser) parseNumber() (*jsonNode, error) {
+	start := p.pos
+	if p.data[p.pos] == '+' {
+		p.fix(p.pos, FixNumberFormat, "移除数字前的 '+'")
+		p.pos++
+	}
+	negative := p.pos < len(p.data) && p.data[p.pos] == '-'
+	if negative {
+		p.pos++
+	}
+
+	// -Infinity、+Infinity
+	if strings.HasPrefix(p.data[p.pos:], "Infinity") {
+		p.fix(start, FixNonFinite, "%s 替换为 null", p.data[start:p.pos+len("Infinity")])
+		p.pos += len("Infinity")
+		return &jsonNode{kind: nodeLiteral, raw: "null", offset: start}, nil
+	}
+
+	digitsStart := p.pos
+	intStart := p.pos
+	p.skipDigits()
+	intPart := p.data[intStart:p.pos]
+
+	fracPart := ""
+	hasFrac := p.pos < len(p.data) && p.data[p.pos] == '.'
+	if hasFrac {
+		p.pos++
+		fracStart := p.pos
+		p.skipDigits()
+		fracPart = p.data[fracStart:p.pos]
+	}
+	if intPart == "" && fracPart == "" {
+		p.pos = digitsStart
+		return nil, p.unexpected("数字")
+	}
+
+	expPart := ""
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		expStart := p.pos
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if !p.skipDigits() {
+			return nil, p.unexpected("指数部分的数字")
+		}
+		expPart = p.data[expStart:p.pos]
+	}
+
+	// 规范化：去掉多余的前导零，补全 .5 和 5. 这类写法
+	normalized := strings.TrimLeft(intPart, "0")
+	if normalized == "" {
+		normalized = "0"
+	}
+	if hasFrac {
+		if fracPart == "" {
+			fracPart = "0"
+		}
+		normalized += "." + fracPart
+	}
+	normalized += expPart
+	if negative {
+		normalized = "-" + normalized
+	}
+
+	original := p.data[start:p.pos]
+	if original != normalized && strings.TrimPrefix(original, "+") != normalized {
+		p.fix(start, FixNumberFormat, "数字 %s 改写为 %s", original, normalized)
+	}
+	return &jsonNode{kind: nodeNumber, raw: normalized, offset: start}, nil
+}
+
+// readIdent 读取一个标识符
+func (p *repairParser) readIdent() string {
+	start := p.pos
+	for p.pos < len(p.data) && (isIdentStart(p.data[p.pos]) || isDigit(p.data[p.pos])) {
+		p.pos++
+	}
+	return p.data[start:p.pos]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
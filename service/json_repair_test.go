@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		kinds    []string
+	}{
+		{
+			name:     "合法JSON无需修复",
+			input:    `{"a":[1,2.50,"x\/y"]}`,
+			expected: `{"a":[1,2.50,"x\/y"]}`,
+		},
+		{
+			name:     "尾随逗号",
+			input:    `{"a":[1,2,],}`,
+			expected: `{"a":[1,2]}`,
+			kinds:    []string{FixTrailingComma, FixTrailingComma},
+		},
+		{
+			name:     "单引号和未加引号的键",
+			input:    `{name:'it\'s "ok"', $id_2: 1}`,
+			expected: `{"name":"it's \"ok\"","$id_2":1}`,
+			kinds:    []string{FixUnquotedKey, FixSingleQuote, FixUnquotedKey},
+		},
+		{
+			name:     "注释",
+			input:    "{\n  // 行注释\n  \"a\": 1, /* 块注释 */ \"b\": \"http://x\"\n}",
+			expected: `{"a":1,"b":"http://x"}`,
+			kinds:    []string{FixComment, FixComment},
+		},
+		{
+			name:     "缺失的逗号",
+			input:    "{\"a\": 1\n \"b\": [1 2]}",
+			expected: `{"a":1,"b":[1,2]}`,
+			kinds:    []string{FixMissingComma, FixMissingComma},
+		},
+		{
+			name:     "缺失的右括号",
+			input:    `{"a":[1,{"b":2`,
+			expected: `{"a":[1,{"b":2}]}`,
+			kinds:    []string{FixMissingBracket, FixMissingBracket, FixMissingBracket},
+		},
+		{
+			name:     "内层缺少右括号",
+			input:    `{"a":[1,2}`,
+			expected: `{"a":[1,2]}`,
+			kinds:    []string{FixMissingBracket},
+		},
+		{
+			name:     "多余的右括号",
+			input:    `{"a":[1]], "b":2}}`,
+			expected: `{"a":[1],"b":2}`,
+			kinds:    []string{FixExtraBracket, FixExtraBracket},
+		},
+		{
+			name:     "NaN和Infinity",
+			input:    `[NaN, Infinity, -Infinity, +1, .5, 007]`,
+			expected: `[null,null,null,1,0.5,7]`,
+			kinds:    []string{FixNonFinite, FixNonFinite, FixNonFinite, FixNumberFormat, FixNumberFormat, FixNumberFormat},
+		},
+		{
+			name:     "字符串中的控制字符和无效转义",
+			input:    "[\"a\tb\", \"c:\\d\"]",
+			expected: `["a\tb","c:\\d"]`,
+			kinds:    []string{FixControlChar, FixInvalidEscape},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.RepairJSON(ctx, tt.input, FormatOptions{})
+			if err != nil {
+				t.Fatalf("RepairJSON() unexpected error = %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("RepairJSON() result = %s, want %s", result.Result, tt.expected)
+			}
+			var kinds []string
+			for _, fix := range result.Fixes {
+				kinds = append(kinds, fix.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Errorf("RepairJSON() fixes = %v, want %v", kinds, tt.kinds)
+			}
+			if err := JSONProcessorService.ValidateJSON(result.Result); err != nil {
+				t.Errorf("RepairJSON() result is not valid JSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestRepairJSONFixPosition(t *testing.T) {
+	result, err := JSONProcessorService.RepairJSON(context.Background(), "{\n  \"a\": 1,\n}", FormatOptions{})
+	if err != nil {
+		t.Fatalf("RepairJSON() failed: %v", err)
+	}
+	if len(result.Fixes) != 1 {
+		t.Fatalf("RepairJSON() fixes = %v, want 1 fix", result.Fixes)
+	}
+	if fix := result.Fixes[0]; fix.Line != 2 || fix.Column != 9 || fix.Offset != 10 {
+		t.Errorf("RepairJSON() fix position = %+v, want line 2 column 9 offset 10", fix.Position)
+	}
+}
+
+func TestProcessJSONWithRepair(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		fixes    []int // 每处修复在原始输入中的偏移
+	}{
+		{
+			name:     "未转义的输入保留字符串中的反斜杠",
+			input:    `{'a': 'it\'s'}`,
+			expected: `{"a":"it's"}`,
+			fixes:    []int{1, 6},
+		},
+		{
+			name:     "整体是字符串字面量时先去除转义",
+			input:    `"{\"a\": 1,}"`,
+			expected: `{"a":1}`,
+			fixes:    []int{10},
+		},
+		{
+			name:     "没有外层引号的转义JSON",
+			input:    `{\"a\":\"é\", \"b\":1,}`,
+			expected: `{"a":"é","b":1}`,
+			fixes:    []int{22},
+		},
+		{
+			name:     "加引号的裸值不影响修复位置",
+			input:    `{"t": 2025-08-18, "a": 1,}`,
+			expected: `{"t":"2025-08-18","a":1}`,
+			fixes:    []int{24},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.ProcessJSONWithRepair(ctx, tt.input, ProcessOptions{})
+			if err != nil {
+				t.Fatalf("ProcessJSONWithRepair() unexpected error = %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("ProcessJSONWithRepair() = %s, want %s", result.Result, tt.expected)
+			}
+			var offsets []int
+			for _, fix := range result.Fixes {
+				offsets = append(offsets, fix.Offset)
+			}
+			if !reflect.DeepEqual(offsets, tt.fixes) {
+				t.Errorf("ProcessJSONWithRepair() fix offsets = %v, want %v", offsets, tt.fixes)
+			}
+		})
+	}
+}
+
+func TestRepairJSONUnrepairable(t *testing.T) {
+	inputs := []string{`{"a": @}`, `[1] trailing`, `{"a" 1 : 2}`, ``}
+	for _, input := range inputs {
+		if _, err := JSONProcessorService.RepairJSON(context.Background(), input, FormatOptions{}); err == nil {
+			t.Errorf("RepairJSON(%q) expected error but got none", input)
+		}
+	}
+}
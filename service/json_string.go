@@ -0,0 +1,236 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UnescapeError 转义序列解析错误
+type UnescapeError struct {
+	Position
+	Sequence string // 出错的转义序列原文
+	Reason   string // 错误原因
+}
+
+func (e *UnescapeError) Error() string {
+	return fmt.Sprintf("第 %d 行第 %d 列的转义序列 %q 无效: %s", e.Line, e.Column, e.Sequence, e.Reason)
+}
+
+// Pos 返回错误位置
+func (e *UnescapeError) Pos() Position {
+	return e.Position
+}
+
+// decodeJSONString 按 RFC 8259 单遍解码 JSON 字符串字面量的内容（不含外层引号）
+// base 为 text 在原始输入中的起始偏移，用于错误定位
+func decodeJSONString(text string, base int) (string, error) {
+	// 没有反斜杠时无需解码
+	if strings.IndexByte(text, '\\') < 0 {
+		return text, nil
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(text))
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		if c != '\\' {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+
+		if i+1 >= len(text) {
+			return "", &UnescapeError{Position: Position{Offset: base + i}, Sequence: `\`, Reason: "反斜杠位于文本末尾"}
+		}
+
+		switch text[i+1] {
+		case '"':
+			sb.WriteByte('"')
+		case '\\':
+			sb.WriteByte('\\')
+		case '/':
+			sb.WriteByte('/')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			r, n, err := decodeUnicodeEscape(text, i, base)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(r)
+			i += n
+			continue
+		default:
+			_, size := utf8.DecodeRuneInString(text[i+1:])
+			return "", &UnescapeError{Position: Position{Offset: base + i}, Sequence: text[i : i+1+size], Reason: "不支持的转义字符"}
+		}
+		i += 2
+	}
+
+	return sb.String(), nil
+}
+
+// escapeOffsets 已成功解码的 text 中每个转义序列在解码结果中的位置，映射到 base + 转义序列在 text 中的偏移
+func escapeOffsets(text string, base int) offsetMap {
+	m := offsetMap{{out: 0, in: base}}
+	out := 0
+	for i := 0; i < len(text); {
+		if text[i] != '\\' {
+			j := strings.IndexByte(text[i:], '\\')
+			if j < 0 {
+				break
+			}
+			out += j
+			i += j
+			continue
+		}
+
+		n, size := 2, 1
+		if text[i+1] == 'u' {
+			r, consumed, _ := decodeUnicodeEscape(text, i, base)
+			n, size = consumed, utf8.RuneLen(r)
+		}
+		m.add(out, base+i)
+		out += size
+		i += n
+		m.add(out, base+i)
+	}
+	return m
+}
+
+// decodeUnicodeEscape 解析从 text[i] 开始的 \uXXXX 序列，必要时合并 UTF-16 代理对
+// 返回解码后的字符以及消耗的字节数
+func decodeUnicodeEscape(text string, i int, base int) (rune, int, error) {
+	r1, ok := parseHex4(text, i+2)
+	if !ok {
+		return 0, 0, &UnescapeError{Position: Position{Offset: base + i}, Sequence: escapeSnippet(text, i, 6), Reason: `\u 后需要 4 位十六进制数字`}
+	}
+
+	// 普通 BMP 字符
+	if !utf16.IsSurrogate(r1) {
+		return r1, 6, nil
+	}
+
+	// 低代理项不能单独出现
+	if r1 >= 0xDC00 {
+		return 0, 0, &UnescapeError{Position: Position{Offset: base + i}, Sequence: text[i : i+6], Reason: "孤立的 UTF-16 低代理项"}
+	}
+
+	// 高代理项后必须紧跟低代理项
+	if i+12 > len(text) || text[i+6] != '\\' || text[i+7] != 'u' {
+		return 0, 0, &UnescapeError{Position: Position{Offset: base + i}, Sequence: text[i : i+6], Reason: "UTF-16 高代理项后缺少低代理项"}
+	}
+	r2, ok := parseHex4(text, i+8)
+	if !ok {
+		return 0, 0, &UnescapeError{Position: Position{Offset: base + i + 6}, Sequence: escapeSnippet(text, i+6, 6), Reason: `\u 后需要 4 位十六进制数字`}
+	}
+	r := utf16.DecodeRune(r1, r2)
+	if r == utf8.RuneError {
+		return 0, 0, &UnescapeError{Position: Position{Offset: base + i}, Sequence: text[i : i+12], Reason: "无效的 UTF-16 代理对"}
+	}
+	return r, 12, nil
+}
+
+// parseHex4 解析 text[start:start+4] 处的 4 位十六进制数字
+func parseHex4(text string, start int) (rune, bool) {
+	if start+4 > len(text) {
+		return 0, false
+	}
+	var r rune
+	for _, c := range []byte(text[start : start+4]) {
+		switch {
+		case '0' <= c && c <= '9':
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
+// escapeSnippet 截取错误位置附近最多 n 字节的文本
+func escapeSnippet(text string, start int, n int) string {
+	end := start + n
+	if end > len(text) {
+		end = len(text)
+	}
+	return text[start:end]
+}
+
+const hexDigits = "0123456789abcdef"
+
+// encodeJSONString 把文本编码为带外层引号的 JSON 字符串字面量
+// asciiOnly 为 true 时非 ASCII 字符输出为 \uXXXX（必要时为代理对）
+// htmlSafe 为 true 时 <、>、& 以及 U+2028、U+2029 也输出为 \uXXXX
+func encodeJSONString(text string, asciiOnly bool, htmlSafe bool) string {
+	var sb strings.Builder
+	sb.Grow(len(text) + 2)
+	sb.WriteByte('"')
+
+	for _, r := range text {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '<', '>', '&', '\u2028', '\u2029':
+			if htmlSafe || (asciiOnly && r >= utf8.RuneSelf) {
+				writeUnicodeEscape(&sb, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		default:
+			switch {
+			case r < 0x20:
+				writeUnicodeEscape(&sb, r)
+			case r >= utf8.RuneSelf && asciiOnly:
+				if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+					writeUnicodeEscape(&sb, r1)
+					writeUnicodeEscape(&sb, r2)
+				} else {
+					writeUnicodeEscape(&sb, r)
+				}
+			default:
+				sb.WriteRune(r)
+			}
+		}
+	}
+
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// writeUnicodeEscape 写入 \uXXXX 形式的转义
+func writeUnicodeEscape(sb *strings.Builder, r rune) {
+	sb.WriteString(`\u`)
+	sb.WriteByte(hexDigits[r>>12&0xF])
+	sb.WriteByte(hexDigits[r>>8&0xF])
+	sb.WriteByte(hexDigits[r>>4&0xF])
+	sb.WriteByte(hexDigits[r&0xF])
+}
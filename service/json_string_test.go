@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnescapeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "外层引号和转义引号",
+			input:    `"{\"a\":1}"`,
+			expected: `{"a":1}`,
+		},
+		{
+			name:     "转义的反斜杠后跟n",
+			input:    `"C:\\new"`,
+			expected: `C:\new`,
+		},
+		{
+			name:     "控制字符",
+			input:    `a\tb\nc\rd\be\ff\/g`,
+			expected: "a\tb\nc\rd\be\ff/g",
+		},
+		{
+			name:     "unicode转义",
+			input:    `\u4f60\u597D`,
+			expected: "你好",
+		},
+		{
+			name:     "代理对",
+			input:    `\ud83d\ude00`,
+			expected: "😀",
+		},
+		{
+			name:     "首尾空白",
+			input:    "  \"x\\\"y\"\n",
+			expected: `x"y`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.UnescapeJSON(tt.input)
+			if err != nil {
+				t.Fatalf("UnescapeJSON() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("UnescapeJSON() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnescapeJSONInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+	}{
+		{
+			name:   "未知转义字符",
+			input:  `"ab\x"`,
+			offset: 3,
+		},
+		{
+			name:   "十六进制位数不足",
+			input:  `  \u12`,
+			offset: 2,
+		},
+		{
+			name:   "孤立的高代理项",
+			input:  `x\ud83dy`,
+			offset: 1,
+		},
+		{
+			name:   "孤立的低代理项",
+			input:  `\ude00`,
+			offset: 0,
+		},
+		{
+			name:   "末尾反斜杠",
+			input:  `abc\`,
+			offset: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := JSONProcessorService.UnescapeJSON(tt.input)
+			var unescapeErr *UnescapeError
+			if !errors.As(err, &unescapeErr) {
+				t.Fatalf("UnescapeJSON() error = %v, want *UnescapeError", err)
+			}
+			if unescapeErr.Offset != tt.offset {
+				t.Errorf("UnescapeJSON() offset = %d, want %d", unescapeErr.Offset, tt.offset)
+			}
+		})
+	}
+}
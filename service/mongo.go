@@ -0,0 +1,818 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sojson/zlog"
+)
+
+// MongoMode MongoDB 相关的转换方式
+type MongoMode string
+
+const (
+	// MongoNone 不做转换
+	MongoNone MongoMode = ""
+	// MongoCanonical 把 mongo shell 输出转换为 Canonical Extended JSON，保留全部类型信息
+	MongoCanonical MongoMode = "canonical"
+	// MongoRelaxed 把 mongo shell 输出转换为 Relaxed Extended JSON，数字和常见日期直接输出
+	MongoRelaxed MongoMode = "relaxed"
+	// MongoFlatten 把 Extended JSON（或 mongo shell 输出）展平为普通的JSON值
+	MongoFlatten MongoMode = "flatten"
+)
+
+// ParseMongoMode 解析转换方式名称，空字符串表示不做转换
+func ParseMongoMode(name string) (MongoMode, error) {
+	switch mode := MongoMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case MongoNone, MongoCanonical, MongoRelaxed, MongoFlatten:
+		return mode, nil
+	}
+	return "", fmt.Errorf("不支持的MongoDB转换方式: %s，可选 canonical、relaxed、flatten", name)
+}
+
+// mongoDateLayouts ISODate 和 Date 中日期字符串的格式，未带时区时按 UTC 处理
+var mongoDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// objectIDPattern ObjectId 的 24 位十六进制字符串
+var objectIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{24}$`)
+
+// MongoShellToExtendedJSON 把 mongo shell 输出转换为 Extended JSON，
+// 支持 ObjectId、ISODate、NumberLong、NumberDecimal、Timestamp、BinData、UUID、正则字面量等写法；
+// 输入中已有的 {"$numberLong": ...}、{"$date": ...}、{"$timestamp": ...} 等包装也按目标模式改写
+func (s *jsonProcessorService) MongoShellToExtendedJSON(ctx context.Context, text string, relaxed bool, opts FormatOptions) (string, error) {
+	tree, err := decodeMongoShell(text, relaxed)
+	if err != nil {
+		zlog.Errorf(ctx, "MongoShellToExtendedJSON: decodeMongoShell failed, input text length: %d, relaxed: %t, error: %v", len(text), relaxed, err)
+		return "", err
+	}
+
+	result := formatJSONTree(normalizeExtendedJSON(tree, relaxed), opts)
+	zlog.Infof(ctx, "MongoShellToExtendedJSON: successfully converted, input length: %d, output length: %d, relaxed: %t", len(text), len(result), relaxed)
+	return result, nil
+}
+
+// FlattenExtendedJSON 把 Extended JSON 中的 {"$oid": ...}、{"$date": ...}、{"$numberLong": ...} 等
+// 包装展平为普通的字符串和数字，输入也可以直接是 mongo shell 输出；日期统一输出为 2006-01-02T15:04:05.000Z 的写法，
+// 取值无效的包装保留原样
+func (s *jsonProcessorService) FlattenExtendedJSON(ctx context.Context, text string, opts FormatOptions) (string, error) {
+	// 按 Relaxed 模式解析，普通数字保留原始写法
+	tree, err := decodeMongoShell(text, true)
+	if err != nil {
+		zlog.Errorf(ctx, "FlattenExtendedJSON: decodeMongoShell failed, input text length: %d, error: %v", len(text), err)
+		return "", err
+	}
+
+	result := formatJSONTree(flattenExtendedJSON(tree), opts)
+	zlog.Infof(ctx, "FlattenExtendedJSON: successfully flattened, input length: %d, output length: %d", len(text), len(result))
+	return result, nil
+}
+
+// convertMongo 按转换方式处理 mongo shell 输出或 Extended JSON
+func (s *jsonProcessorService) convertMongo(ctx context.Context, text string, mode MongoMode, opts FormatOptions) (string, error) {
+	switch mode {
+	case MongoCanonical, MongoRelaxed:
+		return s.MongoShellToExtendedJSON(ctx, text, mode == MongoRelaxed, opts)
+	case MongoFlatten:
+		return s.FlattenExtendedJSON(ctx, text, opts)
+	}
+	return "", fmt.Errorf("不支持的MongoDB转换方式: %s", mode)
+}
+
+// mongoParser mongo shell 输出的解码器，同时接受严格JSON和 Extended JSON
+type mongoParser struct {
+	literalParser
+	relaxed bool // 输出 Relaxed Extended JSON
+}
+
+func decodeMongoShell(text string, relaxed bool) (*jsonNode, error) {
+	p := &mongoParser{literalParser: newLiteralParser(text), relaxed: relaxed}
+	p.skipSpace()
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(node)
+}
+
+func (p *mongoParser) parseValue() (*jsonNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected("值")
+	}
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || c == '\'':
+		value, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return newStringNode(value), nil
+	case c == '/':
+		return p.parseRegex()
+	case c == '-' || c == '+' || c == '.' || isDigit(c):
+		return p.parseNumber()
+	}
+
+	start := p.pos
+	name := p.readIdent()
+	if name == "new" {
+		p.skipSpace()
+		start = p.pos
+		name = p.readIdent()
+	}
+	switch name {
+	case "":
+		return nil, p.unexpected("值")
+	case "true", "false", "null":
+		return newLiteralNode(name), nil
+	case "undefined":
+		return ejsonWrap("$undefined", newLiteralNode("true")), nil
+	case "NaN", "Infinity":
+		return p.double(name), nil
+	}
+
+	p.skipSpace()
+	var args []*jsonNode
+	if p.peek() == '(' {
+		var err error
+		if args, err = p.parseArgs(); err != nil {
+			return nil, err
+		}
+	} else if name != "MinKey" && name != "MaxKey" {
+		p.pos = start
+		return nil, p.unexpected("值")
+	}
+
+	node, err := p.construct(name, args)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("%s: %v", name, err)
+	}
+	return node, nil
+}
+
+// parseObject 解析对象，键可以不加引号或使用单引号，允许末尾多余的逗号
+func (p *mongoParser) parseObject() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // {
+	node := &jsonNode{kind: nodeObject}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			return node, nil
+		}
+
+		var key string
+		if c := p.peek(); c == '"' || c == '\'' {
+			value, err := p.readQuoted()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+		} else {
+			start := p.pos
+			for p.readIdent() != "" && p.consume(".") {
+			}
+			if key = p.data[start:p.pos]; key == "" {
+				return nil, p.unexpected("键")
+			}
+		}
+
+		p.skipSpace()
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		appendMember(node, key, value)
+
+		if done, err := p.separator('}'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+func (p *mongoParser) parseArray() (*jsonNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++ // [
+	node := &jsonNode{kind: nodeArray}
+	for {
+		p.skipSpace()
+		if p.consume("]") {
+			return node, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elements = append(node.elements, value)
+
+		if done, err := p.separator(']'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// parseArgs 解析构造函数的参数列表
+func (p *mongoParser) parseArgs() ([]*jsonNode, error) {
+	p.pos++ // (
+	var args []*jsonNode
+	for {
+		p.skipSpace()
+		if p.consume(")") {
+			return args, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+
+		if done, err := p.separator(')'); err != nil || done {
+			return args, err
+		}
+	}
+}
+
+// separator 处理元素之间的逗号，返回容器是否已结束
+func (p *mongoParser) separator(close byte) (bool, error) {
+	p.skipSpace()
+	if p.consume(string(close)) {
+		return true, nil
+	}
+	if !p.consume(",") {
+		return false, p.unexpected("',' 或 '" + string(close) + "'")
+	}
+	return false, nil
+}
+
+// parseRegex 解析正则字面量，如 /^a.*b$/i
+func (p *mongoParser) parseRegex() (*jsonNode, error) {
+	start := p.pos
+	p.pos++ // /
+	inClass := false
+	for ; p.pos < len(p.data); p.pos++ {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos++
+			continue
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			p.pos = start
+			return nil, p.errorf("正则表达式未结束")
+		case '/':
+			if inClass {
+				continue
+			}
+			pattern := p.data[start+1 : p.pos]
+			p.pos++
+			flagsStart := p.pos
+			for isLetter(p.peek()) {
+				p.pos++
+			}
+			return regexNode(pattern, p.data[flagsStart:p.pos]), nil
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("正则表达式未结束")
+}
+
+// parseNumber 解析数字，Canonical 模式下按取值范围包装为 $numberInt、$numberLong 或 $numberDouble
+func (p *mongoParser) parseNumber() (*jsonNode, error) {
+	start := p.pos
+	if c := p.peek(); c == '-' || c == '+' {
+		p.pos++
+	}
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if isDigit(c) || isLetter(c) || c == '.' || c == '_' ||
+			((c == '+' || c == '-') && (p.data[p.pos-1] == 'e' || p.data[p.pos-1] == 'E')) {
+			p.pos++
+			continue
+		}
+		break
+	}
+
+	token := p.data[start:p.pos]
+	switch strings.TrimPrefix(token, "+") {
+	case "Infinity", "-Infinity", "NaN":
+		return p.double(strings.TrimPrefix(token, "+")), nil
+	}
+	raw, ok := normalizeNumber(token)
+	if !ok || raw == "" {
+		p.pos = start
+		return nil, p.unexpected("数字")
+	}
+
+	number := &jsonNode{kind: nodeNumber, raw: raw}
+	if p.relaxed {
+		return number, nil
+	}
+	if !strings.ContainsAny(raw, ".eE") {
+		if _, err := strconv.ParseInt(raw, 10, 32); err == nil {
+			return ejsonWrap("$numberInt", newStringNode(raw)), nil
+		}
+		if _, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return ejsonWrap("$numberLong", newStringNode(raw)), nil
+		}
+	}
+	value, _ := strconv.ParseFloat(raw, 64)
+	return ejsonWrap("$numberDouble", newStringNode(formatDouble(value))), nil
+}
+
+// double 生成 NaN、Infinity 等非有限值，两种模式下都只能用 $numberDouble 表示
+func (p *mongoParser) double(name string) *jsonNode {
+	return ejsonWrap("$numberDouble", newStringNode(name))
+}
+
+// construct 把 shell 的构造函数转换为 Extended JSON
+func (p *mongoParser) construct(name string, args []*jsonNode) (*jsonNode, error) {
+	switch name {
+	case "ObjectId":
+		id, err := stringArg(args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		if !objectIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("ObjectId 需要 24 位十六进制字符串")
+		}
+		return ejsonWrap("$oid", newStringNode(strings.ToLower(id))), nil
+
+	case "ISODate", "Date":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("需要 1 个参数")
+		}
+		var t time.Time
+		if args[0].kind == nodeString {
+			var err error
+			if t, err = parseMongoDate(args[0].stringValue()); err != nil {
+				return nil, err
+			}
+		} else {
+			ms, err := int64Arg(args, 0, 1)
+			if err != nil {
+				return nil, err
+			}
+			t = time.UnixMilli(ms)
+		}
+		return p.date(t), nil
+
+	case "NumberLong", "Long":
+		value, err := int64Arg(args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		raw := strconv.FormatInt(value, 10)
+		if p.relaxed {
+			return &jsonNode{kind: nodeNumber, raw: raw}, nil
+		}
+		return ejsonWrap("$numberLong", newStringNode(raw)), nil
+
+	case "NumberInt", "Int32":
+		value, err := int64Arg(args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			return nil, fmt.Errorf("%d 超出 32 位整数范围", value)
+		}
+		raw := strconv.FormatInt(value, 10)
+		if p.relaxed {
+			return &jsonNode{kind: nodeNumber, raw: raw}, nil
+		}
+		return ejsonWrap("$numberInt", newStringNode(raw)), nil
+
+	case "Double":
+		text, err := scalarArg(args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字 %s", text)
+		}
+		if p.relaxed && !math.IsInf(value, 0) && !math.IsNaN(value) {
+			return &jsonNode{kind: nodeNumber, raw: strconv.FormatFloat(value, 'g', -1, 64)}, nil
+		}
+		return ejsonWrap("$numberDouble", newStringNode(formatDouble(value))), nil
+
+	case "NumberDecimal", "Decimal128":
+		text, err := scalarArg(args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := strconv.ParseFloat(text, 64); err != nil && !isRangeError(err) {
+			return nil, fmt.Errorf("无效的十进制数 %s", text)
+		}
+		return ejsonWrap("$numberDecimal", newStringNode(text)), nil
+
+	case "Timestamp":
+		if len(args) == 1 && args[0].kind == nodeObject {
+			// mongosh 的 Timestamp({ t: 1, i: 2 })
+			var fields []*jsonNode
+			for _, key := range []string{"t", "i"} {
+				value := memberValue(args[0], key)
+				if value == nil {
+					return nil, fmt.Errorf("缺少字段 %s", key)
+				}
+				fields = append(fields, value)
+			}
+			args = fields
+		}
+		t, err := uint32Arg(args, 0, 2)
+		if err != nil {
+			return nil, err
+		}
+		i, err := uint32Arg(args, 1, 2)
+		if err != nil {
+			return nil, err
+		}
+		ts := &jsonNode{kind: nodeObject}
+		appendMember(ts, "t", &jsonNode{kind: nodeNumber, raw: t})
+		appendMember(ts, "i", &jsonNode{kind: nodeNumber, raw: i})
+		return ejsonWrap("$timestamp", ts), nil
+
+	case "BinData", "HexData":
+		subType, err := int64Arg(args, 0, 2)
+		if err != nil {
+			return nil, err
+		}
+		if subType < 0 || subType > 0xFF {
+			return nil, fmt.Errorf("子类型 %d 超出范围", subType)
+		}
+		data, err := stringArg(args, 1, 2)
+		if err != nil {
+			return nil, err
+		}
+		var bytes []byte
+		if name == "HexData" {
+			bytes, err = hex.DecodeString(data)
+		} else {
+			bytes, err = base64.StdEncoding.DecodeString(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("无效的二进制数据: %v", err)
+		}
+		return binaryNode(bytes, byte(subType)), nil
+
+	case "UUID":
+		text, err := stringArg(args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		bytes, err := hex.DecodeString(strings.ReplaceAll(text, "-", ""))
+		if err != nil || len(bytes) != 16 {
+			return nil, fmt.Errorf("无效的UUID %s", text)
+		}
+		return binaryNode(bytes, 4), nil
+
+	case "RegExp", "BSONRegExp":
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("需要 1 或 2 个参数")
+		}
+		pattern, err := stringArg(args, 0, len(args))
+		if err != nil {
+			return nil, err
+		}
+		flags := ""
+		if len(args) == 2 {
+			if flags, err = stringArg(args, 1, 2); err != nil {
+				return nil, err
+			}
+		}
+		return regexNode(pattern, flags), nil
+
+	case "MinKey":
+		return ejsonWrap("$minKey", &jsonNode{kind: nodeNumber, raw: "1"}), nil
+	case "MaxKey":
+		return ejsonWrap("$maxKey", &jsonNode{kind: nodeNumber, raw: "1"}), nil
+
+	case "DBRef":
+		if len(args) != 2 && len(args) != 3 {
+			return nil, fmt.Errorf("需要 2 或 3 个参数")
+		}
+		ref := &jsonNode{kind: nodeObject}
+		for i, key := range []string{"$ref", "$id", "$db"}[:len(args)] {
+			if key != "$id" && args[i].kind != nodeString {
+				return nil, fmt.Errorf("%s 需要字符串", key)
+			}
+			appendMember(ref, key, args[i])
+		}
+		return ref, nil
+
+	case "Code":
+		code, err := stringArg(args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		return ejsonWrap("$code", newStringNode(code)), nil
+	}
+	return nil, fmt.Errorf("不支持的构造函数")
+}
+
+// date 生成日期：Relaxed 模式下 1970 到 9999 年之间的日期输出为 ISO-8601 字符串，其余输出毫秒数
+func (p *mongoParser) date(t time.Time) *jsonNode {
+	t = t.UTC()
+	if p.relaxed && t.Year() >= 1970 && t.Year() <= 9999 {
+		return ejsonWrap("$date", newStringNode(t.Format("2006-01-02T15:04:05.000Z")))
+	}
+	return ejsonWrap("$date", ejsonWrap("$numberLong", newStringNode(strconv.FormatInt(t.UnixMilli(), 10))))
+}
+
+// parseMongoDate 解析 ISODate 中的日期字符串
+func parseMongoDate(text string) (time.Time, error) {
+	for _, layout := range mongoDateLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期 %s", text)
+}
+
+// ejsonWrap 生成只有一个成员的 Extended JSON 包装对象，如 {"$oid": "..."}
+func ejsonWrap(key string, value *jsonNode) *jsonNode {
+	node := &jsonNode{kind: nodeObject}
+	appendMember(node, key, value)
+	return node
+}
+
+// binaryNode 生成 {"$binary": {"base64": ..., "subType": ...}}
+func binaryNode(data []byte, subType byte) *jsonNode {
+	bin := &jsonNode{kind: nodeObject}
+	appendMember(bin, "base64", newStringNode(base64.StdEncoding.EncodeToString(data)))
+	appendMember(bin, "subType", newStringNode(fmt.Sprintf("%02x", subType)))
+	return ejsonWrap("$binary", bin)
+}
+
+// regexNode 生成 {"$regularExpression": {...}}，选项按字母顺序排列
+func regexNode(pattern string, flags string) *jsonNode {
+	options := strings.Split(flags, "")
+	sort.Strings(options)
+	re := &jsonNode{kind: nodeObject}
+	appendMember(re, "pattern", newStringNode(pattern))
+	appendMember(re, "options", newStringNode(strings.Join(options, "")))
+	return ejsonWrap("$regularExpression", re)
+}
+
+// formatDouble 按 Extended JSON 的习惯输出浮点数，整数值保留 .0
+func formatDouble(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".eE") {
+		text += ".0"
+	}
+	return text
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+// memberValue 返回对象中第一个键为 key 的成员的值，不存在时返回 nil
+func memberValue(obj *jsonNode, key string) *jsonNode {
+	for _, m := range obj.members {
+		if m.keyValue() == key {
+			return m.value
+		}
+	}
+	return nil
+}
+
+// scalarArg 取第 i 个参数的文本，参数可以是字符串、数字或已包装的数字，n 为要求的参数个数
+func scalarArg(args []*jsonNode, i int, n int) (string, error) {
+	if len(args) != n {
+		return "", fmt.Errorf("需要 %d 个参数", n)
+	}
+	if text, ok := scalarText(args[i]); ok {
+		return text, nil
+	}
+	return "", fmt.Errorf("第 %d 个参数需要数字或字符串", i+1)
+}
+
+// scalarText 取字符串、数字或 {"$numberInt": ...} 等包装数字的文本
+func scalarText(node *jsonNode) (string, bool) {
+	switch node.kind {
+	case nodeString:
+		return node.stringValue(), true
+	case nodeNumber:
+		return node.raw, true
+	case nodeObject:
+		if len(node.members) == 1 && node.members[0].value.kind == nodeString {
+			switch node.members[0].keyValue() {
+			case "$numberInt", "$numberLong", "$numberDouble", "$numberDecimal":
+				return node.members[0].value.stringValue(), true
+			}
+		}
+	}
+	return "", false
+}
+
+func stringArg(args []*jsonNode, i int, n int) (string, error) {
+	if len(args) != n {
+		return "", fmt.Errorf("需要 %d 个参数", n)
+	}
+	if args[i].kind != nodeString {
+		return "", fmt.Errorf("第 %d 个参数需要字符串", i+1)
+	}
+	return args[i].stringValue(), nil
+}
+
+func int64Arg(args []*jsonNode, i int, n int) (int64, error) {
+	text, err := scalarArg(args, i, n)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s 不是 64 位整数", text)
+	}
+	return value, nil
+}
+
+func uint32Arg(args []*jsonNode, i int, n int) (string, error) {
+	text, err := scalarArg(args, i, n)
+	if err != nil {
+		return "", err
+	}
+	if _, err := strconv.ParseUint(text, 10, 32); err != nil {
+		return "", fmt.Errorf("%s 不是 32 位无符号整数", text)
+	}
+	return text, nil
+}
+
+// wrapperConstructors 需要按 Canonical 或 Relaxed 模式改写的 Extended JSON 包装，及与之等价的 shell 构造函数
+var wrapperConstructors = map[string]string{
+	"$numberInt":     "NumberInt",
+	"$numberLong":    "NumberLong",
+	"$numberDouble":  "Double",
+	"$numberDecimal": "NumberDecimal",
+	"$date":          "Date",
+	"$timestamp":     "Timestamp",
+}
+
+// normalizeExtendedJSON 递归地把已有的 Extended JSON 包装改写为目标模式的写法，
+// 如 Relaxed 模式下 {"$numberLong": "1"} 改写为 1，Canonical 模式下 {"$date": "2024-01-01T00:00:00Z"} 改写为毫秒数；
+// 取值无效的包装保留原样
+func normalizeExtendedJSON(node *jsonNode, relaxed bool) *jsonNode {
+	switch node.kind {
+	case nodeArray:
+		for i, e := range node.elements {
+			node.elements[i] = normalizeExtendedJSON(e, relaxed)
+		}
+	case nodeObject:
+		if len(node.members) == 1 {
+			if name, ok := wrapperConstructors[node.members[0].keyValue()]; ok {
+				p := &mongoParser{relaxed: relaxed}
+				if normalized, err := p.construct(name, []*jsonNode{node.members[0].value}); err == nil {
+					return normalized
+				}
+				return node
+			}
+		}
+		for _, m := range node.members {
+			m.value = normalizeExtendedJSON(m.value, relaxed)
+		}
+	}
+	return node
+}
+
+// flattenExtendedJSON 递归地把 Extended JSON 包装替换为普通的值
+func flattenExtendedJSON(node *jsonNode) *jsonNode {
+	switch node.kind {
+	case nodeArray:
+		for i, e := range node.elements {
+			node.elements[i] = flattenExtendedJSON(e)
+		}
+	case nodeObject:
+		if flat, ok := flattenWrapper(node); ok {
+			return flat
+		}
+		for _, m := range node.members {
+			m.value = flattenExtendedJSON(m.value)
+		}
+	}
+	return node
+}
+
+// flattenWrapper 展平单个 Extended JSON 包装，不是包装时返回 false
+func flattenWrapper(node *jsonNode) (*jsonNode, bool) {
+	if len(node.members) == 2 && node.members[0].keyValue() == "$binary" && node.members[1].keyValue() == "$type" {
+		// 旧版的 {"$binary": "...", "$type": "00"}
+		if node.members[0].value.kind == nodeString && node.members[1].value.kind == nodeString {
+			return flattenBinary(node.members[0].value.stringValue(), node.members[1].value.stringValue()), true
+		}
+		return nil, false
+	}
+	if len(node.members) != 1 {
+		return nil, false
+	}
+
+	value := node.members[0].value
+	switch key := node.members[0].keyValue(); key {
+	case "$oid":
+		// 不是 24 位十六进制字符串的 ObjectId 无效，保留原样
+		if value.kind == nodeString && objectIDPattern.MatchString(value.stringValue()) {
+			return newStringNode(strings.ToLower(value.stringValue())), true
+		}
+	case "$symbol", "$code", "$uuid":
+		if value.kind == nodeString {
+			return value, true
+		}
+	case "$numberInt", "$numberLong", "$numberDouble", "$numberDecimal":
+		if value.kind == nodeString {
+			if number, ok := newNumberNode(value.stringValue()); ok {
+				return number, true
+			}
+			// NaN、Infinity、-Infinity 无法用JSON表示
+			return newLiteralNode("null"), true
+		}
+	case "$date":
+		// 字符串与毫秒数一样统一为 UTC 的毫秒精度写法，无法识别的日期保留原样
+		if value.kind == nodeString {
+			if t, err := parseMongoDate(value.stringValue()); err == nil {
+				return newStringNode(t.UTC().Format("2006-01-02T15:04:05.000Z")), true
+			}
+			return nil, false
+		}
+		if text, ok := scalarText(value); ok {
+			if ms, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return newStringNode(time.UnixMilli(ms).UTC().Format("2006-01-02T15:04:05.000Z")), true
+			}
+		}
+	case "$timestamp":
+		if value.kind == nodeObject && memberValue(value, "t") != nil && memberValue(value, "i") != nil {
+			return value, true
+		}
+	case "$binary":
+		if value.kind == nodeObject {
+			data, subType := memberValue(value, "base64"), memberValue(value, "subType")
+			if data != nil && subType != nil && data.kind == nodeString && subType.kind == nodeString {
+				return flattenBinary(data.stringValue(), subType.stringValue()), true
+			}
+		}
+	case "$regularExpression":
+		if value.kind == nodeObject {
+			pattern, options := memberValue(value, "pattern"), memberValue(value, "options")
+			if pattern != nil && options != nil && pattern.kind == nodeString && options.kind == nodeString {
+				return newStringNode("/" + pattern.stringValue() + "/" + options.stringValue()), true
+			}
+		}
+	case "$minKey":
+		return newStringNode("MinKey"), true
+	case "$maxKey":
+		return newStringNode("MaxKey"), true
+	case "$undefined":
+		return newLiteralNode("null"), true
+	}
+	return nil, false
+}
+
+// flattenBinary 二进制数据展平为 base64 字符串，UUID 子类型展平为标准的 UUID 字符串
+func flattenBinary(data string, subType string) *jsonNode {
+	if subType == "03" || subType == "04" {
+		if bytes, err := base64.StdEncoding.DecodeString(data); err == nil && len(bytes) == 16 {
+			h := hex.EncodeToString(bytes)
+			return newStringNode(h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:])
+		}
+	}
+	return newStringNode(data)
+}
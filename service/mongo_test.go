@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMongoShellToExtendedJSON(t *testing.T) {
+	ctx := context.Background()
+	input := `{ _id: ObjectId("507F1F77BCF86CD799439011"), n: 1, big: NumberLong(9007199254740993), i: NumberInt("5"), d: 1.5, dec: NumberDecimal("1.10"), at: ISODate("2024-01-01T08:00:00+08:00"), old: new Date(-1000), ts: Timestamp(1700000000, 1), bin: BinData(0, "AQID"), uuid: UUID("0123456789abcdef0123456789abcdef"), re: /^a\/b[/]$/mi, min: MinKey, nan: NaN, u: undefined, 'tags': ['a',], }`
+
+	tests := []struct {
+		name     string
+		mode     MongoMode
+		expected string
+	}{
+		{
+			name:     "Canonical",
+			mode:     MongoCanonical,
+			expected: `{"_id":{"$oid":"507f1f77bcf86cd799439011"},"n":{"$numberInt":"1"},"big":{"$numberLong":"9007199254740993"},"i":{"$numberInt":"5"},"d":{"$numberDouble":"1.5"},"dec":{"$numberDecimal":"1.10"},"at":{"$date":{"$numberLong":"1704067200000"}},"old":{"$date":{"$numberLong":"-1000"}},"ts":{"$timestamp":{"t":1700000000,"i":1}},"bin":{"$binary":{"base64":"AQID","subType":"00"}},"uuid":{"$binary":{"base64":"ASNFZ4mrze8BI0VniavN7w==","subType":"04"}},"re":{"$regularExpression":{"pattern":"^a\\/b[/]$","options":"im"}},"min":{"$minKey":1},"nan":{"$numberDouble":"NaN"},"u":{"$undefined":true},"tags":["a"]}`,
+		},
+		{
+			name:     "Relaxed",
+			mode:     MongoRelaxed,
+			expected: `{"_id":{"$oid":"507f1f77bcf86cd799439011"},"n":1,"big":9007199254740993,"i":5,"d":1.5,"dec":{"$numberDecimal":"1.10"},"at":{"$date":"2024-01-01T00:00:00.000Z"},"old":{"$date":{"$numberLong":"-1000"}},"ts":{"$timestamp":{"t":1700000000,"i":1}},"bin":{"$binary":{"base64":"AQID","subType":"00"}},"uuid":{"$binary":{"base64":"ASNFZ4mrze8BI0VniavN7w==","subType":"04"}},"re":{"$regularExpression":{"pattern":"^a\\/b[/]$","options":"im"}},"min":{"$minKey":1},"nan":{"$numberDouble":"NaN"},"u":{"$undefined":true},"tags":["a"]}`,
+		},
+		{
+			name:     "展平",
+			mode:     MongoFlatten,
+			expected: `{"_id":"507f1f77bcf86cd799439011","n":1,"big":9007199254740993,"i":5,"d":1.5,"dec":1.10,"at":"2024-01-01T00:00:00.000Z","old":"1969-12-31T23:59:59.000Z","ts":{"t":1700000000,"i":1},"bin":"AQID","uuid":"01234567-89ab-cdef-0123-456789abcdef","re":"/^a\\/b[/]$/im","min":"MinKey","nan":null,"u":null,"tags":["a"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.ProcessJSONWithOptions(ctx, input, ProcessOptions{Mongo: tt.mode, QuoteRules: []QuoteRule{}})
+			if err != nil {
+				t.Fatalf("ProcessJSONWithOptions() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ProcessJSONWithOptions() =\n%s\nwant:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeExtendedJSON(t *testing.T) {
+	ctx := context.Background()
+	input := `{"n": {"$numberInt": "1"}, "big": {"$numberLong": "9007199254740993"}, "d": {"$numberDouble": "1.5"}, "nan": {"$numberDouble": "NaN"}, "dec": {"$numberDecimal": "1.10"}, "at": {"$date": "2024-01-01T08:00:00+08:00"}, "ms": {"$date": {"$numberLong": "1704067200000"}}, "ts": {"$timestamp": {"t": 1700000000, "i": 1}}, "bad": {"$numberLong": "x"}}`
+
+	tests := []struct {
+		name     string
+		relaxed  bool
+		expected string
+	}{
+		{
+			name:     "Canonical",
+			expected: `{"n":{"$numberInt":"1"},"big":{"$numberLong":"9007199254740993"},"d":{"$numberDouble":"1.5"},"nan":{"$numberDouble":"NaN"},"dec":{"$numberDecimal":"1.10"},"at":{"$date":{"$numberLong":"1704067200000"}},"ms":{"$date":{"$numberLong":"1704067200000"}},"ts":{"$timestamp":{"t":1700000000,"i":1}},"bad":{"$numberLong":"x"}}`,
+		},
+		{
+			name:     "Relaxed",
+			relaxed:  true,
+			expected: `{"n":1,"big":9007199254740993,"d":1.5,"nan":{"$numberDouble":"NaN"},"dec":{"$numberDecimal":"1.10"},"at":{"$date":"2024-01-01T00:00:00.000Z"},"ms":{"$date":"2024-01-01T00:00:00.000Z"},"ts":{"$timestamp":{"t":1700000000,"i":1}},"bad":{"$numberLong":"x"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.MongoShellToExtendedJSON(ctx, input, tt.relaxed, FormatOptions{})
+			if err != nil {
+				t.Fatalf("MongoShellToExtendedJSON() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("MongoShellToExtendedJSON() =\n%s\nwant:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenExtendedJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Canonical Extended JSON",
+			input:    `{"_id": {"$oid": "507f1f77bcf86cd799439011"}, "at": {"$date": {"$numberLong": "1704067200000"}}, "n": {"$numberLong": "42"}, "inf": {"$numberDouble": "-Infinity"}}`,
+			expected: `{"_id":"507f1f77bcf86cd799439011","at":"2024-01-01T00:00:00.000Z","n":42,"inf":null}`,
+		},
+		{
+			name:     "旧版二进制和嵌套",
+			input:    `[{"$binary": "AQID", "$type": "00"}, {"ref": {"$ref": "users", "$id": {"$oid": "507f1f77bcf86cd799439011"}}}]`,
+			expected: `["AQID",{"ref":{"$ref":"users","$id":"507f1f77bcf86cd799439011"}}]`,
+		},
+		{
+			name:     "日期字符串统一为毫秒精度的UTC写法，无效的ObjectId保留原样",
+			input:    `{"a": {"$date": "2024-01-01T08:00:00+08:00"}, "b": ISODate("2024-01-01"), "c": {"$oid": "507F1F77BCF86CD799439011"}, "d": {"$oid": "123"}, "e": {"$date": "昨天"}}`,
+			expected: `{"a":"2024-01-01T00:00:00.000Z","b":"2024-01-01T00:00:00.000Z","c":"507f1f77bcf86cd799439011","d":{"$oid":"123"},"e":{"$date":"昨天"}}`,
+		},
+		{
+			name:     "查询操作符保持不变",
+			input:    `{"name": {"$regex": "^a", "$options": "i"}, "age": {"$gt": 18}}`,
+			expected: `{"name":{"$regex":"^a","$options":"i"},"age":{"$gt":18}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.FlattenExtendedJSON(ctx, tt.input, FormatOptions{})
+			if err != nil {
+				t.Fatalf("FlattenExtendedJSON() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("FlattenExtendedJSON() =\n%s\nwant:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMongoShellInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "ObjectId长度错误", input: `{_id: ObjectId("123")}`},
+		{name: "无法识别的日期", input: `{at: ISODate("yesterday")}`},
+		{name: "NumberInt超出范围", input: `NumberInt(3000000000)`},
+		{name: "未知的构造函数", input: `{a: Foo(1)}`},
+		{name: "正则未结束", input: `{re: /abc}`},
+		{name: "RegExp缺少参数", input: `RegExp()`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := JSONProcessorService.MongoShellToExtendedJSON(ctx, tt.input, false, FormatOptions{}); err == nil {
+				t.Error("MongoShellToExtendedJSON() expected error")
+			}
+		})
+	}
+
+	if _, err := ParseMongoMode("bson"); err == nil {
+		t.Error("ParseMongoMode() expected error for unknown mode")
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sojson/zlog"
+)
+
+// InputMode 输入的组织方式
+type InputMode string
+
+const (
+	// ModeDocument 整个输入是一个文档
+	ModeDocument InputMode = "document"
+	// ModeNDJSON 每行一个JSON值（NDJSON / JSON Lines），逐行处理后仍按行输出
+	ModeNDJSON InputMode = "ndjson"
+	// ModeNDJSONToArray 逐行处理 NDJSON，结果合并为一个JSON数组
+	ModeNDJSONToArray InputMode = "ndjson_to_array"
+	// ModeArrayToNDJSON 把JSON数组的每个元素逐个处理，按行输出为 NDJSON
+	ModeArrayToNDJSON InputMode = "array_to_ndjson"
+)
+
+// inputModeAliases 输入方式的别名
+var inputModeAliases = map[string]InputMode{
+	"":                ModeDocument,
+	"document":        ModeDocument,
+	"ndjson":          ModeNDJSON,
+	"jsonl":           ModeNDJSON,
+	"ndjson_to_array": ModeNDJSONToArray,
+	"array_to_ndjson": ModeArrayToNDJSON,
+}
+
+// ParseInputMode 解析输入方式名称，空字符串表示整个输入是一个文档
+func ParseInputMode(name string) (InputMode, error) {
+	mode, ok := inputModeAliases[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("不支持的处理模式: %s，可选 document、ndjson、ndjson_to_array、array_to_ndjson", name)
+	}
+	return mode, nil
+}
+
+// LineFunc 处理一行，opts 为压缩到一行输出的格式化选项
+type LineFunc func(line string, opts FormatOptions) (string, error)
+
+// LineError NDJSON 中某一行的错误
+type LineError struct {
+	Position
+	Err error // 该行的错误，其中的位置已换算为整个输入中的位置
+}
+
+func (e *LineError) Error() string {
+	var posErr PositionError
+	if errors.As(e.Err, &posErr) {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("第 %d 行: %v", e.Line, e.Err)
+}
+
+// Pos 返回错误位置
+func (e *LineError) Pos() Position {
+	return e.Position
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// LinesResult 按行处理的结果
+type LinesResult struct {
+	Result string       // 处理成功的行，按 mode 输出为 NDJSON 或JSON数组
+	Lines  int          // 参与处理的行数（不含空行）
+	Errors []*LineError // 处理失败的行，按行号排列
+}
+
+// ProcessLines 按 mode 逐行调用 fn：失败的行记录在 Errors 中并从结果里去掉，其余行照常输出
+func (s *jsonProcessorService) ProcessLines(ctx context.Context, text string, mode InputMode, opts FormatOptions, fn LineFunc) (*LinesResult, error) {
+	lineOpts := lineFormatOptions(opts)
+
+	type line struct {
+		text   string
+		offset int // 该行在输入中的字节偏移
+		shift  bool
+	}
+	var lines []line
+
+	switch mode {
+	case ModeNDJSON, ModeNDJSONToArray:
+		offset := 0
+		for _, raw := range strings.SplitAfter(text, "\n") {
+			if content := strings.TrimRight(raw, "\r\n"); strings.TrimSpace(content) != "" {
+				lines = append(lines, line{text: content, offset: offset, shift: true})
+			}
+			offset += len(raw)
+		}
+	case ModeArrayToNDJSON:
+		tree, err := parseDialectTree(text, opts.Dialect)
+		if err != nil {
+			zlog.Errorf(ctx, "ProcessLines: parseDialectTree failed, input text length: %d, error: %v", len(text), err)
+			return nil, err
+		}
+		if tree.kind != nodeArray {
+			return nil, fmt.Errorf("输入不是JSON数组")
+		}
+		// 元素先按输入的方言压缩为一行，再交给 fn 处理
+		elemOpts := lineOpts
+		elemOpts.TargetDialect = opts.Dialect
+		for _, elem := range tree.elements {
+			lines = append(lines, line{text: formatJSONTree(elem, elemOpts), offset: elem.offset})
+		}
+	default:
+		return nil, fmt.Errorf("不支持的处理模式: %s", mode)
+	}
+	if len(lines) == 0 && mode != ModeArrayToNDJSON {
+		return nil, fmt.Errorf("输入中没有非空行")
+	}
+
+	result := &LinesResult{Lines: len(lines)}
+	var outputs []string
+	for _, l := range lines {
+		output, err := fn(l.text, lineOpts)
+		if err == nil && mode == ModeNDJSONToArray {
+			// 合并为数组前确认每行的结果是一个JSON值
+			_, err = parseDialectTree(output, opts.target())
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, newLineError(text, l.offset, l.shift, err))
+			continue
+		}
+		outputs = append(outputs, output)
+	}
+
+	if mode == ModeNDJSONToArray {
+		arr := &jsonNode{kind: nodeArray}
+		for _, output := range outputs {
+			elem, _ := parseDialectTree(output, opts.target())
+			arr.elements = append(arr.elements, elem)
+		}
+		arrOpts := opts
+		arrOpts.Dialect, arrOpts.TargetDialect = opts.target(), ""
+		result.Result = formatJSONTree(arr, arrOpts)
+	} else {
+		result.Result = joinLines(outputs, opts)
+	}
+
+	zlog.Infof(ctx, "ProcessLines: processed lines, mode: %s, lines: %d, failed: %d, output length: %d", mode, result.Lines, len(result.Errors), len(result.Result))
+	return result, nil
+}
+
+// lineFormatOptions 返回把结果压缩到一行之内的格式化选项
+func lineFormatOptions(opts FormatOptions) FormatOptions {
+	opts.Indent, opts.UseTabs, opts.LineWidth = 0, false, 0
+	opts.TrailingNewline, opts.CRLF = false, false
+	return opts
+}
+
+// newlineOf 返回 opts 指定的换行符
+func newlineOf(opts FormatOptions) string {
+	if opts.CRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// joinLines 按 opts 的换行符把各行连接为 NDJSON
+func joinLines(lines []string, opts FormatOptions) string {
+	result := strings.Join(lines, newlineOf(opts))
+	if opts.TrailingNewline && len(lines) > 0 {
+		result += newlineOf(opts)
+	}
+	return result
+}
+
+// newLineError 生成某一行的错误；shift 为 true 时把行内的错误位置换算为整个输入中的位置
+func newLineError(text string, offset int, shift bool, err error) *LineError {
+	lineErr := &LineError{Position: newPosition(text, offset), Err: err}
+	if !shift {
+		return lineErr
+	}
+
+	end := strings.IndexByte(text[offset:], '\n')
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += offset
+	}
+	at := func(pos Position) Position {
+		return newPosition(text, min(offset+pos.Offset, end))
+	}
+
+	var syntaxErr *SyntaxError
+	var unescapeErr *UnescapeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		shifted := *syntaxErr
+		shifted.Position = at(syntaxErr.Position)
+		lineErr.Err, lineErr.Position = &shifted, shifted.Position
+	case errors.As(err, &unescapeErr):
+		shifted := *unescapeErr
+		shifted.Position = at(unescapeErr.Position)
+		lineErr.Err, lineErr.Position = &shifted, shifted.Position
+	}
+	return lineErr
+}
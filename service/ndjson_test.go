@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProcessLines(t *testing.T) {
+	ctx := context.Background()
+	format := func(line string, opts FormatOptions) (string, error) {
+		return JSONProcessorService.FormatJSONWithOptions(ctx, line, opts)
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		mode       InputMode
+		opts       FormatOptions
+		expected   string
+		lines      int
+		errorLines []int
+	}{
+		{
+			name:     "逐行压缩并跳过空行",
+			input:    "{\"a\": 1}\n\n  {\"b\": [1, 2]}\r\n",
+			mode:     ModeNDJSON,
+			opts:     FormatOptions{Indent: 2},
+			expected: "{\"a\":1}\n{\"b\":[1,2]}",
+			lines:    2,
+		},
+		{
+			name:       "保留正确的行",
+			input:      "{\"a\":1}\n{bad}\n[2]\n{\"c\":}",
+			mode:       ModeNDJSON,
+			opts:       FormatOptions{TrailingNewline: true},
+			expected:   "{\"a\":1}\n[2]\n",
+			lines:      4,
+			errorLines: []int{2, 4},
+		},
+		{
+			name:     "NDJSON转换为数组",
+			input:    "{\"a\":1}\n[2]",
+			mode:     ModeNDJSONToArray,
+			opts:     FormatOptions{Indent: 2},
+			expected: "[\n  {\n    \"a\": 1\n  },\n  [\n    2\n  ]\n]",
+			lines:    2,
+		},
+		{
+			name:     "数组转换为NDJSON",
+			input:    "[\n  {\"a\": 1},\n  [2, 3]\n]",
+			mode:     ModeArrayToNDJSON,
+			opts:     FormatOptions{Indent: 4, CRLF: true},
+			expected: "{\"a\":1}\r\n[2,3]",
+			lines:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.ProcessLines(ctx, tt.input, tt.mode, tt.opts, format)
+			if err != nil {
+				t.Fatalf("ProcessLines() unexpected error = %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("ProcessLines() = %q, want %q", result.Result, tt.expected)
+			}
+			if result.Lines != tt.lines {
+				t.Errorf("ProcessLines() lines = %d, want %d", result.Lines, tt.lines)
+			}
+			if len(result.Errors) != len(tt.errorLines) {
+				t.Fatalf("ProcessLines() errors = %v, want lines %v", result.Errors, tt.errorLines)
+			}
+			for i, line := range tt.errorLines {
+				if result.Errors[i].Line != line {
+					t.Errorf("ProcessLines() error line = %d, want %d", result.Errors[i].Line, line)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessLinesErrorPosition(t *testing.T) {
+	ctx := context.Background()
+	input := "{\"a\":1}\n{\"b\":tru}"
+
+	result, err := JSONProcessorService.ProcessLines(ctx, input, ModeNDJSON, FormatOptions{}, func(line string, opts FormatOptions) (string, error) {
+		return line, JSONProcessorService.ValidateJSON(line)
+	})
+	if err != nil {
+		t.Fatalf("ProcessLines() unexpected error = %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("ProcessLines() errors = %d, want 1", len(result.Errors))
+	}
+
+	pos := result.Errors[0].Pos()
+	if pos.Line != 2 || pos.Column != 6 || pos.Offset != 13 {
+		t.Errorf("ProcessLines() position = %+v, want line 2 column 6 offset 13", pos)
+	}
+	if got, want := result.Errors[0].Error(), "第 2 行第 6 列语法错误: 期望 true，遇到 't'"; got != want {
+		t.Errorf("ProcessLines() error = %s, want %s", got, want)
+	}
+}
+
+func TestProcessLinesInvalid(t *testing.T) {
+	ctx := context.Background()
+	identity := func(line string, opts FormatOptions) (string, error) { return line, nil }
+
+	if _, err := JSONProcessorService.ProcessLines(ctx, `{"a":1}`, ModeArrayToNDJSON, FormatOptions{}, identity); err == nil {
+		t.Error("ProcessLines() expected error for non-array input")
+	}
+	if _, err := JSONProcessorService.ProcessLines(ctx, "\n \n", ModeNDJSON, FormatOptions{}, identity); err == nil {
+		t.Error("ProcessLines() expected error for empty input")
+	}
+	if _, err := ParseInputMode("csv"); err == nil {
+		t.Error("ParseInputMode() expected error for unknown mode")
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// QuoteRule 裸值加引号规则：键匹配 Key 且值匹配 Value 时，为未加引号的值加上引号
+type QuoteRule struct {
+	Name  string `json:"name"`          // 规则名称
+	Key   string `json:"key,omitempty"` // 键的正则表达式，为空时匹配任意键
+	Value string `json:"value"`         // 内置值类型名称，或匹配整个值的正则表达式
+}
+
+// 内置值类型
+var builtinValueMatchers = map[string]func(string) bool{
+	// RFC3339 时间，支持纳秒和时区偏移，如 2025-08-18T08:04:19.827+08:00
+	"rfc3339": regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt]\d{2}:\d{2}:\d{2}(\.\d{1,9})?([Zz]|[+-]\d{2}:?\d{2})?$`).MatchString,
+	// 空格分隔的日期时间，如 2025-08-18 08:04:19.827
+	"datetime": regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}(:\d{2}(\.\d{1,9})?)?( ?([Zz]|[+-]\d{2}:?\d{2}|UTC))?$`).MatchString,
+	// 日期，如 2025-08-18
+	"date": regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`).MatchString,
+	// UUID
+	"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+	// IPv4 或 IPv6 地址
+	"ip": func(value string) bool { return net.ParseIP(value) != nil },
+	// 十六进制哈希，如 git 提交号、MD5、SHA 摘要
+	"hex": regexp.MustCompile(`^(0[xX])?[0-9a-fA-F]{7,}$`).MatchString,
+	// 不含空白的单词，如枚举值、标识符
+	"word": regexp.MustCompile(`^[A-Za-z_][\w.\-/]*$`).MatchString,
+}
+
+// DefaultQuoteRules 内置的默认规则
+var DefaultQuoteRules = []QuoteRule{
+	{Name: "rfc3339", Value: "rfc3339"},
+	{Name: "datetime", Value: "datetime"},
+	{Name: "date", Value: "date"},
+	{Name: "uuid", Value: "uuid"},
+	{Name: "ip", Value: "ip"},
+	{Name: "hex", Value: "hex"},
+}
+
+var (
+	defaultRulesMu  sync.RWMutex
+	defaultCompiled = mustCompileQuoteRules(DefaultQuoteRules)
+)
+
+// compiledQuoteRule 编译后的规则
+type compiledQuoteRule struct {
+	name  string
+	key   *regexp.Regexp
+	match func(string) bool
+}
+
+// compileQuoteRules 校验并编译规则
+func compileQuoteRules(rules []QuoteRule) ([]compiledQuoteRule, error) {
+	compiled := make([]compiledQuoteRule, 0, len(rules))
+	for i, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		c := compiledQuoteRule{name: name}
+		if rule.Key != "" {
+			re, err := regexp.Compile(rule.Key)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %s 的键正则无效: %v", name, err)
+			}
+			c.key = re
+		}
+
+		if rule.Value == "" {
+			return nil, fmt.Errorf("规则 %s 缺少值类型", name)
+		}
+		if match, ok := builtinValueMatchers[rule.Value]; ok {
+			c.match = match
+		} else {
+			re, err := regexp.Compile(`^(?:` + rule.Value + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %s 的值正则无效: %v", name, err)
+			}
+			c.match = re.MatchString
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func mustCompileQuoteRules(rules []QuoteRule) []compiledQuoteRule {
+	compiled, err := compileQuoteRules(rules)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// SetDefaultQuoteRules 替换未指定规则时使用的默认规则，通常来自服务器配置文件
+func (s *jsonProcessorService) SetDefaultQuoteRules(rules []QuoteRule) error {
+	compiled, err := compileQuoteRules(rules)
+	if err != nil {
+		return err
+	}
+
+	defaultRulesMu.Lock()
+	defaultCompiled = compiled
+	defaultRulesMu.Unlock()
+	return nil
+}
+
+// QuoteBareValues 按规则为未加引号的裸值加上引号，rules 为 nil 时使用默认规则
+// 例如 "time":2025-08-18T08:04:19.827Z 修复为 "time":"2025-08-18T08:04:19.827Z"
+func (s *jsonProcessorService) QuoteBareValues(text string, rules []QuoteRule) (string, error) {
+	return s.QuoteBareValuesWithDialect(text, rules, DialectJSON)
+}
+
+// QuoteBareValuesWithDialect 按规则为裸值加引号，方言中合法的数字和字面量（如 JSON5 的 0xDEADBEEF、Infinity）保持不变
+func (s *jsonProcessorService) QuoteBareValuesWithDialect(text string, rules []QuoteRule, dialect Dialect) (string, error) {
+	fixed, _, err := s.quoteValues(text, rules, dialect)
+	return fixed, err
+}
+
+// quoteValues 为裸值加引号，同时返回结果中的偏移到输入中偏移的映射
+func (s *jsonProcessorService) quoteValues(text string, rules []QuoteRule, dialect Dialect) (string, offsetMap, error) {
+	if err := dialect.check(); err != nil {
+		return "", nil, err
+	}
+
+	var compiled []compiledQuoteRule
+	if rules == nil {
+		defaultRulesMu.RLock()
+		compiled = defaultCompiled
+		defaultRulesMu.RUnlock()
+	} else {
+		var err error
+		if compiled, err = compileQuoteRules(rules); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if len(compiled) == 0 {
+		return text, nil, nil
+	}
+	fixed, offsets := quoteBareValues(text, compiled, dialect)
+	return fixed, offsets, nil
+}
+
+// quoteBareValues 扫描文本，找到“键: 裸值”并按规则加引号，字符串内部的内容不受影响
+func quoteBareValues(text string, rules []compiledQuoteRule, dialect Dialect) (string, offsetMap) {
+	var sb strings.Builder
+	var offsets offsetMap
+	last := 0
+
+	for i := 0; i < len(text); {
+		if text[i] != '"' {
+			i++
+			continue
+		}
+
+		// 读取一个字符串
+		start := i
+		i = skipStringLiteral(text, i)
+		end := i
+
+		// 字符串后紧跟冒号时视为键
+		j := skipSpaces(text, i)
+		if j >= len(text) || text[j] != ':' {
+			continue
+		}
+		j = skipSpaces(text, j+1)
+		if j >= len(text) || strings.IndexByte(`"{[`, text[j]) >= 0 {
+			continue
+		}
+
+		// 读取裸值，到逗号、右括号或换行为止
+		valueEnd := j
+		for valueEnd < len(text) && strings.IndexByte(",}]\r\n", text[valueEnd]) < 0 {
+			valueEnd++
+		}
+		value := strings.TrimRight(text[j:valueEnd], " \t")
+		if value == "" || isDialectScalar(value, dialect) {
+			continue
+		}
+
+		key, err := decodeJSONString(text[start+1:end-1], 0)
+		if err != nil {
+			continue
+		}
+		if !matchQuoteRule(rules, key, value) {
+			continue
+		}
+
+		sb.WriteString(text[last:j])
+		quoted := encodeJSONString(value, false, false)
+		offsets.add(sb.Len(), j)
+		offsets.add(sb.Len()+1, j)
+		sb.WriteString(quoted)
+		offsets.add(sb.Len()-1, j+len(value))
+		offsets.add(sb.Len(), j+len(value))
+		last = j + len(value)
+		i = last
+	}
+
+	if last == 0 {
+		return text, nil
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), offsets
+}
+
+// matchQuoteRule 是否有规则匹配该键值
+func matchQuoteRule(rules []compiledQuoteRule, key string, value string) bool {
+	for _, rule := range rules {
+		if rule.key != nil && !rule.key.MatchString(key) {
+			continue
+		}
+		if rule.match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSONScalar 是否已经是合法的JSON数字或字面量
+func isJSONScalar(value string) bool {
+	switch value {
+	case "true", "false", "null":
+		return true
+	}
+	node, err := parseJSONTree(value)
+	return err == nil && node.kind == nodeNumber
+}
+
+// isDialectScalar 是否已经是方言中合法的数字或字面量
+func isDialectScalar(value string, dialect Dialect) bool {
+	if dialect != DialectJSON5 {
+		return isJSONScalar(value)
+	}
+	node, err := parseDialectTree(value, dialect)
+	return err == nil && (node.kind == nodeNumber || node.kind == nodeLiteral)
+}
+
+// skipStringLiteral 跳过从 text[i] 开始的字符串，返回结束引号之后的位置
+func skipStringLiteral(text string, i int) int {
+	for i++; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// skipSpaces 跳过空白
+func skipSpaces(text string, i int) int {
+	for i < len(text) && strings.IndexByte(" \t\r\n", text[i]) >= 0 {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestQuoteBareValuesDefaultRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "单个time字段",
+			input:    `{"level":30,"time":2025-08-18T08:04:19.827Z}`,
+			expected: `{"level":30,"time":"2025-08-18T08:04:19.827Z"}`,
+		},
+		{
+			name:     "time字段在中间",
+			input:    `{"level":30,"time":2025-08-18T08:04:19.827Z,"msg":"test"}`,
+			expected: `{"level":30,"time":"2025-08-18T08:04:19.827Z","msg":"test"}`,
+		},
+		{
+			name:     "多个时间字段",
+			input:    `{"created_at":2025-08-18T08:04:19.827Z,"updated_at":2025-08-18T09:04:19.827Z}`,
+			expected: `{"created_at":"2025-08-18T08:04:19.827Z","updated_at":"2025-08-18T09:04:19.827Z"}`,
+		},
+		{
+			name:     "timestamp字段",
+			input:    `{"level":30,"timestamp":2025-08-18T08:04:19Z,"msg":"test"}`,
+			expected: `{"level":30,"timestamp":"2025-08-18T08:04:19Z","msg":"test"}`,
+		},
+		{
+			name:     "已经有引号的时间字段（不应该改变）",
+			input:    `{"level":30,"time":"2025-08-18T08:04:19.827Z","msg":"test"}`,
+			expected: `{"level":30,"time":"2025-08-18T08:04:19.827Z","msg":"test"}`,
+		},
+		{
+			name:     "没有时间字段（不应该改变）",
+			input:    `{"level":30,"msg":"test message"}`,
+			expected: `{"level":30,"msg":"test message"}`,
+		},
+		{
+			name:     "任意字段名的RFC3339时间（带时区偏移和纳秒）",
+			input:    `{"ts":2025-08-18T08:04:19.123456789+08:00,"at":2025-08-18T08:04:19-0700}`,
+			expected: `{"ts":"2025-08-18T08:04:19.123456789+08:00","at":"2025-08-18T08:04:19-0700"}`,
+		},
+		{
+			name:     "空格分隔的日期时间",
+			input:    "{\"start\": 2025-08-18 08:04:19.827 , \"day\": 2025-08-18\n}",
+			expected: "{\"start\": \"2025-08-18 08:04:19.827\" , \"day\": \"2025-08-18\"\n}",
+		},
+		{
+			name:     "UUID、IP和十六进制哈希",
+			input:    `{"id":6ba7b810-9dad-11d1-80b4-00c04fd430c8,"ip":10.0.0.1,"v6":[1],"addr":fe80::1,"commit":9fceb02d0ae598e95dc970b74767f19372d61af8}`,
+			expected: `{"id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","ip":"10.0.0.1","v6":[1],"addr":"fe80::1","commit":"9fceb02d0ae598e95dc970b74767f19372d61af8"}`,
+		},
+		{
+			name:     "数字和字面量保持不变",
+			input:    `{"n":1234567,"f":1.5e3,"b":true,"z":null}`,
+			expected: `{"n":1234567,"f":1.5e3,"b":true,"z":null}`,
+		},
+		{
+			name:     "字符串内部的内容保持不变",
+			input:    `{"msg":"\"time\":2025-08-18T08:04:19Z"}`,
+			expected: `{"msg":"\"time\":2025-08-18T08:04:19Z"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.QuoteBareValues(tt.input, nil)
+			if err != nil {
+				t.Fatalf("QuoteBareValues() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("QuoteBareValues() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuoteBareValuesCustomRules(t *testing.T) {
+	rules := []QuoteRule{
+		{Name: "level", Key: `^level$`, Value: "word"},
+		{Name: "version", Key: `version`, Value: `v\d+(\.\d+)*`},
+	}
+	input := `{"level":warn,"status":ok,"app_version":v1.2.3,"time":2025-08-18T08:04:19Z}`
+	expected := `{"level":"warn","status":ok,"app_version":"v1.2.3","time":2025-08-18T08:04:19Z}`
+
+	result, err := JSONProcessorService.QuoteBareValues(input, rules)
+	if err != nil {
+		t.Fatalf("QuoteBareValues() unexpected error = %v", err)
+	}
+	if result != expected {
+		t.Errorf("QuoteBareValues() = %v, want %v", result, expected)
+	}
+
+	// 空规则列表不做任何修改
+	result, err = JSONProcessorService.QuoteBareValues(input, []QuoteRule{})
+	if err != nil || result != input {
+		t.Errorf("QuoteBareValues() with empty rules = %v, %v, want input unchanged", result, err)
+	}
+}
+
+func TestQuoteBareValuesInvalidRules(t *testing.T) {
+	invalid := [][]QuoteRule{
+		{{Name: "bad key", Key: `(`, Value: "uuid"}},
+		{{Name: "bad value", Value: `[`}},
+		{{Name: "missing value"}},
+	}
+	for _, rules := range invalid {
+		if _, err := JSONProcessorService.QuoteBareValues(`{}`, rules); err == nil {
+			t.Errorf("QuoteBareValues() with rules %+v expected error but got none", rules)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"sojson/zlog"
+)
+
+// schemaDraft 生成的 JSON Schema 版本
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// emailPattern 常见的邮箱地址，不追求完整覆盖 RFC 5322
+var emailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@[A-Za-z0-9](?:[A-Za-z0-9\-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9\-]*[A-Za-z0-9])?)+$`)
+
+// schemaFormats 按顺序检测的字符串格式，名称为 JSON Schema 的 format
+var schemaFormats = []struct {
+	name  string
+	match func(string) bool
+}{
+	{"date-time", func(value string) bool {
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	}},
+	{"date", func(value string) bool {
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	}},
+	{"uuid", builtinValueMatchers["uuid"]},
+	{"email", emailPattern.MatchString},
+	{"ipv4", func(value string) bool {
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	}},
+	{"ipv6", func(value string) bool {
+		return strings.Contains(value, ":") && net.ParseIP(value) != nil
+	}},
+}
+
+// schemaTypes 各类型在 JSON Schema 中的 type
+var schemaTypes = map[typeKind]string{
+	kindNull:   "null",
+	kindBool:   "boolean",
+	kindInt:    "integer",
+	kindFloat:  "number",
+	kindNumber: "integer",
+	kindString: "string",
+	kindObject: "object",
+	kindArray:  "array",
+}
+
+// SchemaOptions JSON Schema 推断选项
+type SchemaOptions struct {
+	FormatOptions
+	Mode      InputMode // 为 ndjson 时每行是一个样本，为 array_to_ndjson 时顶层数组的每个元素是一个样本
+	EnumLimit int       // 字符串取值不超过该数量且有重复时生成 enum，0 表示默认的 5，负数表示不生成
+	Strict    bool      // 对象加上 "additionalProperties": false，不允许样本以外的属性
+}
+
+// stringFormat 字符串符合的格式，都不符合时返回空字符串
+func stringFormat(value string) string {
+	for _, f := range schemaFormats {
+		if f.match(value) {
+			return f.name
+		}
+	}
+	return ""
+}
+
+// InferSchema 从一个或多个JSON样本推断 JSON Schema（draft 2020-12）：所有样本中都出现的属性为 required，
+// 取值少且重复出现的字符串生成 enum，所有取值都符合同一格式的字符串加上 format，数字记录样本中的取值范围
+func (s *jsonProcessorService) InferSchema(ctx context.Context, text string, opts SchemaOptions) (*ConversionResult, error) {
+	root, notes, err := inferTypes(text, CodegenOptions{Dialect: opts.Dialect, Mode: opts.Mode, EnumLimit: opts.EnumLimit}, true)
+	if err != nil {
+		zlog.Errorf(ctx, "InferSchema: inferTypes failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+
+	g := &schemaGenerator{strict: opts.Strict}
+	schema := &jsonNode{kind: nodeObject}
+	appendMember(schema, "$schema", newStringNode(schemaDraft))
+	schema.members = append(schema.members, g.schema(root).members...)
+
+	result := formatJSONTree(schema, opts.FormatOptions)
+	zlog.Infof(ctx, "InferSchema: successfully inferred, input length: %d, output length: %d", len(text), len(result))
+	return &ConversionResult{Result: result, Notes: notes}, nil
+}
+
+// schemaGenerator 把推断出的类型输出为 JSON Schema
+type schemaGenerator struct {
+	strict bool
+}
+
+// schema 类型对应的 schema，出现过 null 的类型允许 null
+func (g *schemaGenerator) schema(t *typeNode) *jsonNode {
+	obj := &jsonNode{kind: nodeObject}
+	if t == nil {
+		// 只出现过空数组的元素，不限制类型
+		return obj
+	}
+
+	if t.kind == kindMixed {
+		anyOf := &jsonNode{kind: nodeArray}
+		for _, v := range t.variants {
+			anyOf.elements = append(anyOf.elements, g.schema(v))
+		}
+		if t.nullable {
+			null := &jsonNode{kind: nodeObject}
+			appendMember(null, "type", newStringNode("null"))
+			anyOf.elements = append(anyOf.elements, null)
+		}
+		appendMember(obj, "anyOf", anyOf)
+		return obj
+	}
+
+	typ := newStringNode(schemaTypes[t.kind])
+	if t.nullable && t.kind != kindNull {
+		typ = &jsonNode{kind: nodeArray, elements: []*jsonNode{typ, newStringNode("null")}}
+	}
+	appendMember(obj, "type", typ)
+
+	switch t.kind {
+	case kindString:
+		if values := t.enum(); values != nil {
+			enum := &jsonNode{kind: nodeArray}
+			for _, v := range values {
+				enum.elements = append(enum.elements, newStringNode(v))
+			}
+			if t.nullable {
+				// enum 与 type 同时生效，需要列出 null
+				enum.elements = append(enum.elements, newLiteralNode("null"))
+			}
+			appendMember(obj, "enum", enum)
+		} else if t.format != "" {
+			appendMember(obj, "format", newStringNode(t.format))
+		}
+	case kindInt, kindFloat, kindNumber:
+		if t.minimum != "" {
+			appendMember(obj, "minimum", &jsonNode{kind: nodeNumber, raw: t.minimum})
+			appendMember(obj, "maximum", &jsonNode{kind: nodeNumber, raw: t.maximum})
+		}
+	case kindArray:
+		if t.elem != nil {
+			appendMember(obj, "items", g.schema(t.elem))
+		}
+	case kindObject:
+		g.properties(obj, t)
+	}
+	return obj
+}
+
+// properties 对象的属性，所有对象中都出现的属性为 required
+func (g *schemaGenerator) properties(obj *jsonNode, t *typeNode) {
+	if len(t.fields) == 0 {
+		return
+	}
+
+	properties := &jsonNode{kind: nodeObject}
+	required := &jsonNode{kind: nodeArray}
+	for _, f := range t.fields {
+		appendMember(properties, f.key, g.schema(f.typ))
+		if !t.optional(f) {
+			required.elements = append(required.elements, newStringNode(f.key))
+		}
+	}
+	appendMember(obj, "properties", properties)
+	if len(required.elements) > 0 {
+		appendMember(obj, "required", required)
+	}
+	if g.strict {
+		appendMember(obj, "additionalProperties", newLiteralNode("false"))
+	}
+}
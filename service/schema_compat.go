@@ -0,0 +1,598 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxCompatDepth 比较 schema 时的最大嵌套深度，超过时不再深入，避免 $ref 互相引用时无限展开
+const maxCompatDepth = 64
+
+// schemaAnnotations 不影响校验结果的关键字
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$anchor": true, "$dynamicAnchor": true, "$comment": true, "$defs": true, "definitions": true,
+	"title": true, "description": true, "default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// schemaUnchecked 无法按结构判断是否兼容的关键字，读取方的这些关键字需要与写入方相同
+var schemaUnchecked = []string{
+	"patternProperties", "propertyNames", "dependentRequired", "dependentSchemas", "dependencies",
+	"contains", "minContains", "maxContains", "not", "if", "then", "else",
+}
+
+// compatProblem 读取方 schema 中不接受写入方数据的一处约束
+type compatProblem struct {
+	path    string // 约束在读取方 schema 中的路径
+	message string
+}
+
+// compatChecker 检查按写入方 schema 产生的数据是否都能被读取方 schema 接受，
+// 无法精确判断时按不兼容处理；合并写入方的 allOf 时只会放宽约束，因此不会把不兼容判断为兼容
+type compatChecker struct {
+	writer *schemaValidator
+	reader *schemaValidator
+	active map[[2]*jsonNode]bool // 正在比较的 schema，再次遇到时视为兼容
+	depth  int
+	out    []compatProblem
+}
+
+// schemaCompatible 返回读取方 schema 不接受写入方数据的所有约束，为空表示兼容
+func schemaCompatible(writer, reader *jsonNode) []compatProblem {
+	c := &compatChecker{
+		writer: newSchemaValidator("", writer),
+		reader: newSchemaValidator("", reader),
+		active: make(map[[2]*jsonNode]bool),
+	}
+	c.check(writer, reader, "")
+	return c.out
+}
+
+func (c *compatChecker) report(path string, format string, args ...interface{}) {
+	c.out = append(c.out, compatProblem{path: path, message: fmt.Sprintf(format, args...)})
+}
+
+// trial 只判断是否兼容，不记录问题
+func (c *compatChecker) trial(w, r *jsonNode, path string) bool {
+	saved := c.out
+	c.out = nil
+	c.check(w, r, path)
+	ok := len(c.out) == 0
+	c.out = saved
+	return ok
+}
+
+// check 比较写入方 w 和读取方 r，path 为 r 在读取方 schema 中的路径
+func (c *compatChecker) check(w, r *jsonNode, path string) {
+	switch {
+	case isFalseSchema(w), isTrueSchema(r):
+		return
+	case isFalseSchema(r):
+		c.report(path, "不再接受任何值")
+		return
+	}
+	if isTrueSchema(w) {
+		w = &jsonNode{kind: nodeObject}
+	}
+	if w.kind != nodeObject || r.kind != nodeObject {
+		return
+	}
+
+	key := [2]*jsonNode{w, r}
+	if c.active[key] || c.depth >= maxCompatDepth {
+		return
+	}
+	c.active[key] = true
+	c.depth++
+	defer func() {
+		delete(c.active, key)
+		c.depth--
+	}()
+
+	// 写入方的 $ref 和 allOf 合并为一个 schema 后再比较
+	if merged, ok := c.flatten(w); ok {
+		c.check(merged, r, path)
+		return
+	}
+	wk := c.writer.keywordsOf(w)
+
+	// 写入方的每个分支都需要被接受
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if branches := wk[keyword]; branches != nil && branches.kind == nodeArray {
+			rest := withoutKeywords(w, keyword)
+			for _, b := range branches.elements {
+				c.check(mergeSchemas(rest, b), r, path)
+			}
+			return
+		}
+	}
+
+	// 写入方只允许有限的几个值时，逐个按读取方校验
+	if values := c.finiteValues(w, wk); values != nil {
+		for _, v := range values {
+			if ok, err := c.reader.valid(r, path, v, ""); err == nil && !ok {
+				c.report(path, "不再接受取值 %s", formatJSONTree(v, FormatOptions{}))
+			}
+		}
+		return
+	}
+
+	// 读取方的 $ref、allOf 要同时满足，anyOf、oneOf 满足其中一个
+	rk := c.reader.keywordsOf(r)
+	if ref := rk["$ref"]; ref != nil && ref.kind == nodeString {
+		if target, err := c.reader.resolve(ref.stringValue()); err == nil {
+			c.check(w, target, path+"/$ref")
+		}
+		if c.reader.draft7 {
+			return
+		}
+	}
+	if allOf := rk["allOf"]; allOf != nil && allOf.kind == nodeArray {
+		for i, b := range allOf.elements {
+			c.check(w, b, path+"/allOf/"+strconv.Itoa(i))
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		branches := rk[keyword]
+		if branches == nil || branches.kind != nodeArray {
+			continue
+		}
+		matched := false
+		for i, b := range branches.elements {
+			if c.trial(w, b, path+"/"+keyword+"/"+strconv.Itoa(i)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			c.report(path+"/"+keyword, "原有的值不一定符合 %s 中的某一个 schema", keyword)
+		}
+	}
+
+	c.compareTypes(wk, rk, path)
+	for _, keyword := range []string{"enum", "const"} {
+		if rk[keyword] != nil {
+			c.report(path+"/"+keyword, "新增了取值的限制 %s", keyword)
+		}
+	}
+	if schemaMay(wk, "number") && schemaMay(rk, "number") {
+		c.compareNumbers(wk, rk, path)
+	}
+	if schemaMay(wk, "string") && schemaMay(rk, "string") {
+		c.compareLimits(wk, rk, path, "minLength", "maxLength")
+		for _, keyword := range []string{"pattern", "format"} {
+			if rv := rk[keyword]; rv != nil && (wk[keyword] == nil || !jsonEqual(wk[keyword], rv)) {
+				c.report(path+"/"+keyword, "新增或修改了 %s %s", keyword, rv.raw)
+			}
+		}
+	}
+	if schemaMay(wk, "array") && schemaMay(rk, "array") {
+		c.compareArrays(wk, rk, path)
+	}
+	if schemaMay(wk, "object") && schemaMay(rk, "object") {
+		c.compareObjects(wk, rk, path)
+	}
+	for _, keyword := range schemaUnchecked {
+		if rv := rk[keyword]; rv != nil && (wk[keyword] == nil || !jsonEqual(wk[keyword], rv)) {
+			c.report(path+"/"+keyword, "无法判断 %s 的变化是否兼容", keyword)
+		}
+	}
+}
+
+// flatten 把写入方 schema 中的 $ref 和 allOf 合并到一起，没有时返回 false
+func (c *compatChecker) flatten(w *jsonNode) (*jsonNode, bool) {
+	wk := c.writer.keywordsOf(w)
+	ref, allOf := wk["$ref"], wk["allOf"]
+	hasRef := ref != nil && ref.kind == nodeString
+	hasAllOf := allOf != nil && allOf.kind == nodeArray
+	if !hasRef && !hasAllOf {
+		return nil, false
+	}
+
+	parts := []*jsonNode{withoutKeywords(w, "$ref", "allOf")}
+	if hasRef {
+		target, err := c.writer.resolve(ref.stringValue())
+		if err != nil {
+			// 无法解析时按不限制处理，只会得到更严格的结论
+			target = newLiteralNode("true")
+		}
+		if c.writer.draft7 || (onlyKeyword(w, "$ref") && !hasAllOf) {
+			// 保持原来的节点，递归引用时才能发现正在比较的 schema
+			return target, true
+		}
+		parts = append(parts, target)
+	}
+	if hasAllOf {
+		parts = append(parts, allOf.elements...)
+	}
+	return mergeSchemas(parts...), true
+}
+
+// finiteValues 写入方用 enum 或 const 限制了取值时，返回其中写入方本身接受的值
+func (c *compatChecker) finiteValues(w *jsonNode, wk map[string]*jsonNode) []*jsonNode {
+	var candidates []*jsonNode
+	if value := wk["const"]; value != nil {
+		candidates = []*jsonNode{value}
+	} else if enum := wk["enum"]; enum != nil && enum.kind == nodeArray {
+		candidates = enum.elements
+	} else {
+		return nil
+	}
+
+	values := []*jsonNode{}
+	for _, v := range candidates {
+		if ok, err := c.writer.valid(w, "", v, ""); err != nil || ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// compareTypes 读取方的 type 需要包含写入方的所有类型
+func (c *compatChecker) compareTypes(wk, rk map[string]*jsonNode, path string) {
+	rTypes := schemaTypeNames(rk)
+	if rTypes == nil {
+		return
+	}
+	wTypes := schemaTypeNames(wk)
+	if wTypes == nil {
+		c.report(path+"/type", "新增了类型限制 %s", strings.Join(rTypes, "、"))
+		return
+	}
+	for _, t := range wTypes {
+		switch {
+		case containsString(rTypes, t), t == "integer" && containsString(rTypes, "number"):
+		case t == "number" && containsString(rTypes, "integer"):
+			c.report(path+"/type", "不再接受非整数的数字")
+		default:
+			c.report(path+"/type", "不再接受类型 %s", t)
+		}
+	}
+}
+
+// compareNumbers 读取方的取值范围需要包含写入方的范围，multipleOf 需要是写入方的约数
+func (c *compatChecker) compareNumbers(wk, rk map[string]*jsonNode, path string) {
+	bounds := []struct {
+		inclusive, exclusive string
+		sign                 int // 读取方的界限比写入方大（下限）或小（上限）时更严格
+		name                 string
+	}{
+		{"minimum", "exclusiveMinimum", 1, "下限"},
+		{"maximum", "exclusiveMaximum", -1, "上限"},
+	}
+	for _, b := range bounds {
+		rValue, rExclusive, rKeyword := numberBound(rk, b.inclusive, b.exclusive, b.sign)
+		if rValue == nil {
+			continue
+		}
+		wValue, wExclusive, _ := numberBound(wk, b.inclusive, b.exclusive, b.sign)
+		switch {
+		case wValue == nil:
+			c.report(path+"/"+rKeyword, "新增了%s %s", b.name, rk[rKeyword].raw)
+		case rValue.Cmp(wValue)*b.sign > 0 || (rValue.Cmp(wValue) == 0 && rExclusive && !wExclusive):
+			c.report(path+"/"+rKeyword, "%s收紧为 %s", b.name, rk[rKeyword].raw)
+		}
+	}
+
+	if rNode := rk["multipleOf"]; rNode != nil {
+		rValue, rOK := numberValue(rNode)
+		wValue, wOK := numberValue(orNil(wk["multipleOf"]))
+		if rOK && rValue.Sign() > 0 && (!wOK || !wValue.MultipleOf(rValue)) {
+			c.report(path+"/multipleOf", "新增或修改了 multipleOf %s", rNode.raw)
+		}
+	}
+}
+
+// compareLimits 比较长度、个数的下限 minKeyword 和上限 maxKeyword
+func (c *compatChecker) compareLimits(wk, rk map[string]*jsonNode, path string, minKeyword string, maxKeyword string) {
+	if rMin, ok := schemaCount(rk, minKeyword); ok && rMin > 0 {
+		if wMin, _ := schemaCount(wk, minKeyword); wMin < rMin {
+			c.report(path+"/"+minKeyword, "%s 从 %d 提高到 %d", minKeyword, wMin, rMin)
+		}
+	}
+	if rMax, ok := schemaCount(rk, maxKeyword); ok {
+		wMax, ok := schemaCount(wk, maxKeyword)
+		switch {
+		case !ok:
+			c.report(path+"/"+maxKeyword, "新增了 %s %d", maxKeyword, rMax)
+		case wMax > rMax:
+			c.report(path+"/"+maxKeyword, "%s 从 %d 降低到 %d", maxKeyword, wMax, rMax)
+		}
+	}
+}
+
+// compareArrays 比较数组的元素和个数
+func (c *compatChecker) compareArrays(wk, rk map[string]*jsonNode, path string) {
+	c.compareLimits(wk, rk, path, "minItems", "maxItems")
+	if unique := rk["uniqueItems"]; unique != nil && unique.raw == "true" && (wk["uniqueItems"] == nil || wk["uniqueItems"].raw != "true") {
+		c.report(path+"/uniqueItems", "新增了 uniqueItems")
+	}
+
+	wPrefix, wRest, _, _ := arrayItems(wk)
+	rPrefix, rRest, rPrefixKeyword, rRestKeyword := arrayItems(rk)
+	for i := range rPrefix {
+		c.check(itemAt(wPrefix, wRest, i), rPrefix[i], path+"/"+rPrefixKeyword+"/"+strconv.Itoa(i))
+	}
+	if rRest == nil {
+		return
+	}
+	// 写入方按位置约束的元素超出读取方的部分，以及其余所有元素，都需要符合读取方的 items
+	for i := len(rPrefix); i < len(wPrefix); i++ {
+		c.check(wPrefix[i], rRest, path+"/"+rRestKeyword)
+	}
+	c.check(orTrue(wRest), rRest, path+"/"+rRestKeyword)
+}
+
+// compareObjects 比较对象的属性，新增的可选属性视为兼容
+func (c *compatChecker) compareObjects(wk, rk map[string]*jsonNode, path string) {
+	c.compareLimits(wk, rk, path, "minProperties", "maxProperties")
+
+	wProperties, rProperties := schemaProperties(wk), schemaProperties(rk)
+	for _, m := range rProperties {
+		if wv := findMember(wProperties, m.keyValue()); wv != nil {
+			c.check(wv, m.value, path+"/properties/"+escapePointer(m.keyValue()))
+		}
+	}
+
+	rAdditional := rk["additionalProperties"]
+	if rAdditional != nil {
+		for _, m := range wProperties {
+			name := m.keyValue()
+			if findMember(rProperties, name) != nil || c.matchesPattern(rk, name) {
+				continue
+			}
+			if isFalseSchema(rAdditional) {
+				c.report(path+"/additionalProperties", "不再接受属性 %s", name)
+			} else {
+				c.check(m.value, rAdditional, path+"/additionalProperties")
+			}
+		}
+		if wAdditional := wk["additionalProperties"]; !isFalseSchema(wAdditional) {
+			if isFalseSchema(rAdditional) {
+				c.report(path+"/additionalProperties", "不再接受未列出的属性")
+			} else {
+				c.check(orTrue(wAdditional), rAdditional, path+"/additionalProperties")
+			}
+		}
+	}
+
+	wRequired := make(map[string]bool)
+	if required := wk["required"]; required != nil {
+		for _, el := range required.elements {
+			wRequired[el.stringValue()] = true
+		}
+	}
+	if required := rk["required"]; required != nil {
+		for _, el := range required.elements {
+			if !wRequired[el.stringValue()] {
+				c.report(path+"/required", "新增了必需的属性 %s", el.stringValue())
+			}
+		}
+	}
+}
+
+// matchesPattern 属性名是否匹配读取方的 patternProperties，这些属性由 patternProperties 的比较负责
+func (c *compatChecker) matchesPattern(rk map[string]*jsonNode, name string) bool {
+	patterns := rk["patternProperties"]
+	if patterns == nil || patterns.kind != nodeObject {
+		return false
+	}
+	for _, p := range patterns.members {
+		if re, err := c.reader.regexp(&jsonNode{kind: nodeString, raw: p.key}, ""); err == nil && re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// arrayItems 按位置约束的元素和其余元素的 schema，以及它们的关键字；draft-07 中 items 为数组时按位置约束
+func arrayItems(kw map[string]*jsonNode) ([]*jsonNode, *jsonNode, string, string) {
+	if items := kw["items"]; items != nil && items.kind == nodeArray {
+		return items.elements, kw["additionalItems"], "items", "additionalItems"
+	}
+	var prefix []*jsonNode
+	if p := kw["prefixItems"]; p != nil && p.kind == nodeArray {
+		prefix = p.elements
+	}
+	return prefix, kw["items"], "prefixItems", "items"
+}
+
+// itemAt 第 i 个元素的 schema
+func itemAt(prefix []*jsonNode, rest *jsonNode, i int) *jsonNode {
+	if i < len(prefix) {
+		return prefix[i]
+	}
+	return orTrue(rest)
+}
+
+// numberBound 下限（sign 为 1）或上限（sign 为 -1）中更严格的一个，以及是否不含界限本身和所在的关键字
+func numberBound(kw map[string]*jsonNode, inclusive string, exclusive string, sign int) (*schemaNumber, bool, string) {
+	value, _ := numberValue(orNil(kw[inclusive]))
+	isExclusive, keyword := false, inclusive
+	// draft-04 中 exclusiveMinimum 为布尔值，不是数字时忽略
+	if ex, ok := numberValue(orNil(kw[exclusive])); ok && (value == nil || ex.Cmp(value)*sign >= 0) {
+		value, isExclusive, keyword = ex, true, exclusive
+	}
+	return value, isExclusive, keyword
+}
+
+// schemaTypeNames schema 的 type，没有限制时返回 nil
+func schemaTypeNames(kw map[string]*jsonNode) []string {
+	typ := kw["type"]
+	if typ == nil {
+		return nil
+	}
+	if typ.kind == nodeString {
+		return []string{typ.stringValue()}
+	}
+	names := []string{}
+	for _, el := range typ.elements {
+		names = append(names, el.stringValue())
+	}
+	return names
+}
+
+// schemaMay schema 是否可能接受类型 name 的值，integer 与 number 互相包含
+func schemaMay(kw map[string]*jsonNode, name string) bool {
+	types := schemaTypeNames(kw)
+	if types == nil || containsString(types, name) {
+		return true
+	}
+	return name == "number" && containsString(types, "integer")
+}
+
+// schemaCount 长度、个数类关键字的值，不存在时返回 0 和 false
+func schemaCount(kw map[string]*jsonNode, keyword string) (int64, bool) {
+	value, ok := numberValue(orNil(kw[keyword]))
+	if !ok {
+		return 0, false
+	}
+	return value.Int64()
+}
+
+// schemaProperties schema 中 properties 的成员
+func schemaProperties(kw map[string]*jsonNode) []*jsonMember {
+	if properties := kw["properties"]; properties != nil && properties.kind == nodeObject {
+		return properties.members
+	}
+	return nil
+}
+
+// findMember 按键查找成员的值，重复的键取最后一个
+func findMember(members []*jsonMember, key string) *jsonNode {
+	var value *jsonNode
+	for _, m := range members {
+		if m.keyValue() == key {
+			value = m.value
+		}
+	}
+	return value
+}
+
+// mergeBounds 合并时取较大值（1）或较小值（-1）的界限类关键字
+var mergeBounds = map[string]int{
+	"minimum": 1, "exclusiveMinimum": 1, "minLength": 1, "minItems": 1, "minProperties": 1, "minContains": 1,
+	"maximum": -1, "exclusiveMaximum": -1, "maxLength": -1, "maxItems": -1, "maxProperties": -1, "maxContains": -1,
+}
+
+// mergeSchemas 把多个 schema 的关键字合并为一个：同名的 properties 递归合并，required 和 allOf 取并集，
+// 界限类关键字取更严格的，多出的 $ref 放入 allOf，其他的取后出现的；得到的 schema 不会比同时满足所有 schema 更严格
+func mergeSchemas(parts ...*jsonNode) *jsonNode {
+	merged := &jsonNode{kind: nodeObject}
+	index := map[string]int{}
+	var propertyKeys []*jsonMember
+	propertyParts := map[string][]*jsonNode{}
+	var properties, required, allOf *jsonNode
+	for _, part := range parts {
+		if isFalseSchema(part) {
+			return part
+		}
+		if part.kind != nodeObject {
+			continue
+		}
+		for _, m := range part.members {
+			key := m.keyValue()
+			i, seen := index[key]
+			switch {
+			case key == "properties" && m.value.kind == nodeObject:
+				if properties == nil {
+					properties = &jsonNode{kind: nodeObject}
+					merged.members = append(merged.members, &jsonMember{key: m.key, value: properties})
+				}
+				for _, p := range m.value.members {
+					name := p.keyValue()
+					if propertyParts[name] == nil {
+						propertyKeys = append(propertyKeys, p)
+					}
+					propertyParts[name] = append(propertyParts[name], p.value)
+				}
+			case key == "required" && m.value.kind == nodeArray:
+				if required == nil {
+					required = &jsonNode{kind: nodeArray}
+					merged.members = append(merged.members, &jsonMember{key: m.key, value: required})
+				}
+				required.elements = append(required.elements, m.value.elements...)
+			case key == "allOf" && m.value.kind == nodeArray, key == "$ref" && seen:
+				if allOf == nil {
+					allOf = &jsonNode{kind: nodeArray}
+					merged.members = append(merged.members, &jsonMember{key: encodeJSONString("allOf", false, false), value: allOf})
+				}
+				if key == "allOf" {
+					allOf.elements = append(allOf.elements, m.value.elements...)
+				} else {
+					allOf.elements = append(allOf.elements, &jsonNode{kind: nodeObject, members: []*jsonMember{m}})
+				}
+			case seen:
+				if !looserBound(key, merged.members[i].value, m.value) {
+					merged.members[i] = m
+				}
+			default:
+				index[key] = len(merged.members)
+				merged.members = append(merged.members, m)
+			}
+		}
+	}
+	for _, p := range propertyKeys {
+		if values := propertyParts[p.keyValue()]; len(values) > 1 {
+			p = &jsonMember{key: p.key, keySource: p.keySource, value: mergeSchemas(values...)}
+		}
+		properties.members = append(properties.members, p)
+	}
+	return merged
+}
+
+// looserBound 界限类关键字 key 已有的值 current 是否比 next 更严格，此时合并时保留 current
+func looserBound(key string, current *jsonNode, next *jsonNode) bool {
+	sign, ok := mergeBounds[key]
+	if !ok {
+		return false
+	}
+	a, aOK := numberValue(current)
+	b, bOK := numberValue(next)
+	return aOK && bOK && a.Cmp(b)*sign > 0
+}
+
+// withoutKeywords 去掉指定关键字后的 schema
+func withoutKeywords(schema *jsonNode, keywords ...string) *jsonNode {
+	rest := &jsonNode{kind: nodeObject}
+	for _, m := range schema.members {
+		if !containsString(keywords, m.keyValue()) {
+			rest.members = append(rest.members, m)
+		}
+	}
+	return rest
+}
+
+// onlyKeyword schema 中除注解外是否只有关键字 keyword
+func onlyKeyword(schema *jsonNode, keyword string) bool {
+	for _, m := range schema.members {
+		if key := m.keyValue(); key != keyword && !schemaAnnotations[key] {
+			return false
+		}
+	}
+	return true
+}
+
+func isTrueSchema(schema *jsonNode) bool {
+	return schema != nil && schema.kind == nodeLiteral && schema.raw == "true"
+}
+
+func isFalseSchema(schema *jsonNode) bool {
+	return schema != nil && schema.kind == nodeLiteral && schema.raw == "false"
+}
+
+// orTrue 不存在的子 schema 视为 true
+func orTrue(schema *jsonNode) *jsonNode {
+	if schema == nil {
+		return newLiteralNode("true")
+	}
+	return schema
+}
+
+// orNil 不存在的关键字视为 null，便于按数字取值
+func orNil(node *jsonNode) *jsonNode {
+	if node == nil {
+		return newLiteralNode("null")
+	}
+	return node
+}
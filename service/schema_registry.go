@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sojson/zlog"
+)
+
+// CompatibilityLevel 注册新版本时的兼容性要求，与 Kafka Schema Registry 相同
+type CompatibilityLevel string
+
+const (
+	// CompatibilityNone 不检查
+	CompatibilityNone CompatibilityLevel = "NONE"
+	// CompatibilityBackward 新版本能接受上一个版本的数据
+	CompatibilityBackward CompatibilityLevel = "BACKWARD"
+	// CompatibilityBackwardTransitive 新版本能接受所有已注册版本的数据
+	CompatibilityBackwardTransitive CompatibilityLevel = "BACKWARD_TRANSITIVE"
+	// CompatibilityForward 上一个版本能接受新版本的数据
+	CompatibilityForward CompatibilityLevel = "FORWARD"
+	// CompatibilityForwardTransitive 所有已注册版本都能接受新版本的数据
+	CompatibilityForwardTransitive CompatibilityLevel = "FORWARD_TRANSITIVE"
+	// CompatibilityFull 同时满足 BACKWARD 和 FORWARD
+	CompatibilityFull CompatibilityLevel = "FULL"
+	// CompatibilityFullTransitive 同时满足 BACKWARD_TRANSITIVE 和 FORWARD_TRANSITIVE
+	CompatibilityFullTransitive CompatibilityLevel = "FULL_TRANSITIVE"
+)
+
+var compatibilityLevels = []CompatibilityLevel{
+	CompatibilityNone, CompatibilityBackward, CompatibilityBackwardTransitive, CompatibilityForward,
+	CompatibilityForwardTransitive, CompatibilityFull, CompatibilityFullTransitive,
+}
+
+// ParseCompatibilityLevel 解析兼容性要求，不区分大小写
+func ParseCompatibilityLevel(value string) (CompatibilityLevel, error) {
+	level := CompatibilityLevel(strings.ToUpper(strings.TrimSpace(value)))
+	for _, l := range compatibilityLevels {
+		if l == level {
+			return l, nil
+		}
+	}
+	names := make([]string, len(compatibilityLevels))
+	for i, l := range compatibilityLevels {
+		names[i] = string(l)
+	}
+	return "", fmt.Errorf("不支持的兼容性要求: %s，可选值: %s", value, strings.Join(names, "、"))
+}
+
+// backward 是否要求新版本接受旧版本的数据
+func (l CompatibilityLevel) backward() bool {
+	return l == CompatibilityBackward || l == CompatibilityBackwardTransitive || l == CompatibilityFull || l == CompatibilityFullTransitive
+}
+
+// forward 是否要求旧版本接受新版本的数据
+func (l CompatibilityLevel) forward() bool {
+	return l == CompatibilityForward || l == CompatibilityForwardTransitive || l == CompatibilityFull || l == CompatibilityFullTransitive
+}
+
+// transitive 是否与所有已注册版本比较，否则只与最新版本比较
+func (l CompatibilityLevel) transitive() bool {
+	return strings.HasSuffix(string(l), "_TRANSITIVE")
+}
+
+// SchemaVersion 注册表中某个主题的一个版本
+type SchemaVersion struct {
+	Subject string
+	Version int
+	Schema  string
+}
+
+// SchemaIncompatibility 新的 schema 与已注册版本之间的一处不兼容
+type SchemaIncompatibility struct {
+	Version     int    // 比较的已注册版本
+	Direction   string // backward 表示新版本不接受该版本的数据，forward 表示该版本不接受新版本的数据
+	KeywordPath string // 不接受对方数据的约束在读取方 schema 中的路径，backward 时为新版本，forward 时为已注册版本
+	Message     string
+}
+
+// SchemaNotFoundError 主题或版本不存在
+type SchemaNotFoundError struct {
+	Subject string
+	Version string // 为空时表示主题不存在
+}
+
+func (e *SchemaNotFoundError) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf("主题 %s 不存在", e.Subject)
+	}
+	return fmt.Sprintf("主题 %s 没有版本 %s", e.Subject, e.Version)
+}
+
+// IncompatibleSchemaError 新的 schema 不满足主题的兼容性要求
+type IncompatibleSchemaError struct {
+	Subject           string
+	Level             CompatibilityLevel
+	Incompatibilities []SchemaIncompatibility
+}
+
+func (e *IncompatibleSchemaError) Error() string {
+	first := e.Incompatibilities[0]
+	return fmt.Sprintf("新的 schema 不满足主题 %s 的兼容性要求 %s，共 %d 处不兼容，如与版本 %d 比较时 #%s: %s",
+		e.Subject, e.Level, len(e.Incompatibilities), first.Version, first.KeywordPath, first.Message)
+}
+
+// subjectPattern 主题名，同时作为目录名
+var subjectPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._\-]{0,254}$`)
+
+// versionFilePattern 版本文件名，如 3.json
+var versionFilePattern = regexp.MustCompile(`^([1-9][0-9]*)\.json$`)
+
+// subjectConfigFile 主题配置文件名
+const subjectConfigFile = "config.json"
+
+var (
+	registryMu            sync.Mutex
+	registryDir           = "schemas"
+	registryCompatibility = CompatibilityBackward
+)
+
+// subjectConfig 主题的配置
+type subjectConfig struct {
+	Compatibility CompatibilityLevel `json:"compatibility"`
+}
+
+// SetSchemaRegistry 设置注册表的存储目录和默认的兼容性要求，通常来自服务器参数或配置文件；为空的参数保持不变
+func (s *jsonProcessorService) SetSchemaRegistry(dir string, compatibility string) error {
+	level := CompatibilityLevel("")
+	if compatibility != "" {
+		var err error
+		if level, err = ParseCompatibilityLevel(compatibility); err != nil {
+			return err
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if dir != "" {
+		registryDir = dir
+	}
+	if level != "" {
+		registryCompatibility = level
+	}
+	return nil
+}
+
+// SchemaSubjects 注册表中的所有主题，按名称排序
+func (s *jsonProcessorService) SchemaSubjects(ctx context.Context) ([]string, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	entries, err := os.ReadDir(registryDir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		zlog.Errorf(ctx, "SchemaSubjects: ReadDir failed, dir: %s, error: %v", registryDir, err)
+		return nil, fmt.Errorf("读取注册表目录失败: %v", err)
+	}
+
+	subjects := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() || !subjectPattern.MatchString(entry.Name()) {
+			continue
+		}
+		if versions, err := subjectVersions(entry.Name()); err == nil && len(versions) > 0 {
+			subjects = append(subjects, entry.Name())
+		}
+	}
+	return subjects, nil
+}
+
+// SchemaVersions 主题的所有版本号，从小到大
+func (s *jsonProcessorService) SchemaVersions(ctx context.Context, subject string) ([]int, error) {
+	if err := checkSubject(subject); err != nil {
+		return nil, err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	versions, err := subjectVersions(subject)
+	if err != nil {
+		zlog.Errorf(ctx, "SchemaVersions: subjectVersions failed, subject: %s, error: %v", subject, err)
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, &SchemaNotFoundError{Subject: subject}
+	}
+	return versions, nil
+}
+
+// GetSchema 主题的一个版本，version 为版本号或 latest
+func (s *jsonProcessorService) GetSchema(ctx context.Context, subject string, version string) (*SchemaVersion, error) {
+	if err := checkSubject(subject); err != nil {
+		return nil, err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	versions, err := subjectVersions(subject)
+	if err != nil {
+		zlog.Errorf(ctx, "GetSchema: subjectVersions failed, subject: %s, error: %v", subject, err)
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, &SchemaNotFoundError{Subject: subject}
+	}
+
+	number := versions[len(versions)-1]
+	if version != "" && version != "latest" {
+		if number, err = strconv.Atoi(version); err != nil || number <= 0 {
+			return nil, fmt.Errorf("版本需要是正整数或 latest: %s", version)
+		}
+		if i := sort.SearchInts(versions, number); i == len(versions) || versions[i] != number {
+			return nil, &SchemaNotFoundError{Subject: subject, Version: version}
+		}
+	}
+
+	text, err := readSchemaVersion(subject, number)
+	if err != nil {
+		zlog.Errorf(ctx, "GetSchema: readSchemaVersion failed, subject: %s, version: %d, error: %v", subject, number, err)
+		return nil, err
+	}
+	return &SchemaVersion{Subject: subject, Version: number, Schema: text}, nil
+}
+
+// SubjectCompatibility 主题的兼容性要求，没有单独设置时为默认值
+func (s *jsonProcessorService) SubjectCompatibility(ctx context.Context, subject string) (CompatibilityLevel, error) {
+	if err := checkSubject(subject); err != nil {
+		return "", err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	level, err := subjectCompatibility(subject)
+	if err != nil {
+		zlog.Errorf(ctx, "SubjectCompatibility: subjectCompatibility failed, subject: %s, error: %v", subject, err)
+		return "", err
+	}
+	return level, nil
+}
+
+// SetSubjectCompatibility 设置主题的兼容性要求，只影响之后注册的版本
+func (s *jsonProcessorService) SetSubjectCompatibility(ctx context.Context, subject string, compatibility string) (CompatibilityLevel, error) {
+	if err := checkSubject(subject); err != nil {
+		return "", err
+	}
+	level, err := ParseCompatibilityLevel(compatibility)
+	if err != nil {
+		return "", err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	data, _ := json.MarshalIndent(subjectConfig{Compatibility: level}, "", "  ")
+	if err := writeRegistryFile(subject, subjectConfigFile, data); err != nil {
+		zlog.Errorf(ctx, "SetSubjectCompatibility: writeRegistryFile failed, subject: %s, error: %v", subject, err)
+		return "", err
+	}
+	zlog.Infof(ctx, "SetSubjectCompatibility: subject: %s, compatibility: %s", subject, level)
+	return level, nil
+}
+
+// CheckSchemaCompatibility 按主题的兼容性要求检查 schema 能否注册为新版本，不写入注册表；主题不存在时总是兼容
+func (s *jsonProcessorService) CheckSchemaCompatibility(ctx context.Context, subject string, schemaText string) (CompatibilityLevel, []SchemaIncompatibility, error) {
+	if err := checkSubject(subject); err != nil {
+		return "", nil, err
+	}
+	schema, err := parseRegistrySchema(schemaText)
+	if err != nil {
+		return "", nil, err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	level, err := subjectCompatibility(subject)
+	if err != nil {
+		zlog.Errorf(ctx, "CheckSchemaCompatibility: subjectCompatibility failed, subject: %s, error: %v", subject, err)
+		return "", nil, err
+	}
+	versions, err := subjectVersions(subject)
+	if err != nil {
+		zlog.Errorf(ctx, "CheckSchemaCompatibility: subjectVersions failed, subject: %s, error: %v", subject, err)
+		return "", nil, err
+	}
+	incompatibilities, err := compareWithVersions(subject, versions, schema, level)
+	if err != nil {
+		zlog.Errorf(ctx, "CheckSchemaCompatibility: compareWithVersions failed, subject: %s, error: %v", subject, err)
+		return "", nil, err
+	}
+	return level, incompatibilities, nil
+}
+
+// RegisterSchema 把 schema 注册为主题的新版本；与已注册的某个版本相同时返回该版本，created 为 false；
+// 不满足主题的兼容性要求时返回 IncompatibleSchemaError
+func (s *jsonProcessorService) RegisterSchema(ctx context.Context, subject string, schemaText string) (version *SchemaVersion, created bool, err error) {
+	if err := checkSubject(subject); err != nil {
+		return nil, false, err
+	}
+	schema, err := parseRegistrySchema(schemaText)
+	if err != nil {
+		return nil, false, err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	versions, err := subjectVersions(subject)
+	if err != nil {
+		zlog.Errorf(ctx, "RegisterSchema: subjectVersions failed, subject: %s, error: %v", subject, err)
+		return nil, false, err
+	}
+	for _, v := range versions {
+		text, err := readSchemaVersion(subject, v)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing, err := parseJSONTree(text); err == nil && jsonEqual(existing, schema) {
+			return &SchemaVersion{Subject: subject, Version: v, Schema: text}, false, nil
+		}
+	}
+
+	level, err := subjectCompatibility(subject)
+	if err != nil {
+		return nil, false, err
+	}
+	incompatibilities, err := compareWithVersions(subject, versions, schema, level)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(incompatibilities) > 0 {
+		zlog.Warnf(ctx, "RegisterSchema: incompatible schema rejected, subject: %s, compatibility: %s, incompatibilities: %d", subject, level, len(incompatibilities))
+		return nil, false, &IncompatibleSchemaError{Subject: subject, Level: level, Incompatibilities: incompatibilities}
+	}
+
+	number := 1
+	if len(versions) > 0 {
+		number = versions[len(versions)-1] + 1
+	}
+	text := strings.TrimSpace(schemaText)
+	if err := writeRegistryFile(subject, strconv.Itoa(number)+".json", []byte(text+"\n")); err != nil {
+		zlog.Errorf(ctx, "RegisterSchema: writeRegistryFile failed, subject: %s, version: %d, error: %v", subject, number, err)
+		return nil, false, err
+	}
+
+	zlog.Infof(ctx, "RegisterSchema: registered, subject: %s, version: %d, schema length: %d", subject, number, len(text))
+	return &SchemaVersion{Subject: subject, Version: number, Schema: text}, true, nil
+}
+
+// compareWithVersions 按兼容性要求与已注册的版本比较，非传递的要求只与最新版本比较
+func compareWithVersions(subject string, versions []int, schema *jsonNode, level CompatibilityLevel) ([]SchemaIncompatibility, error) {
+	if level == CompatibilityNone || len(versions) == 0 {
+		return nil, nil
+	}
+	if !level.transitive() {
+		versions = versions[len(versions)-1:]
+	}
+
+	var incompatibilities []SchemaIncompatibility
+	for i := len(versions) - 1; i >= 0; i-- {
+		text, err := readSchemaVersion(subject, versions[i])
+		if err != nil {
+			return nil, err
+		}
+		old, err := parseJSONTree(text)
+		if err != nil {
+			return nil, fmt.Errorf("主题 %s 的版本 %d 不是合法的JSON: %v", subject, versions[i], err)
+		}
+		if level.backward() {
+			for _, p := range schemaCompatible(old, schema) {
+				incompatibilities = append(incompatibilities, SchemaIncompatibility{Version: versions[i], Direction: "backward", KeywordPath: p.path, Message: p.message})
+			}
+		}
+		if level.forward() {
+			for _, p := range schemaCompatible(schema, old) {
+				incompatibilities = append(incompatibilities, SchemaIncompatibility{Version: versions[i], Direction: "forward", KeywordPath: p.path, Message: p.message})
+			}
+		}
+	}
+	return incompatibilities, nil
+}
+
+// parseRegistrySchema 解析要注册的 schema，并检查其中的 $ref 都能在本地解析
+func parseRegistrySchema(schemaText string) (*jsonNode, error) {
+	schema, err := parseJSONTree(schemaText)
+	if err != nil {
+		return nil, fmt.Errorf("schema 格式错误: %s", err.Error())
+	}
+	if schema.kind != nodeObject && !isTrueSchema(schema) && !isFalseSchema(schema) {
+		return nil, fmt.Errorf("schema 需要是对象或布尔值")
+	}
+	v := newSchemaValidator("", schema)
+	if err := checkSchemaRefs(v, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// checkSchemaRefs 检查 schema 中所有的 $ref，带 $id 的子 schema 中的 $ref 相对于该子 schema 解析
+func checkSchemaRefs(v *schemaValidator, node *jsonNode) error {
+	switch node.kind {
+	case nodeObject:
+		if res := v.resources[node]; res != nil {
+			v.scope = append(v.scope, res)
+			defer func() { v.scope = v.scope[:len(v.scope)-1] }()
+		}
+		for _, m := range node.members {
+			if key := m.keyValue(); (key == "$ref" || key == "$dynamicRef") && m.value.kind == nodeString {
+				if _, err := v.resolve(m.value.stringValue()); err != nil {
+					return err
+				}
+			}
+			if err := checkSchemaRefs(v, m.value); err != nil {
+				return err
+			}
+		}
+	case nodeArray:
+		for _, el := range node.elements {
+			if err := checkSchemaRefs(v, el); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkSubject 检查主题名，主题名同时作为目录名，只允许字母、数字和 . _ -
+func checkSubject(subject string) error {
+	if !subjectPattern.MatchString(subject) {
+		return fmt.Errorf("主题名只能包含字母、数字和 . _ -，以字母或数字开头，不超过 255 个字符: %s", subject)
+	}
+	return nil
+}
+
+// subjectVersions 主题已注册的版本号，从小到大；主题不存在时为空
+func subjectVersions(subject string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(registryDir, subject))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取主题 %s 失败: %v", subject, err)
+	}
+
+	var versions []int
+	for _, entry := range entries {
+		if match := versionFilePattern.FindStringSubmatch(entry.Name()); match != nil && !entry.IsDir() {
+			number, err := strconv.Atoi(match[1])
+			if err == nil {
+				versions = append(versions, number)
+			}
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// subjectCompatibility 主题的兼容性要求，没有配置文件时为默认值
+func subjectCompatibility(subject string) (CompatibilityLevel, error) {
+	data, err := os.ReadFile(filepath.Join(registryDir, subject, subjectConfigFile))
+	if os.IsNotExist(err) {
+		return registryCompatibility, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取主题 %s 的配置失败: %v", subject, err)
+	}
+
+	var cfg subjectConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", fmt.Errorf("解析主题 %s 的配置失败: %v", subject, err)
+	}
+	return ParseCompatibilityLevel(string(cfg.Compatibility))
+}
+
+// readSchemaVersion 读取主题的一个版本
+func readSchemaVersion(subject string, version int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(registryDir, subject, strconv.Itoa(version)+".json"))
+	if err != nil {
+		return "", fmt.Errorf("读取主题 %s 的版本 %d 失败: %v", subject, version, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeRegistryFile 写入主题目录下的文件，先写入临时文件再重命名，避免留下写了一半的文件
+func writeRegistryFile(subject string, name string, data []byte) error {
+	dir := filepath.Join(registryDir, subject)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建主题目录失败: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入主题 %s 失败: %v", subject, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入主题 %s 失败: %v", subject, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入主题 %s 失败: %v", subject, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("写入主题 %s 失败: %v", subject, err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaCompatible(t *testing.T) {
+	tests := []struct {
+		name   string
+		writer string
+		reader string
+		want   []string // 每处不兼容的 路径 说明
+	}{
+		{
+			name:   "新增可选属性和放宽约束",
+			writer: `{"type":"object","properties":{"id":{"type":"integer","minimum":1},"tags":{"type":"array","maxItems":3}},"required":["id"]}`,
+			reader: `{"type":"object","properties":{"id":{"type":"number","minimum":0},"tags":{"type":"array"},"note":{"type":"string"}},"required":["id"]}`,
+		},
+		{
+			name:   "新增必需属性、收紧类型和范围",
+			writer: `{"type":"object","properties":{"id":{"type":"number"},"name":{"type":"string"}}}`,
+			reader: `{"type":"object","properties":{"id":{"type":"integer","exclusiveMaximum":100},"name":{"type":"string","maxLength":20}},"required":["name"]}`,
+			want: []string{
+				"/properties/id/type 不再接受非整数的数字",
+				"/properties/id/exclusiveMaximum 新增了上限 100",
+				"/properties/name/maxLength 新增了 maxLength 20",
+				"/required 新增了必需的属性 name",
+			},
+		},
+		{
+			name:   "删除属性后不再接受额外的属性",
+			writer: `{"properties":{"a":{"type":"string"},"b":{"type":"string"}}}`,
+			reader: `{"properties":{"a":{"type":"string"}},"additionalProperties":false}`,
+			want: []string{
+				"/additionalProperties 不再接受属性 b",
+				"/additionalProperties 不再接受未列出的属性",
+			},
+		},
+		{
+			name:   "枚举值逐个按新版本校验",
+			writer: `{"type":"string","enum":["a","b","c"]}`,
+			reader: `{"enum":["a","b"]}`,
+			want:   []string{` 不再接受取值 "c"`},
+		},
+		{
+			name:   "经过$ref和allOf比较，递归的schema不会无限展开",
+			writer: `{"$ref":"#/$defs/node","$defs":{"node":{"allOf":[{"type":"object"},{"properties":{"children":{"type":"array","items":{"$ref":"#/$defs/node"}}}}]}}}`,
+			reader: `{"$ref":"#/definitions/node","definitions":{"node":{"type":"object","properties":{"children":{"items":{"$ref":"#/definitions/node"},"minItems":1}}}}}`,
+			want:   []string{"/$ref/properties/children/minItems minItems 从 0 提高到 1"},
+		},
+		{
+			name:   "allOf中同名的属性递归合并，界限取更严格的",
+			writer: `{"allOf":[{"properties":{"a":{"maximum":5}},"minItems":2},{"properties":{"a":{"minimum":0}},"minItems":1}]}`,
+			reader: `{"properties":{"a":{"maximum":5}},"minItems":2}`,
+		},
+		{
+			name:   "写入方的每个分支都需要被读取方的某个分支接受",
+			writer: `{"anyOf":[{"type":"string"},{"type":"integer"},{"type":"null"}]}`,
+			reader: `{"oneOf":[{"type":"string"},{"type":"number"}]}`,
+			want:   []string{"/oneOf 原有的值不一定符合 oneOf 中的某一个 schema"},
+		},
+		{
+			name:   "无法判断的关键字按不兼容处理",
+			writer: `{"type":"string"}`,
+			reader: `{"type":"string","not":{"const":""}}`,
+			want:   []string{"/not 无法判断 not 的变化是否兼容"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, err := parseJSONTree(tt.writer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader, err := parseJSONTree(tt.reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range schemaCompatible(writer, reader) {
+				got = append(got, p.path+" "+p.message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("schemaCompatible() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := JSONProcessorService.SetSchemaRegistry(dir, "backward"); err != nil {
+		t.Fatal(err)
+	}
+
+	const v1 = `{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}`
+	const v2 = `{"type":"object","properties":{"id":{"type":"integer"},"note":{"type":"string"}},"required":["id"]}`
+
+	register := func(subject string, schema string) (*SchemaVersion, bool, error) {
+		return JSONProcessorService.RegisterSchema(ctx, subject, schema)
+	}
+
+	got, created, err := register("orders", v1)
+	if err != nil || !created || got.Version != 1 {
+		t.Fatalf("RegisterSchema(v1) = %v, %v, %v", got, created, err)
+	}
+	got, created, err = register("orders", v2)
+	if err != nil || !created || got.Version != 2 {
+		t.Fatalf("RegisterSchema(v2) = %v, %v, %v", got, created, err)
+	}
+
+	// 与已注册的版本相同时不创建新版本，键的顺序不影响
+	got, created, err = register("orders", `{"required":["id"],"properties":{"id":{"type":"integer"}},"type":"object"}`)
+	if err != nil || created || got.Version != 1 {
+		t.Errorf("RegisterSchema(same as v1) = %v, %v, %v", got, created, err)
+	}
+
+	// 新增必需属性不向后兼容
+	_, _, err = register("orders", `{"type":"object","properties":{"id":{"type":"integer"},"note":{"type":"string"}},"required":["id","note"]}`)
+	var incompatible *IncompatibleSchemaError
+	if !errors.As(err, &incompatible) || incompatible.Incompatibilities[0].Version != 2 || incompatible.Incompatibilities[0].Direction != "backward" {
+		t.Fatalf("RegisterSchema(incompatible) error = %v", err)
+	}
+
+	// FORWARD_TRANSITIVE 要求所有旧版本都接受新版本的数据：id 改为字符串且不再必需，两个旧版本各有 2 处不兼容
+	if _, err := JSONProcessorService.SetSubjectCompatibility(ctx, "orders", "forward_transitive"); err != nil {
+		t.Fatal(err)
+	}
+	level, problems, err := JSONProcessorService.CheckSchemaCompatibility(ctx, "orders", `{"type":"object","properties":{"id":{"type":"string"}}}`)
+	if err != nil || level != CompatibilityForwardTransitive || len(problems) != 4 {
+		t.Errorf("CheckSchemaCompatibility() = %v, %v, %v", level, problems, err)
+	}
+
+	versions, err := JSONProcessorService.SchemaVersions(ctx, "orders")
+	if err != nil || fmt.Sprint(versions) != "[1 2]" {
+		t.Errorf("SchemaVersions() = %v, %v", versions, err)
+	}
+	latest, err := JSONProcessorService.GetSchema(ctx, "orders", "latest")
+	if err != nil || latest.Version != 2 || latest.Schema != v2 {
+		t.Errorf("GetSchema(latest) = %v, %v", latest, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "orders", "1.json")); err != nil || strings.TrimSpace(string(data)) != v1 {
+		t.Errorf("1.json = %q, %v", data, err)
+	}
+
+	var notFound *SchemaNotFoundError
+	if _, err := JSONProcessorService.GetSchema(ctx, "orders", "3"); !errors.As(err, &notFound) {
+		t.Errorf("GetSchema(3) error = %v", err)
+	}
+	if _, err := JSONProcessorService.SchemaVersions(ctx, "payments"); !errors.As(err, &notFound) {
+		t.Errorf("SchemaVersions(payments) error = %v", err)
+	}
+	if _, _, err := register("../etc", v1); err == nil || !strings.Contains(err.Error(), "主题名") {
+		t.Errorf("RegisterSchema(../etc) error = %v", err)
+	}
+	if _, _, err := register("payments", `{"$ref":"#/$defs/missing"}`); err == nil || !strings.Contains(err.Error(), "无法解析") {
+		t.Errorf("RegisterSchema(missing $ref) error = %v", err)
+	}
+	subjects, err := JSONProcessorService.SchemaSubjects(ctx)
+	if err != nil || fmt.Sprint(subjects) != "[orders]" {
+		t.Errorf("SchemaSubjects() = %v, %v", subjects, err)
+	}
+}
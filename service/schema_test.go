@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    SchemaOptions
+		want    string
+		wantErr string
+	}{
+		{
+			name: "NDJSON中的必需属性、枚举、格式和取值范围",
+			input: "{\"id\":3,\"status\":\"paid\",\"at\":\"2024-01-01T00:00:00Z\",\"email\":\"a@b.com\",\"score\":1.5}\n" +
+				"{\"id\":1,\"status\":\"paid\",\"at\":\"2024-02-01T08:00:00+08:00\",\"email\":\"c@d.io\",\"score\":-2,\"ip\":\"10.0.0.1\"}\n",
+			opts: SchemaOptions{Mode: ModeNDJSON},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+				`"id":{"type":"integer","minimum":1,"maximum":3},` +
+				`"status":{"type":"string","enum":["paid"]},` +
+				`"at":{"type":"string","format":"date-time"},` +
+				`"email":{"type":"string","format":"email"},` +
+				`"score":{"type":"number","minimum":-2,"maximum":1.5},` +
+				`"ip":{"type":"string","format":"ipv4"}},` +
+				`"required":["id","status","at","email","score"]}`,
+		},
+		{
+			name:  "数组元素、null和联合类型",
+			input: `{"tags":["a",null],"v":[1,"x",null],"any":[],"u":"123e4567-e89b-12d3-a456-426614174000","s":"2024-01-01"}`,
+			opts:  SchemaOptions{EnumLimit: -1, Strict: true},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+				`"tags":{"type":"array","items":{"type":["string","null"]}},` +
+				`"v":{"type":"array","items":{"anyOf":[{"type":"integer","minimum":1,"maximum":1},{"type":"string"},{"type":"null"}]}},` +
+				`"any":{"type":"array"},` +
+				`"u":{"type":"string","format":"uuid"},` +
+				`"s":{"type":"string","format":"date"}},` +
+				`"required":["tags","v","any","u","s"],"additionalProperties":false}`,
+		},
+		{
+			name:  "数组中的每个元素作为一个样本，格式不一致时不生成format",
+			input: `[{"e":"a@b.com","k":"x"},{"e":"not an email","k":"x"},{"k":null}]`,
+			opts:  SchemaOptions{Mode: ModeArrayToNDJSON},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+				`"e":{"type":"string"},` +
+				`"k":{"type":["string","null"],"enum":["x",null]}},` +
+				`"required":["k"]}`,
+		},
+		{
+			name:    "数组样本模式下输入不是数组",
+			input:   `{"a":1}`,
+			opts:    SchemaOptions{Mode: ModeArrayToNDJSON},
+			wantErr: "输入不是非空的JSON数组",
+		},
+		{
+			name:    "语法错误",
+			input:   `{"a":}`,
+			wantErr: "第 1 行第 6 列",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.InferSchema(ctx, tt.input, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("InferSchema() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InferSchema() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("InferSchema() =\n%s\nwant\n%s", result.Result, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"sojson/zlog"
+)
+
+// SchemaViolation 文档中不符合 schema 的一处
+type SchemaViolation struct {
+	Position            // 不符合约束的值在文档中的位置
+	InstancePath string // 值的 JSON Pointer，如 /items/0/id，文档本身为空字符串
+	KeywordPath  string // 约束在 schema 中的路径，经过 $ref 时包含 $ref，如 /properties/items/$ref/type
+	Message      string // 说明
+}
+
+// ValidateSchema 按 JSON Schema（draft-07 或 2020-12）校验文档，返回所有不符合的位置；
+// 文档语法错误时返回 SyntaxError，schema 本身有误或引用了外部的 schema 时返回普通错误
+func (s *jsonProcessorService) ValidateSchema(ctx context.Context, text string, schemaText string, dialect Dialect) ([]SchemaViolation, error) {
+	if err := dialect.check(); err != nil {
+		return nil, err
+	}
+	doc, err := parseDialectTree(text, dialect)
+	if err != nil {
+		zlog.Errorf(ctx, "ValidateSchema: parseDialectTree failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+	schema, err := parseJSONTree(schemaText)
+	if err != nil {
+		zlog.Errorf(ctx, "ValidateSchema: parse schema failed, schema length: %d, error: %v", len(schemaText), err)
+		// 不保留位置，避免把 schema 中的位置标记到文档上
+		return nil, fmt.Errorf("schema 格式错误: %s", err.Error())
+	}
+
+	v := newSchemaValidator(text, schema)
+	violations, err := v.validate(schema, "", doc, "")
+	if err != nil {
+		zlog.Errorf(ctx, "ValidateSchema: validate failed, error: %v", err)
+		return nil, err
+	}
+
+	zlog.Infof(ctx, "ValidateSchema: successfully validated, input length: %d, schema length: %d, violations: %d", len(text), len(schemaText), len(violations))
+	return violations, nil
+}
+
+// schemaValidator 按 schema 校验文档
+type schemaValidator struct {
+	text      string
+	root      *jsonNode
+	draft7    bool // draft-07 及更早的版本中 $ref 的同级关键字被忽略
+	resources map[*jsonNode]*schemaResource
+	ids       map[string]*schemaResource // 按 $id 的完整地址
+	keywords  map[*jsonNode]map[string]*jsonNode
+	regexps   map[string]*regexp.Regexp
+	active    map[[2]*jsonNode]bool // 正在校验的 schema 和值，用于发现 $ref 循环
+	scope     []*schemaResource     // 动态作用域：正在校验的 schema 资源，由外到内
+}
+
+// schemaResource 根 schema 或带 $id 的子 schema，其中的锚点和相对的 $ref 以它为准
+type schemaResource struct {
+	node    *jsonNode
+	uri     string               // $id 的完整地址，没有 $id 时为空
+	anchors map[string]*jsonNode // $anchor、$dynamicAnchor 及以 # 开头的 $id 定义的位置，键不带 #
+	dynamic map[string]*jsonNode // $dynamicAnchor 定义的位置
+}
+
+func newSchemaValidator(text string, schema *jsonNode) *schemaValidator {
+	v := &schemaValidator{
+		text:      text,
+		root:      schema,
+		resources: make(map[*jsonNode]*schemaResource),
+		ids:       make(map[string]*schemaResource),
+		keywords:  make(map[*jsonNode]map[string]*jsonNode),
+		regexps:   make(map[string]*regexp.Regexp),
+		active:    make(map[[2]*jsonNode]bool),
+	}
+	var uri string
+	if schema.kind == nodeObject {
+		kw := v.keywordsOf(schema)
+		if draft := kw["$schema"]; draft != nil && draft.kind == nodeString {
+			uri := draft.stringValue()
+			v.draft7 = strings.Contains(uri, "draft-07") || strings.Contains(uri, "draft-06") || strings.Contains(uri, "draft-04")
+		}
+		if id := kw["$id"]; id != nil && id.kind == nodeString {
+			uri = strings.TrimSuffix(id.stringValue(), "#")
+		}
+	}
+	v.collectAnchors(schema, v.addResource(schema, uri))
+	return v
+}
+
+// addResource 登记一个 schema 资源
+func (v *schemaValidator) addResource(node *jsonNode, uri string) *schemaResource {
+	res := &schemaResource{node: node, uri: uri, anchors: make(map[string]*jsonNode), dynamic: make(map[string]*jsonNode)}
+	v.resources[node] = res
+	if uri != "" {
+		v.ids[uri] = res
+	}
+	return res
+}
+
+// collectAnchors 收集 schema 中所有的 $id、$anchor 和 $dynamicAnchor，res 为 node 所在的资源
+func (v *schemaValidator) collectAnchors(node *jsonNode, res *schemaResource) {
+	switch node.kind {
+	case nodeObject:
+		kw := v.keywordsOf(node)
+		if id := kw["$id"]; id != nil && id.kind == nodeString && node != v.root {
+			if value := id.stringValue(); strings.HasPrefix(value, "#") {
+				res.anchors[value[1:]] = node
+			} else {
+				res = v.addResource(node, resolveURI(res.uri, value))
+			}
+		}
+		if anchor := kw["$anchor"]; anchor != nil && anchor.kind == nodeString {
+			res.anchors[anchor.stringValue()] = node
+		}
+		if anchor := kw["$dynamicAnchor"]; anchor != nil && anchor.kind == nodeString {
+			res.anchors[anchor.stringValue()] = node
+			res.dynamic[anchor.stringValue()] = node
+		}
+		for _, m := range node.members {
+			v.collectAnchors(m.value, res)
+		}
+	case nodeArray:
+		for _, el := range node.elements {
+			v.collectAnchors(el, res)
+		}
+	}
+}
+
+// resolveURI 按 base 解析相对地址，去掉片段
+func resolveURI(base string, ref string) string {
+	ref = strings.TrimSuffix(ref, "#")
+	if base == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	resolved := baseURL.ResolveReference(refURL)
+	resolved.Fragment = ""
+	return resolved.String()
+}
+
+// keywordsOf schema 对象中的关键字，重复时取最后一个
+func (v *schemaValidator) keywordsOf(schema *jsonNode) map[string]*jsonNode {
+	if kw, ok := v.keywords[schema]; ok {
+		return kw
+	}
+	kw := make(map[string]*jsonNode, len(schema.members))
+	for _, m := range schema.members {
+		kw[m.keyValue()] = m.value
+	}
+	v.keywords[schema] = kw
+	return kw
+}
+
+// current 当前所在的 schema 资源，没有在校验时为根 schema
+func (v *schemaValidator) current() *schemaResource {
+	if len(v.scope) == 0 {
+		return v.resources[v.root]
+	}
+	return v.scope[len(v.scope)-1]
+}
+
+// resolve 在当前的 schema 资源中解析本地的 $ref：# 开头的 JSON Pointer、锚点，以及 schema 中的 $id
+func (v *schemaValidator) resolve(ref string) (*jsonNode, error) {
+	base, fragment, _ := strings.Cut(ref, "#")
+	res := v.current()
+	if base != "" {
+		if res = v.ids[resolveURI(res.uri, base)]; res == nil {
+			return nil, fmt.Errorf("不支持外部的 $ref: %s", ref)
+		}
+	}
+	if fragment == "" {
+		return res.node, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		target := res.anchors[fragment]
+		if target == nil {
+			return nil, fmt.Errorf("$ref %s 引用的锚点不存在", ref)
+		}
+		return target, nil
+	}
+
+	pointer, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %s 不是合法的 JSON Pointer: %v", ref, err)
+	}
+	target, _, err := resolvePointer(res.node, pointer)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %s 无法解析: %v", ref, err)
+	}
+	return target, nil
+}
+
+// dynamicTarget $dynamicRef 的目标：静态解析到的位置定义了同名的 $dynamicAnchor 时，
+// 改为动态作用域中最外层定义了该锚点的资源中的位置，否则与 $ref 相同
+func (v *schemaValidator) dynamicTarget(ref string, target *jsonNode) *jsonNode {
+	_, name, _ := strings.Cut(ref, "#")
+	if name == "" || strings.HasPrefix(name, "/") || target.kind != nodeObject {
+		return target
+	}
+	if anchor := v.keywordsOf(target)["$dynamicAnchor"]; anchor == nil || anchor.kind != nodeString || anchor.stringValue() != name {
+		return target
+	}
+	for _, res := range v.scope {
+		if node := res.dynamic[name]; node != nil {
+			return node
+		}
+	}
+	return target
+}
+
+// violation 创建值 inst 的一处不符合
+func (v *schemaValidator) violation(inst *jsonNode, instPath string, keywordPath string, format string, args ...interface{}) SchemaViolation {
+	return SchemaViolation{
+		Position:     newPosition(v.text, inst.offset),
+		InstancePath: instPath,
+		KeywordPath:  keywordPath,
+		Message:      fmt.Sprintf(format, args...),
+	}
+}
+
+// valid 值是否符合子 schema，只关心结果而不收集说明
+func (v *schemaValidator) valid(schema *jsonNode, keywordPath string, inst *jsonNode, instPath string) (bool, error) {
+	violations, err := v.validate(schema, keywordPath, inst, instPath)
+	return len(violations) == 0, err
+}
+
+// validate 按 schema 校验值 inst，返回所有不符合的位置
+func (v *schemaValidator) validate(schema *jsonNode, keywordPath string, inst *jsonNode, instPath string) ([]SchemaViolation, error) {
+	c, err := v.check(schema, keywordPath, inst, instPath)
+	if err != nil {
+		return nil, err
+	}
+	return c.out, nil
+}
+
+// check 按 schema 校验值 inst，结果中还记录了校验过的属性和元素
+func (v *schemaValidator) check(schema *jsonNode, keywordPath string, inst *jsonNode, instPath string) (*schemaCheck, error) {
+	c := &schemaCheck{v: v, path: keywordPath, inst: inst, instPath: instPath}
+	switch {
+	case schema.kind == nodeLiteral && schema.raw == "true":
+		return c, nil
+	case schema.kind == nodeLiteral && schema.raw == "false":
+		c.out = []SchemaViolation{v.violation(inst, instPath, keywordPath, "schema 为 false，不允许任何值")}
+		return c, nil
+	case schema.kind != nodeObject:
+		return nil, fmt.Errorf("schema %s 需要是对象或布尔值", schemaLocation(keywordPath))
+	}
+
+	key := [2]*jsonNode{schema, inst}
+	if v.active[key] {
+		return nil, fmt.Errorf("schema %s 处的 $ref 循环引用", schemaLocation(keywordPath))
+	}
+	v.active[key] = true
+	defer delete(v.active, key)
+
+	if res := v.resources[schema]; res != nil {
+		v.scope = append(v.scope, res)
+		defer func() { v.scope = v.scope[:len(v.scope)-1] }()
+	}
+
+	c.kw = v.keywordsOf(schema)
+	for _, keyword := range schemaUnsupported {
+		if c.kw[keyword] != nil {
+			return nil, fmt.Errorf("schema %s: 不支持 %s", schemaLocation(keywordPath+"/"+keyword), keyword)
+		}
+	}
+	for _, keyword := range []string{"$ref", "$dynamicRef"} {
+		if ref := c.kw[keyword]; ref != nil {
+			if ref.kind != nodeString {
+				return nil, fmt.Errorf("schema %s 需要是字符串", schemaLocation(keywordPath+"/"+keyword))
+			}
+			target, err := v.resolve(ref.stringValue())
+			if err != nil {
+				return nil, err
+			}
+			if keyword == "$dynamicRef" {
+				target = v.dynamicTarget(ref.stringValue(), target)
+			}
+			if err := c.sub(target, keyword, inst, instPath); err != nil {
+				return nil, err
+			}
+			if v.draft7 {
+				return c, nil
+			}
+		}
+	}
+
+	for _, step := range []func() error{c.generic, c.number, c.string, c.array, c.object, c.combinators, c.unevaluated} {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// schemaUnsupported 不支持的关键字，出现时返回错误而不是忽略
+var schemaUnsupported = []string{"$recursiveRef"}
+
+// schemaCheck 一个 schema 对象对一个值的校验
+type schemaCheck struct {
+	v        *schemaValidator
+	kw       map[string]*jsonNode
+	path     string // schema 对象的路径
+	inst     *jsonNode
+	instPath string
+	out      []SchemaViolation
+	props    map[string]bool // 被 properties 等关键字校验过的属性，用于 unevaluatedProperties
+	items    map[int]bool    // 被 items 等关键字校验过的元素，用于 unevaluatedItems
+}
+
+// add 记录值不符合关键字 keyword
+func (c *schemaCheck) add(keyword string, format string, args ...interface{}) {
+	c.out = append(c.out, c.v.violation(c.inst, c.instPath, c.path+"/"+keyword, format, args...))
+}
+
+// sub 按子 schema 校验值，keyword 为子 schema 相对于当前 schema 的路径
+func (c *schemaCheck) sub(schema *jsonNode, keyword string, inst *jsonNode, instPath string) error {
+	sc, err := c.v.check(schema, c.path+"/"+keyword, inst, instPath)
+	if err != nil {
+		return err
+	}
+	c.out = append(c.out, sc.out...)
+	// 子 schema 不符合时当前 schema 也不符合，仍然合并校验过的属性和元素，避免重复报告 unevaluatedProperties
+	c.merge(sc)
+	return nil
+}
+
+// try 按子 schema 校验当前的值，只关心是否符合，不收集说明
+func (c *schemaCheck) try(schema *jsonNode, keyword string) (bool, error) {
+	sc, err := c.v.check(schema, c.path+"/"+keyword, c.inst, c.instPath)
+	if err != nil {
+		return false, err
+	}
+	// 不符合的 anyOf、oneOf 分支和 if 按规范不计入校验过的属性和元素
+	if len(sc.out) == 0 {
+		c.merge(sc)
+	}
+	return len(sc.out) == 0, nil
+}
+
+// merge 合并对同一个值的子 schema 校验过的属性和元素
+func (c *schemaCheck) merge(sc *schemaCheck) {
+	if sc.inst != c.inst {
+		return
+	}
+	for key := range sc.props {
+		c.evaluateProperty(key)
+	}
+	for i := range sc.items {
+		c.evaluateItem(i)
+	}
+}
+
+// evaluateProperty 记录属性已被校验
+func (c *schemaCheck) evaluateProperty(key string) {
+	if c.props == nil {
+		c.props = make(map[string]bool)
+	}
+	c.props[key] = true
+}
+
+// evaluateItem 记录元素已被校验
+func (c *schemaCheck) evaluateItem(i int) {
+	if c.items == nil {
+		c.items = make(map[int]bool)
+	}
+	c.items[i] = true
+}
+
+// numberKeyword 关键字的数值
+func (c *schemaCheck) numberKeyword(keyword string) (*schemaNumber, error) {
+	node := c.kw[keyword]
+	if node == nil {
+		return nil, nil
+	}
+	value, ok := numberValue(node)
+	if !ok {
+		return nil, fmt.Errorf("schema %s 需要是数字", schemaLocation(c.path+"/"+keyword))
+	}
+	return value, nil
+}
+
+// count 关键字的非负整数值，不存在时返回 -1
+func (c *schemaCheck) count(keyword string) (int, error) {
+	value, err := c.numberKeyword(keyword)
+	if err != nil || value == nil {
+		return -1, err
+	}
+	n, ok := value.Int64()
+	if !ok || n < 0 {
+		return -1, fmt.Errorf("schema %s 需要是非负整数", schemaLocation(c.path+"/"+keyword))
+	}
+	return int(n), nil
+}
+
+// schemas 关键字中的 schema 数组，如 allOf、prefixItems
+func (c *schemaCheck) schemas(keyword string) ([]*jsonNode, error) {
+	node := c.kw[keyword]
+	if node == nil {
+		return nil, nil
+	}
+	if node.kind != nodeArray {
+		return nil, fmt.Errorf("schema %s 需要是数组", schemaLocation(c.path+"/"+keyword))
+	}
+	return node.elements, nil
+}
+
+// generic 适用于所有类型的关键字：type、enum、const
+func (c *schemaCheck) generic() error {
+	if typ := c.kw["type"]; typ != nil {
+		var names []string
+		switch typ.kind {
+		case nodeString:
+			names = []string{typ.stringValue()}
+		case nodeArray:
+			for _, el := range typ.elements {
+				names = append(names, el.stringValue())
+			}
+		default:
+			return fmt.Errorf("schema %s 需要是字符串或数组", schemaLocation(c.path+"/type"))
+		}
+		matched := false
+		for _, name := range names {
+			matched = matched || instanceIs(c.inst, name)
+		}
+		if !matched {
+			c.add("type", "类型应为 %s，实际为 %s", strings.Join(names, "、"), instanceType(c.inst))
+		}
+	}
+
+	if enum := c.kw["enum"]; enum != nil {
+		if enum.kind != nodeArray {
+			return fmt.Errorf("schema %s 需要是数组", schemaLocation(c.path+"/enum"))
+		}
+		matched := false
+		for _, el := range enum.elements {
+			matched = matched || jsonEqual(c.inst, el)
+		}
+		if !matched {
+			c.add("enum", "值应为 %s 中的一个", formatJSONTree(enum, FormatOptions{}))
+		}
+	}
+
+	if value := c.kw["const"]; value != nil && !jsonEqual(c.inst, value) {
+		c.add("const", "值应等于 %s", formatJSONTree(value, FormatOptions{}))
+	}
+	return nil
+}
+
+// number 数字的关键字：minimum、maximum、exclusiveMinimum、exclusiveMaximum、multipleOf
+func (c *schemaCheck) number() error {
+	value, ok := numberValue(c.inst)
+	if !ok {
+		return nil
+	}
+
+	bounds := []struct {
+		keyword string
+		fails   func(cmp int) bool
+		message string
+	}{
+		{"minimum", func(cmp int) bool { return cmp < 0 }, "值应大于等于 %s"},
+		{"maximum", func(cmp int) bool { return cmp > 0 }, "值应小于等于 %s"},
+		{"exclusiveMinimum", func(cmp int) bool { return cmp <= 0 }, "值应大于 %s"},
+		{"exclusiveMaximum", func(cmp int) bool { return cmp >= 0 }, "值应小于 %s"},
+	}
+	for _, b := range bounds {
+		limit, err := c.numberKeyword(b.keyword)
+		if err != nil {
+			return err
+		}
+		if limit != nil && b.fails(value.Cmp(limit)) {
+			c.add(b.keyword, b.message, c.kw[b.keyword].raw)
+		}
+	}
+
+	divisor, err := c.numberKeyword("multipleOf")
+	if err != nil {
+		return err
+	}
+	if divisor != nil {
+		if divisor.Sign() <= 0 {
+			return fmt.Errorf("schema %s 需要大于 0", schemaLocation(c.path+"/multipleOf"))
+		}
+		if !value.MultipleOf(divisor) {
+			c.add("multipleOf", "值应为 %s 的倍数", c.kw["multipleOf"].raw)
+		}
+	}
+	return nil
+}
+
+// string 字符串的关键字：minLength、maxLength、pattern、format
+func (c *schemaCheck) string() error {
+	if c.inst.kind != nodeString {
+		return nil
+	}
+	value := c.inst.stringValue()
+	length := utf8.RuneCountInString(value)
+
+	minLength, err := c.count("minLength")
+	if err != nil {
+		return err
+	}
+	if minLength >= 0 && length < minLength {
+		c.add("minLength", "长度应至少为 %d，实际为 %d", minLength, length)
+	}
+	maxLength, err := c.count("maxLength")
+	if err != nil {
+		return err
+	}
+	if maxLength >= 0 && length > maxLength {
+		c.add("maxLength", "长度应至多为 %d，实际为 %d", maxLength, length)
+	}
+
+	if pattern := c.kw["pattern"]; pattern != nil {
+		re, err := c.v.regexp(pattern, c.path+"/pattern")
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(value) {
+			c.add("pattern", "应匹配正则表达式 %s", re.String())
+		}
+	}
+
+	// 只检查能识别的格式，其他格式按规范作为注解忽略
+	if format := c.kw["format"]; format != nil && format.kind == nodeString {
+		for _, f := range schemaFormats {
+			if f.name == format.stringValue() && !f.match(value) {
+				c.add("format", "不是合法的 %s 格式", f.name)
+			}
+		}
+	}
+	return nil
+}
+
+// array 数组的关键字：prefixItems、items、additionalItems、contains、minItems、maxItems、uniqueItems
+func (c *schemaCheck) array() error {
+	if c.inst.kind != nodeArray {
+		return nil
+	}
+	elements := c.inst.elements
+
+	// draft-07 中 items 为数组时按位置校验，其余元素由 additionalItems 校验
+	prefixKeyword, restKeyword := "prefixItems", "items"
+	if items := c.kw["items"]; items != nil && items.kind == nodeArray {
+		prefixKeyword, restKeyword = "items", "additionalItems"
+	}
+	prefix, err := c.schemas(prefixKeyword)
+	if err != nil {
+		return err
+	}
+	rest := c.kw[restKeyword]
+	for i, el := range elements {
+		elPath := c.instPath + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(prefix):
+			c.evaluateItem(i)
+			err = c.sub(prefix[i], prefixKeyword+"/"+strconv.Itoa(i), el, elPath)
+		case rest != nil:
+			c.evaluateItem(i)
+			err = c.sub(rest, restKeyword, el, elPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if contains := c.kw["contains"]; contains != nil {
+		matched := 0
+		for i, el := range elements {
+			ok, err := c.v.valid(contains, c.path+"/contains", el, c.instPath+"/"+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+			if ok {
+				c.evaluateItem(i)
+				matched++
+			}
+		}
+		minContains, err := c.count("minContains")
+		if err != nil {
+			return err
+		}
+		maxContains, err := c.count("maxContains")
+		if err != nil {
+			return err
+		}
+		if minContains < 0 {
+			minContains = 1
+		}
+		if matched < minContains {
+			c.add("contains", "至少应有 %d 个元素符合 contains，实际有 %d 个", minContains, matched)
+		}
+		if maxContains >= 0 && matched > maxContains {
+			c.add("maxContains", "至多应有 %d 个元素符合 contains，实际有 %d 个", maxContains, matched)
+		}
+	}
+
+	minItems, err := c.count("minItems")
+	if err != nil {
+		return err
+	}
+	if minItems >= 0 && len(elements) < minItems {
+		c.add("minItems", "元素个数应至少为 %d，实际为 %d", minItems, len(elements))
+	}
+	maxItems, err := c.count("maxItems")
+	if err != nil {
+		return err
+	}
+	if maxItems >= 0 && len(elements) > maxItems {
+		c.add("maxItems", "元素个数应至多为 %d，实际为 %d", maxItems, len(elements))
+	}
+
+	if unique := c.kw["uniqueItems"]; unique != nil && unique.raw == "true" {
+	outer:
+		for i := range elements {
+			for j := 0; j < i; j++ {
+				if jsonEqual(elements[i], elements[j]) {
+					c.add("uniqueItems", "第 %d 个元素与第 %d 个元素重复", j+1, i+1)
+					break outer
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// object 对象的关键字：properties、patternProperties、additionalProperties、required、propertyNames、
+// minProperties、maxProperties、dependentRequired、dependentSchemas，以及 draft-07 的 dependencies
+func (c *schemaCheck) object() error {
+	if c.inst.kind != nodeObject {
+		return nil
+	}
+
+	present := make(map[string]bool, len(c.inst.members))
+	for _, m := range c.inst.members {
+		present[m.keyValue()] = true
+	}
+
+	var properties map[string]*jsonNode
+	if node := c.kw["properties"]; node != nil {
+		if node.kind != nodeObject {
+			return fmt.Errorf("schema %s 需要是对象", schemaLocation(c.path+"/properties"))
+		}
+		properties = c.v.keywordsOf(node)
+	}
+	var patterns []*jsonMember
+	if node := c.kw["patternProperties"]; node != nil {
+		if node.kind != nodeObject {
+			return fmt.Errorf("schema %s 需要是对象", schemaLocation(c.path+"/patternProperties"))
+		}
+		patterns = node.members
+	}
+	additional := c.kw["additionalProperties"]
+
+	for _, m := range c.inst.members {
+		key := m.keyValue()
+		memberPath := c.instPath + "/" + escapePointer(key)
+		evaluated := false
+		if schema, ok := properties[key]; ok {
+			evaluated = true
+			if err := c.sub(schema, "properties/"+escapePointer(key), m.value, memberPath); err != nil {
+				return err
+			}
+		}
+		for _, p := range patterns {
+			re, err := c.v.regexp(&jsonNode{kind: nodeString, raw: p.key}, c.path+"/patternProperties")
+			if err != nil {
+				return err
+			}
+			if re.MatchString(key) {
+				evaluated = true
+				if err := c.sub(p.value, "patternProperties/"+escapePointer(p.keyValue()), m.value, memberPath); err != nil {
+					return err
+				}
+			}
+		}
+		if evaluated || additional != nil {
+			c.evaluateProperty(key)
+		}
+		if evaluated || additional == nil {
+			continue
+		}
+		if additional.kind == nodeLiteral && additional.raw == "false" {
+			c.out = append(c.out, c.v.violation(m.value, memberPath, c.path+"/additionalProperties", "不允许的属性 %s", key))
+		} else if err := c.sub(additional, "additionalProperties", m.value, memberPath); err != nil {
+			return err
+		}
+	}
+
+	if required := c.kw["required"]; required != nil {
+		for _, name := range required.elements {
+			if !present[name.stringValue()] {
+				c.add("required", "缺少必需的属性 %s", name.stringValue())
+			}
+		}
+	}
+
+	if names := c.kw["propertyNames"]; names != nil {
+		for _, m := range c.inst.members {
+			name := &jsonNode{kind: nodeString, raw: m.key, offset: m.value.offset}
+			if err := c.sub(names, "propertyNames", name, c.instPath+"/"+escapePointer(m.keyValue())); err != nil {
+				return err
+			}
+		}
+	}
+
+	minProperties, err := c.count("minProperties")
+	if err != nil {
+		return err
+	}
+	if minProperties >= 0 && len(present) < minProperties {
+		c.add("minProperties", "属性个数应至少为 %d，实际为 %d", minProperties, len(present))
+	}
+	maxProperties, err := c.count("maxProperties")
+	if err != nil {
+		return err
+	}
+	if maxProperties >= 0 && len(present) > maxProperties {
+		c.add("maxProperties", "属性个数应至多为 %d，实际为 %d", maxProperties, len(present))
+	}
+
+	// dependencies 的值为数组时等同于 dependentRequired，为 schema 时等同于 dependentSchemas
+	for _, keyword := range []string{"dependentRequired", "dependentSchemas", "dependencies"} {
+		node := c.kw[keyword]
+		if node == nil {
+			continue
+		}
+		for _, m := range node.members {
+			name := m.keyValue()
+			if !present[name] {
+				continue
+			}
+			if m.value.kind == nodeArray {
+				for _, dep := range m.value.elements {
+					if !present[dep.stringValue()] {
+						c.add(keyword+"/"+escapePointer(name), "存在属性 %s 时必须有属性 %s", name, dep.stringValue())
+					}
+				}
+			} else if err := c.sub(m.value, keyword+"/"+escapePointer(name), c.inst, c.instPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// combinators 组合的关键字：allOf、anyOf、oneOf、not、if/then/else
+func (c *schemaCheck) combinators() error {
+	allOf, err := c.schemas("allOf")
+	if err != nil {
+		return err
+	}
+	for i, schema := range allOf {
+		if err := c.sub(schema, "allOf/"+strconv.Itoa(i), c.inst, c.instPath); err != nil {
+			return err
+		}
+	}
+
+	anyOf, err := c.schemas("anyOf")
+	if err != nil {
+		return err
+	}
+	if len(anyOf) > 0 {
+		matched, err := c.matching("anyOf", anyOf)
+		if err != nil {
+			return err
+		}
+		if len(matched) == 0 {
+			c.add("anyOf", "不符合 anyOf 中的任何一个 schema")
+		}
+	}
+
+	oneOf, err := c.schemas("oneOf")
+	if err != nil {
+		return err
+	}
+	if len(oneOf) > 0 {
+		matched, err := c.matching("oneOf", oneOf)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(matched) == 0:
+			c.add("oneOf", "不符合 oneOf 中的任何一个 schema")
+		case len(matched) > 1:
+			c.add("oneOf", "同时符合 oneOf 中的第 %s 个 schema，应只符合一个", strings.Join(matched, "、"))
+		}
+	}
+
+	if not := c.kw["not"]; not != nil {
+		ok, err := c.v.valid(not, c.path+"/not", c.inst, c.instPath)
+		if err != nil {
+			return err
+		}
+		if ok {
+			c.add("not", "不应符合 not 中的 schema")
+		}
+	}
+
+	if cond := c.kw["if"]; cond != nil {
+		ok, err := c.try(cond, "if")
+		if err != nil {
+			return err
+		}
+		branch := "else"
+		if ok {
+			branch = "then"
+		}
+		if schema := c.kw[branch]; schema != nil {
+			return c.sub(schema, branch, c.inst, c.instPath)
+		}
+	}
+	return nil
+}
+
+// unevaluated 在其他关键字之后校验没有被校验过的属性和元素：unevaluatedProperties、unevaluatedItems，
+// 经过 $ref、allOf、anyOf、oneOf、if/then/else、dependentSchemas 校验过的属性和元素也算作校验过
+func (c *schemaCheck) unevaluated() error {
+	if schema := c.kw["unevaluatedProperties"]; schema != nil && c.inst.kind == nodeObject {
+		for _, m := range c.inst.members {
+			key := m.keyValue()
+			if c.props[key] {
+				continue
+			}
+			memberPath := c.instPath + "/" + escapePointer(key)
+			if isFalseSchema(schema) {
+				c.out = append(c.out, c.v.violation(m.value, memberPath, c.path+"/unevaluatedProperties", "不允许未经校验的属性 %s", key))
+			} else if err := c.sub(schema, "unevaluatedProperties", m.value, memberPath); err != nil {
+				return err
+			}
+			c.evaluateProperty(key)
+		}
+	}
+
+	if schema := c.kw["unevaluatedItems"]; schema != nil && c.inst.kind == nodeArray {
+		for i, el := range c.inst.elements {
+			if c.items[i] {
+				continue
+			}
+			elPath := c.instPath + "/" + strconv.Itoa(i)
+			if isFalseSchema(schema) {
+				c.out = append(c.out, c.v.violation(el, elPath, c.path+"/unevaluatedItems", "不允许未经校验的第 %d 个元素", i+1))
+			} else if err := c.sub(schema, "unevaluatedItems", el, elPath); err != nil {
+				return err
+			}
+			c.evaluateItem(i)
+		}
+	}
+	return nil
+}
+
+// matching 值符合的子 schema 的下标
+func (c *schemaCheck) matching(keyword string, schemas []*jsonNode) ([]string, error) {
+	var matched []string
+	for i, schema := range schemas {
+		ok, err := c.try(schema, keyword+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, strconv.Itoa(i))
+		}
+	}
+	return matched, nil
+}
+
+// regexp 编译 schema 中的正则表达式，按 RE2 语法解析
+func (v *schemaValidator) regexp(node *jsonNode, keywordPath string) (*regexp.Regexp, error) {
+	if node.kind != nodeString {
+		return nil, fmt.Errorf("schema %s 需要是字符串", schemaLocation(keywordPath))
+	}
+	pattern := node.stringValue()
+	if re, ok := v.regexps[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s 中的正则表达式 %s 无法解析: %v", schemaLocation(keywordPath), pattern, err)
+	}
+	v.regexps[pattern] = re
+	return re, nil
+}
+
+// schemaLocation 关键字路径的写法，schema 本身为 #
+func schemaLocation(keywordPath string) string {
+	return "#" + keywordPath
+}
+
+// escapePointer 转义 JSON Pointer 中的 ~ 和 /
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// numberValue 数字节点的精确值，不是有限的数字时返回 false
+func numberValue(node *jsonNode) (*schemaNumber, bool) {
+	if node.kind != nodeNumber || node.nonFinite {
+		return nil, false
+	}
+	return parseSchemaNumber(node.raw)
+}
+
+// maxNumberExponent 指数的绝对值上限，超出时按上限处理
+const maxNumberExponent = 1 << 40
+
+// schemaNumber 按十进制表示的数字，值为 ±0.digits × 10^exp，digits 不含首尾的 0，值为 0 时为空。
+// 比较大小和判断整数都直接按十进制进行，1e999999 这样的指数不会展开为巨大的整数
+type schemaNumber struct {
+	neg    bool
+	digits string
+	exp    int64
+}
+
+// parseSchemaNumber 解析严格JSON写法的数字
+func parseSchemaNumber(raw string) (*schemaNumber, bool) {
+	n := &schemaNumber{}
+	text := raw
+	if strings.HasPrefix(text, "-") {
+		n.neg = true
+		text = text[1:]
+	}
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(strings.TrimPrefix(text[i+1:], "+"), 10, 64)
+		if err != nil && !isRangeError(err) {
+			return nil, false
+		}
+		n.exp = max(min(exp, maxNumberExponent), -maxNumberExponent)
+		text = text[:i]
+	}
+	intPart, fracPart, _ := strings.Cut(text, ".")
+	digits := intPart + fracPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return nil, false
+	}
+
+	n.exp += int64(len(intPart))
+	trimmed := strings.TrimLeft(digits, "0")
+	n.exp -= int64(len(digits) - len(trimmed))
+	n.digits = strings.TrimRight(trimmed, "0")
+	if n.digits == "" {
+		return &schemaNumber{}, true
+	}
+	return n, true
+}
+
+// Sign 符号：负数为 -1，0 为 0，正数为 1
+func (n *schemaNumber) Sign() int {
+	switch {
+	case n.digits == "":
+		return 0
+	case n.neg:
+		return -1
+	}
+	return 1
+}
+
+// Cmp 比较大小，n 小于、等于、大于 o 时分别返回 -1、0、1
+func (n *schemaNumber) Cmp(o *schemaNumber) int {
+	if sn, so := n.Sign(), o.Sign(); sn != so || sn == 0 {
+		return compareInts(sn, so)
+	}
+	cmp := compareInts(n.exp, o.exp)
+	if cmp == 0 {
+		// 首位都不是 0，指数相同时按数字串的字典序比较
+		cmp = strings.Compare(n.digits, o.digits)
+	}
+	if n.neg {
+		return -cmp
+	}
+	return cmp
+}
+
+// IsInt 是否为整数
+func (n *schemaNumber) IsInt() bool {
+	return n.exp >= int64(len(n.digits))
+}
+
+// Int64 整数且在 int64 范围内时返回其值
+func (n *schemaNumber) Int64() (int64, bool) {
+	if !n.IsInt() || n.exp > 19 {
+		return 0, false
+	}
+	text := n.digits + strings.Repeat("0", int(n.exp)-len(n.digits))
+	if n.neg {
+		text = "-" + text
+	}
+	value, err := strconv.ParseInt(text, 10, 64)
+	return value, err == nil
+}
+
+// MultipleOf n 是否为正数 d 的整数倍：把两者写为 V × 10^a 和 D × 10^b，判断 V × 10^(a-b) 能否被 D 整除，
+// 指数相差很大时用模幂计算，不展开 10 的幂
+func (n *schemaNumber) MultipleOf(d *schemaNumber) bool {
+	if n.Sign() == 0 {
+		return true
+	}
+	v, _ := new(big.Int).SetString(n.digits, 10)
+	divisor, _ := new(big.Int).SetString(d.digits, 10)
+	k := (n.exp - int64(len(n.digits))) - (d.exp - int64(len(d.digits)))
+	ten := big.NewInt(10)
+	if k >= 0 {
+		r := new(big.Int).Exp(ten, big.NewInt(k), divisor)
+		return r.Mul(r, v).Mod(r, divisor).Sign() == 0
+	}
+	// V 小于 10^len(digits)，除数乘以 10^(-k) 后更大时不可能整除
+	if -k > int64(len(n.digits)) {
+		return false
+	}
+	m := new(big.Int).Exp(ten, big.NewInt(-k), nil)
+	return v.Mod(v, m.Mul(m, divisor)).Sign() == 0
+}
+
+// compareInts 比较两个整数
+func compareInts[T int | int64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// instanceType 值在 JSON Schema 中的类型名，值为整数的数字为 integer
+func instanceType(node *jsonNode) string {
+	switch node.kind {
+	case nodeObject:
+		return "object"
+	case nodeArray:
+		return "array"
+	case nodeString:
+		return "string"
+	case nodeNumber:
+		if value, ok := numberValue(node); ok && value.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	if node.raw == "null" {
+		return "null"
+	}
+	return "boolean"
+}
+
+// instanceIs 值是否属于类型 name，integer 也属于 number
+func instanceIs(node *jsonNode, name string) bool {
+	typ := instanceType(node)
+	return typ == name || (name == "number" && typ == "integer")
+}
+
+// jsonEqual 两个值是否相等：数字按数值比较，对象不考虑键的顺序
+func jsonEqual(a, b *jsonNode) bool {
+	if a.kind != b.kind {
+		return false
+	}
+	switch a.kind {
+	case nodeNumber:
+		x, okX := numberValue(a)
+		y, okY := numberValue(b)
+		return okX && okY && x.Cmp(y) == 0
+	case nodeString:
+		return a.stringValue() == b.stringValue()
+	case nodeLiteral:
+		return a.raw == b.raw
+	case nodeArray:
+		if len(a.elements) != len(b.elements) {
+			return false
+		}
+		for i := range a.elements {
+			if !jsonEqual(a.elements[i], b.elements[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	x, y := objectMembers(a), objectMembers(b)
+	if len(x) != len(y) {
+		return false
+	}
+	for key, value := range x {
+		other, ok := y[key]
+		if !ok || !jsonEqual(value, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// objectMembers 对象的成员，重复的键取最后一个
+func objectMembers(node *jsonNode) map[string]*jsonNode {
+	members := make(map[string]*jsonNode, len(node.members))
+	for _, m := range node.members {
+		members[m.keyValue()] = m.value
+	}
+	return members
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSchema(t *testing.T) {
+	ctx := context.Background()
+
+	const order = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "id": {"type": "integer", "minimum": 1},
+    "email": {"type": "string", "format": "email"},
+    "items": {"type": "array", "items": {"$ref": "#/$defs/item"}, "minItems": 1}
+  },
+  "required": ["id", "items"],
+  "additionalProperties": false,
+  "$defs": {
+    "item": {
+      "type": "object",
+      "properties": {"sku": {"type": "string", "pattern": "^[A-Z]+-\\d+$"}, "qty": {"type": "integer", "exclusiveMinimum": 0}},
+      "required": ["sku"]
+    }
+  }
+}`
+
+	tests := []struct {
+		name    string
+		input   string
+		schema  string
+		dialect Dialect
+		want    []string // 每处不符合的 位置 实例路径 关键字路径
+		wantErr string
+	}{
+		{
+			name:   "符合schema",
+			input:  `{"id":1,"email":"a@b.com","items":[{"sku":"A-1","qty":2}]}`,
+			schema: order,
+		},
+		{
+			name:   "列出所有不符合的位置，包括经过$ref的路径",
+			input:  "{\"id\":0,\"email\":\"x\",\n\"items\":[{\"sku\":\"a1\",\"qty\":0},{}],\"extra\":true}",
+			schema: order,
+			want: []string{
+				"1:7 /id /properties/id/minimum",
+				"1:17 /email /properties/email/format",
+				"2:17 /items/0/sku /properties/items/items/$ref/properties/sku/pattern",
+				"2:28 /items/0/qty /properties/items/items/$ref/properties/qty/exclusiveMinimum",
+				"2:31 /items/1 /properties/items/items/$ref/required",
+				"2:43 /extra /additionalProperties",
+			},
+		},
+		{
+			name:  "draft-07的元组、definitions和dependencies",
+			input: `{"pair":["a","b",3],"card":"1"}`,
+			schema: `{"$schema":"http://json-schema.org/draft-07/schema#","properties":{` +
+				`"pair":{"items":[{"type":"string"},{"$ref":"#/definitions/n","type":"string"}],"additionalItems":false}},` +
+				`"dependencies":{"card":["billing"]},"definitions":{"n":{"type":"number"}}}`,
+			want: []string{
+				"1:14 /pair/1 /properties/pair/items/1/$ref/type",
+				"1:18 /pair/2 /properties/pair/additionalItems",
+				"1:1  /dependencies/card",
+			},
+		},
+		{
+			name:   "oneOf、not、if/then、enum按数值比较和锚点",
+			input:  `[{"kind":"a","n":1.0},{"kind":"b"},5,"x",2]`,
+			schema: `{"prefixItems":[{"if":{"properties":{"kind":{"const":"a"}}},"then":{"$ref":"#num"}},{"oneOf":[{"required":["kind"]},{"properties":{"kind":{"enum":["b"]}}}]}],"items":{"not":{"type":"string"},"enum":[1,5,"x"]},"uniqueItems":true,"$defs":{"n":{"$anchor":"num","properties":{"n":{"enum":[2]}}}}}`,
+			want: []string{
+				"1:18 /0/n /prefixItems/0/then/$ref/properties/n/enum",
+				"1:23 /1 /prefixItems/1/oneOf",
+				"1:38 /3 /items/not",
+				"1:42 /4 /items/enum",
+			},
+		},
+		{
+			name:   "unevaluatedProperties计入allOf、通过的anyOf分支和then中校验过的属性",
+			input:  `{"a":1,"b":2,"c":3,"d":4,"e":5}`,
+			schema: `{"allOf":[{"properties":{"a":true}}],"anyOf":[{"properties":{"b":true}},{"required":["x"],"properties":{"c":true}}],"if":true,"then":{"properties":{"d":true}},"unevaluatedProperties":false}`,
+			want: []string{
+				"1:18 /c /unevaluatedProperties",
+				"1:30 /e /unevaluatedProperties",
+			},
+		},
+		{
+			name:   "unevaluatedItems计入prefixItems和contains匹配的元素",
+			input:  `[true,"s",1,false]`,
+			schema: `{"prefixItems":[true],"allOf":[{"contains":{"type":"string"}}],"unevaluatedItems":{"type":"integer"}}`,
+			want:   []string{"1:13 /3 /unevaluatedItems/type"},
+		},
+		{
+			name:  "$dynamicRef解析到动态作用域中最外层的$dynamicAnchor",
+			input: `{"children":[{"data":1,"children":[]},{"daat":2}]}`,
+			schema: `{"$id":"https://example.com/strict-tree","$dynamicAnchor":"node","$ref":"tree","unevaluatedProperties":false,` +
+				`"$defs":{"tree":{"$id":"tree","$dynamicAnchor":"node","type":"object",` +
+				`"properties":{"data":true,"children":{"type":"array","items":{"$dynamicRef":"#node"}}}}}}`,
+			want: []string{"1:47 /children/1/daat /$ref/properties/children/items/$dynamicRef/unevaluatedProperties"},
+		},
+		{
+			name:    "不支持$recursiveRef",
+			input:   `{}`,
+			schema:  `{"$recursiveRef":"#"}`,
+			wantErr: "不支持 $recursiveRef",
+		},
+		{
+			name:    "JSON5中的注释",
+			input:   "// 订单\n{id: 1, items: [{sku: 'A-1'}]}",
+			schema:  order,
+			dialect: DialectJSON5,
+		},
+		{
+			name:   "按十进制精确比较数字",
+			input:  `[0.3,0.35,1e999999,1.5e-999999,100000000000000000001,-2E+1]`,
+			schema: `{"prefixItems":[{"multipleOf":0.1},{"multipleOf":0.1},{"type":"integer","multipleOf":5,"minimum":1e999998},{"exclusiveMinimum":0,"maximum":1e-999998},{"maximum":100000000000000000000},{"multipleOf":0.5,"const":-20.0}]}`,
+			want: []string{
+				"1:6 /1 /prefixItems/1/multipleOf",
+				"1:32 /4 /prefixItems/4/maximum",
+			},
+		},
+		{
+			name:    "不支持外部的$ref",
+			input:   `1`,
+			schema:  `{"$ref":"https://example.com/a.json"}`,
+			wantErr: "不支持外部的 $ref",
+		},
+		{
+			name:    "$ref循环引用",
+			input:   `1`,
+			schema:  `{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#/$defs/a"}},"$ref":"#/$defs/a"}`,
+			wantErr: "循环引用",
+		},
+		{
+			name:    "schema中的正则表达式无法解析",
+			input:   `"a"`,
+			schema:  `{"pattern":"(?<=a)"}`,
+			wantErr: "#/pattern 中的正则表达式",
+		},
+		{
+			name:    "文档语法错误",
+			input:   `{"a":}`,
+			schema:  `true`,
+			wantErr: "第 1 行第 6 列",
+		},
+		{
+			name:    "schema语法错误",
+			input:   `{}`,
+			schema:  `{"type":}`,
+			wantErr: "schema 格式错误",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := JSONProcessorService.ValidateSchema(ctx, tt.input, tt.schema, tt.dialect)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ValidateSchema() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateSchema() unexpected error = %v", err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, fmt.Sprintf("%d:%d %s %s", v.Line, v.Column, v.InstancePath, v.KeywordPath))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("ValidateSchema() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateSchemaLargeExponents(t *testing.T) {
+	const n = 20000
+	input := "[" + strings.Repeat("1e999999,", n-1) + "1e999999]"
+	start := time.Now()
+	violations, err := JSONProcessorService.ValidateSchema(context.Background(), input, `{"items":{"type":"integer","minimum":1,"multipleOf":5}}`, DialectJSON)
+	if err != nil {
+		t.Fatalf("ValidateSchema() unexpected error = %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("ValidateSchema() violations = %d, want 0", len(violations))
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ValidateSchema() took %v", elapsed)
+	}
+}
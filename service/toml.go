@@ -0,0 +1,535 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"sojson/zlog"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// TOMLToJSON 把TOML转换为JSON，保持键的顺序；日期时间输出为 RFC 3339 字符串，inf、nan 输出为 null 并在 Notes 中说明
+func (s *jsonProcessorService) TOMLToJSON(ctx context.Context, text string, opts FormatOptions) (*ConversionResult, error) {
+	// 先完整解码一次，重复的键、重复定义的表等语义错误在这里报告
+	var check map[string]interface{}
+	if err := toml.Unmarshal([]byte(text), &check); err != nil {
+		zlog.Errorf(ctx, "TOMLToJSON: toml unmarshal failed, input text length: %d, error: %v", len(text), err)
+		return nil, tomlError(text, err)
+	}
+
+	c := &tomlConverter{index: map[*jsonNode]map[string]*jsonNode{}}
+	c.parser.Reset([]byte(text))
+	root := &jsonNode{kind: nodeObject}
+	current := root
+	for c.parser.NextExpression() {
+		expr := c.parser.Expression()
+		switch expr.Kind {
+		case unstable.KeyValue:
+			c.keyValue(current, expr)
+		case unstable.Table:
+			current = c.table(root, expr.Key(), false)
+		case unstable.ArrayTable:
+			current = c.table(root, expr.Key(), true)
+		}
+	}
+	if err := c.parser.Error(); err != nil {
+		zlog.Errorf(ctx, "TOMLToJSON: toml parse failed, input text length: %d, error: %v", len(text), err)
+		return nil, fmt.Errorf("TOML格式错误: %v", err)
+	}
+
+	result := formatJSONTree(root, opts)
+	zlog.Infof(ctx, "TOMLToJSON: successfully converted, input length: %d, output length: %d, notes: %d", len(text), len(result), len(c.notes))
+	return &ConversionResult{Result: result, Notes: c.notes}, nil
+}
+
+// JSONToTOML 把JSON对象转换为TOML：键值对在前，子表和表数组在后，其余保持原始顺序；
+// 日期时间格式的字符串输出为TOML的日期时间，null、混合类型的数组等TOML无法表示的值返回 ConversionError
+func (s *jsonProcessorService) JSONToTOML(ctx context.Context, text string, opts FormatOptions) (*ConversionResult, error) {
+	if err := opts.Dialect.check(); err != nil {
+		return nil, err
+	}
+
+	tree, err := parseDialectTree(text, opts.Dialect)
+	if err != nil {
+		zlog.Errorf(ctx, "JSONToTOML: parseDialectTree failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+	if tree.kind != nodeObject {
+		return nil, &ConversionError{Position: newPosition(text, tree.offset), Path: "$", Target: "TOML", Reason: "TOML 文档的顶层需要是对象"}
+	}
+	if opts.KeyOrder != KeyOrderOriginal {
+		sortMembers(tree, opts.KeyOrder)
+	}
+
+	e := &tomlEncoder{text: text}
+	if err := e.table(tree, nil, "$"); err != nil {
+		zlog.Errorf(ctx, "JSONToTOML: encode failed, error: %v", err)
+		return nil, err
+	}
+
+	result := strings.TrimSuffix(e.sb.String(), "\n")
+	if opts.CRLF {
+		result = strings.ReplaceAll(result, "\n", "\r\n")
+	}
+	if opts.TrailingNewline && result != "" {
+		result += newlineOf(opts)
+	}
+
+	zlog.Infof(ctx, "JSONToTOML: successfully converted, input length: %d, output length: %d", len(text), len(result))
+	return &ConversionResult{Result: result}, nil
+}
+
+// tomlError 把 go-toml 的解码错误转换为带位置的语法错误
+func tomlError(text string, err error) error {
+	var decodeErr *toml.DecodeError
+	if !errors.As(err, &decodeErr) {
+		return fmt.Errorf("TOML格式错误: %s", strings.TrimPrefix(err.Error(), "toml: "))
+	}
+
+	// go-toml 的列号按字节计
+	row, column := decodeErr.Position()
+	offset := 0
+	for i := 1; i < row; i++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+	return &SyntaxError{
+		Position: newPosition(text, min(offset+column-1, len(text))),
+		Message:  strings.TrimPrefix(decodeErr.Error(), "toml: "),
+	}
+}
+
+// tomlConverter 按TOML表达式的顺序构建JSON语法树
+type tomlConverter struct {
+	parser unstable.Parser
+	notes  []ConversionNote
+	index  map[*jsonNode]map[string]*jsonNode // 对象的键到值的索引，避免逐个比较成员
+}
+
+// member 返回对象中 key 对应的值，不存在时返回 nil
+func (c *tomlConverter) member(obj *jsonNode, key string) *jsonNode {
+	return c.index[obj][key]
+}
+
+// add 在对象末尾追加成员
+func (c *tomlConverter) add(obj *jsonNode, key string, value *jsonNode) {
+	if c.index[obj] == nil {
+		c.index[obj] = map[string]*jsonNode{}
+	}
+	c.index[obj][key] = value
+	appendMember(obj, key, value)
+}
+
+// table 找到或创建 [a.b] 对应的表；array 为 true 时在 [[a.b]] 对应的表数组末尾追加一个表
+func (c *tomlConverter) table(root *jsonNode, key unstable.Iterator, array bool) *jsonNode {
+	node := root
+	for key.Next() {
+		name := string(key.Node().Data)
+		child := c.member(node, name)
+		if key.IsLast() && array {
+			if child == nil {
+				child = &jsonNode{kind: nodeArray}
+				c.add(node, name, child)
+			}
+			elem := &jsonNode{kind: nodeObject}
+			child.elements = append(child.elements, elem)
+			return elem
+		}
+		if child == nil {
+			child = &jsonNode{kind: nodeObject}
+			c.add(node, name, child)
+		}
+		if child.kind == nodeArray {
+			// 表数组中最后一个表
+			child = child.elements[len(child.elements)-1]
+		}
+		node = child
+	}
+	return node
+}
+
+// keyValue 把键值对加入表中，点分隔的键逐级创建子表
+func (c *tomlConverter) keyValue(table *jsonNode, expr *unstable.Node) {
+	key := expr.Key()
+	first := key.Node()
+	node := table
+	for key.Next() {
+		name := string(key.Node().Data)
+		if key.IsLast() {
+			c.add(node, name, c.value(expr.Value(), first))
+			return
+		}
+		child := c.member(node, name)
+		if child == nil {
+			child = &jsonNode{kind: nodeObject}
+			c.add(node, name, child)
+		}
+		node = child
+	}
+}
+
+// value 转换值，at 为值所属的键，用于定位转换说明
+func (c *tomlConverter) value(n *unstable.Node, at *unstable.Node) *jsonNode {
+	data := string(n.Data)
+	switch n.Kind {
+	case unstable.String:
+		return newStringNode(data)
+	case unstable.Bool:
+		return newLiteralNode(data)
+	case unstable.Integer, unstable.Float:
+		switch strings.TrimLeft(data, "+-") {
+		case "inf", "nan":
+			pos := c.parser.Shape(at.Raw).Start
+			c.notes = append(c.notes, ConversionNote{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf("%s 无法用JSON表示，已输出为 null", data)})
+			return newLiteralNode("null")
+		}
+		if node, ok := newNumberNode(data); ok {
+			return node
+		}
+		return newStringNode(data)
+	case unstable.LocalDate, unstable.LocalTime, unstable.LocalDateTime, unstable.DateTime:
+		// 日期和时间之间的空格或小写 t 统一为 T
+		if len(data) > 10 && (data[10] == ' ' || data[10] == 't') {
+			data = data[:10] + "T" + data[11:]
+		}
+		return newStringNode(data)
+	case unstable.Array:
+		node := &jsonNode{kind: nodeArray}
+		for it := n.Children(); it.Next(); {
+			node.elements = append(node.elements, c.value(it.Node(), at))
+		}
+		return node
+	case unstable.InlineTable:
+		node := &jsonNode{kind: nodeObject}
+		for it := n.Children(); it.Next(); {
+			c.keyValue(node, it.Node())
+		}
+		return node
+	}
+	return newStringNode(data)
+}
+
+// tomlEncoder 把JSON语法树写为TOML
+type tomlEncoder struct {
+	text string
+	sb   strings.Builder
+}
+
+// table 写出表的内容：先写键值对，再依次写子表和表数组；keys 为表头中已转义的键
+func (e *tomlEncoder) table(node *jsonNode, keys []string, path string) error {
+	var tables []*jsonMember
+	for _, m := range node.members {
+		if isTOMLTable(m.value) || isTOMLTableArray(m.value) {
+			tables = append(tables, m)
+			continue
+		}
+		value, err := e.value(m.value, path+"."+m.keyValue())
+		if err != nil {
+			return err
+		}
+		e.comments(m.value.leading)
+		fmt.Fprintf(&e.sb, "%s = %s\n", tomlKey(m.keyValue()), value)
+	}
+
+	for _, m := range tables {
+		childKeys := append(append([]string(nil), keys...), tomlKey(m.keyValue()))
+		childPath := path + "." + m.keyValue()
+		header := strings.Join(childKeys, ".")
+		if m.value.kind == nodeObject {
+			// 只包含子表的表可以省略表头
+			if len(m.value.members) == 0 || len(m.value.members) > countTOMLTables(m.value) {
+				e.header("["+header+"]", m.value.leading)
+			}
+			if err := e.table(m.value, childKeys, childPath); err != nil {
+				return err
+			}
+			continue
+		}
+		for i, elem := range m.value.elements {
+			e.header("[["+header+"]]", elem.leading)
+			if err := e.table(elem, childKeys, fmt.Sprintf("%s[%d]", childPath, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// header 写出表头，与前面的内容之间空一行
+func (e *tomlEncoder) header(header string, comments []string) {
+	if e.sb.Len() > 0 {
+		e.sb.WriteString("\n")
+	}
+	e.comments(comments)
+	e.sb.WriteString(header + "\n")
+}
+
+// comments 把 JSONC、JSON5 的注释写为 # 注释
+func (e *tomlEncoder) comments(comments []string) {
+	if text := hashComments(comments); text != "" {
+		e.sb.WriteString(text + "\n")
+	}
+}
+
+// value 把值写为TOML的行内写法
+func (e *tomlEncoder) value(node *jsonNode, path string) (string, error) {
+	switch node.kind {
+	case nodeObject:
+		if len(node.members) == 0 {
+			return "{}", nil
+		}
+		parts := make([]string, 0, len(node.members))
+		for _, m := range node.members {
+			value, err := e.value(m.value, path+"."+m.keyValue())
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(m.keyValue())+" = "+value)
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case nodeArray:
+		return e.array(node, path)
+	case nodeString:
+		str := node.stringValue()
+		if tomlDateTimeKind(str) != "" {
+			return str, nil
+		}
+		return tomlString(str), nil
+	case nodeNumber:
+		return e.number(node, path, false)
+	}
+	if node.raw == "null" {
+		return "", e.errorf(node, path, "TOML 不支持 null，可以删除该键或改为空字符串")
+	}
+	return node.raw, nil
+}
+
+// array 写出数组。TOML 0.5 要求数组元素类型一致，为兼容仍在使用旧版本解析器的工具，混合类型的数组报错；
+// 整数和浮点数混合时整数按浮点数输出，日期时间字符串只在全部元素为同一种日期时间时按日期时间输出
+func (e *tomlEncoder) array(node *jsonNode, path string) (string, error) {
+	if len(node.elements) == 0 {
+		return "[]", nil
+	}
+
+	first := tomlTypeName(node.elements[0])
+	hasFloat := false
+	dateTime := ""
+	if node.elements[0].kind == nodeString {
+		dateTime = tomlDateTimeKind(node.elements[0].stringValue())
+	}
+	for i, elem := range node.elements {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if elem.kind == nodeLiteral && elem.raw == "null" {
+			return "", e.errorf(elem, elemPath, "TOML 不支持 null")
+		}
+		// 空数组可以与任意元素类型的数组并列
+		switch name := tomlTypeName(elem); {
+		case name == first || name == "数组" && strings.HasSuffix(first, "数组"):
+		case first == "数组" && strings.HasSuffix(name, "数组"):
+			first = name
+		default:
+			return "", e.errorf(elem, elemPath, fmt.Sprintf("TOML 数组的元素类型需要一致，数组中同时有%s和%s", first, name))
+		}
+		if elem.kind == nodeNumber && (elem.nonFinite || strings.ContainsAny(elem.raw, ".eE")) {
+			hasFloat = true
+		}
+		if elem.kind == nodeString && tomlDateTimeKind(elem.stringValue()) != dateTime {
+			dateTime = ""
+		}
+	}
+
+	parts := make([]string, 0, len(node.elements))
+	for i, elem := range node.elements {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		var value string
+		var err error
+		switch {
+		case elem.kind == nodeNumber:
+			value, err = e.number(elem, elemPath, hasFloat)
+		case elem.kind == nodeString && dateTime == "":
+			value = tomlString(elem.stringValue())
+		default:
+			value, err = e.value(elem, elemPath)
+		}
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, value)
+	}
+	return "[" + strings.Join(parts, ", ") + "]", nil
+}
+
+// number 写出数字；asFloat 为 true 时整数也按浮点数输出
+func (e *tomlEncoder) number(node *jsonNode, path string, asFloat bool) (string, error) {
+	if node.nonFinite {
+		switch {
+		case node.raw == "NaN":
+			return "nan", nil
+		case strings.HasPrefix(node.raw, "-"):
+			return "-inf", nil
+		}
+		return "inf", nil
+	}
+	if isFloat := strings.ContainsAny(node.raw, ".eE"); isFloat || asFloat {
+		if _, err := strconv.ParseFloat(node.raw, 64); isRangeError(err) {
+			return "", e.errorf(node, path, fmt.Sprintf("浮点数 %s 超出 TOML 的 64 位浮点数范围", node.raw))
+		}
+		if isFloat {
+			return node.raw, nil
+		}
+		return node.raw + ".0", nil
+	}
+	if _, err := strconv.ParseInt(node.raw, 10, 64); err != nil {
+		return "", e.errorf(node, path, fmt.Sprintf("整数 %s 超出 TOML 的 64 位整数范围", node.raw))
+	}
+	return node.raw, nil
+}
+
+func (e *tomlEncoder) errorf(node *jsonNode, path string, reason string) error {
+	return &ConversionError{Position: newPosition(e.text, node.offset), Path: path, Target: "TOML", Reason: reason}
+}
+
+// tomlTypeName 值在TOML中的类型名称，整数和浮点数视为同一种；
+// 数组按元素类型区分，如 [[1], ["a"]] 中的两个元素分别为数字数组和字符串数组，空数组为"数组"
+func tomlTypeName(node *jsonNode) string {
+	switch node.kind {
+	case nodeObject:
+		return "表"
+	case nodeArray:
+		if len(node.elements) == 0 {
+			return "数组"
+		}
+		return tomlTypeName(node.elements[0]) + "数组"
+	case nodeString:
+		return "字符串"
+	case nodeNumber:
+		return "数字"
+	}
+	if node.raw == "null" {
+		return "null"
+	}
+	return "布尔值"
+}
+
+// isTOMLTable 值是否写为 [表]
+func isTOMLTable(node *jsonNode) bool {
+	return node.kind == nodeObject
+}
+
+// isTOMLTableArray 值是否写为 [[表数组]]：非空且元素全部是对象
+func isTOMLTableArray(node *jsonNode) bool {
+	if node.kind != nodeArray || len(node.elements) == 0 {
+		return false
+	}
+	for _, elem := range node.elements {
+		if elem.kind != nodeObject {
+			return false
+		}
+	}
+	return true
+}
+
+// countTOMLTables 对象中写为子表或表数组的成员数
+func countTOMLTables(node *jsonNode) int {
+	count := 0
+	for _, m := range node.members {
+		if isTOMLTable(m.value) || isTOMLTableArray(m.value) {
+			count++
+		}
+	}
+	return count
+}
+
+// tomlBareKey 可以不加引号的键
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey 写出键，不能作为裸键时加引号
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString 写出TOML基本字符串
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+var (
+	// tomlDateTimePattern 日期、本地日期时间或带时区的日期时间
+	tomlDateTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:[Tt ]\d{2}:\d{2}:\d{2}(?:\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?$`)
+	// tomlTimePattern 本地时间
+	tomlTimePattern = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(?:\.\d+)?$`)
+)
+
+// tomlDateTimeKind 判断字符串是否为TOML的日期时间，返回 offset_datetime、local_datetime、local_date、local_time，
+// 不是时返回空字符串
+func tomlDateTimeKind(s string) string {
+	if tomlTimePattern.MatchString(s) {
+		if _, err := time.Parse("15:04:05.999999999", s); err == nil {
+			return "local_time"
+		}
+		return ""
+	}
+
+	match := tomlDateTimePattern.FindStringSubmatch(s)
+	if match == nil {
+		return ""
+	}
+	if len(s) == len("2006-01-02") {
+		if _, err := time.Parse("2006-01-02", s); err == nil {
+			return "local_date"
+		}
+		return ""
+	}
+
+	normalized := strings.ToUpper(s[:10] + "T" + s[11:])
+	if match[1] == "" {
+		if _, err := time.Parse("2006-01-02T15:04:05.999999999", normalized); err == nil {
+			return "local_datetime"
+		}
+		return ""
+	}
+	if _, err := time.Parse(time.RFC3339Nano, normalized); err == nil {
+		return "offset_datetime"
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTOMLToJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		notes   int
+		wantErr string
+	}{
+		{
+			name:  "保持键和表的顺序",
+			input: "title = \"x\"\n[server]\nport = 8080\nhost = \"a\"\n[client]\nretry = true",
+			want:  `{"title":"x","server":{"port":8080,"host":"a"},"client":{"retry":true}}`,
+		},
+		{
+			name:  "点分隔的键和行内表",
+			input: "site.\"google.com\" = true\npoint = { x = 1, y.z = 2 }",
+			want:  `{"site":{"google.com":true},"point":{"x":1,"y":{"z":2}}}`,
+		},
+		{
+			name:  "表数组和子表",
+			input: "[[fruits]]\nname = \"apple\"\n[fruits.physical]\ncolor = \"red\"\n[[fruits.varieties]]\nname = \"fuji\"\n[[fruits]]\nname = \"banana\"",
+			want:  `{"fruits":[{"name":"apple","physical":{"color":"red"},"varieties":[{"name":"fuji"}]},{"name":"banana"}]}`,
+		},
+		{
+			name:  "日期时间转换为字符串",
+			input: "odt = 1979-05-27 07:32:00-08:00\nldt = 1979-05-27t07:32:00.5\nld = 1979-05-27\nlt = 07:32:00",
+			want:  `{"odt":"1979-05-27T07:32:00-08:00","ldt":"1979-05-27T07:32:00.5","ld":"1979-05-27","lt":"07:32:00"}`,
+		},
+		{
+			name:  "数字写法",
+			input: "hex = 0xDEAD_BEEF\noct = 0o17\nbin = 0b11\nbig = 1_000\nf = +6.626e-34",
+			want:  `{"hex":3735928559,"oct":15,"bin":3,"big":1000,"f":6.626e-34}`,
+		},
+		{
+			name:  "非有限浮点数",
+			input: "a = inf\nb = [nan]",
+			want:  `{"a":null,"b":[null]}`,
+			notes: 2,
+		},
+		{
+			name:    "重复的键",
+			input:   "a = 1\na = 2",
+			wantErr: "already defined",
+		},
+		{
+			name:    "语法错误",
+			input:   "a = \nb = 1",
+			wantErr: "第 1 行",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.TOMLToJSON(ctx, tt.input, FormatOptions{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("TOMLToJSON() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TOMLToJSON() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("TOMLToJSON() = %s, want %s", result.Result, tt.want)
+			}
+			if len(result.Notes) != tt.notes {
+				t.Errorf("TOMLToJSON() notes = %v, want %d", result.Notes, tt.notes)
+			}
+		})
+	}
+}
+
+func TestJSONToTOML(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		errPath string // 期望 ConversionError 的路径
+	}{
+		{
+			name:  "键值对在子表之前",
+			input: `{"server":{"port":8080},"title":"x","owner":{"name":"tom","dob":"1979-05-27T07:32:00Z"}}`,
+			want:  "title = \"x\"\n\n[server]\nport = 8080\n\n[owner]\nname = \"tom\"\ndob = 1979-05-27T07:32:00Z",
+		},
+		{
+			name:  "表数组和省略表头",
+			input: `{"a":{"b":{"c":1}},"items":[{"id":1},{"id":2,"tags":["x"]}]}`,
+			want:  "[a.b]\nc = 1\n\n[[items]]\nid = 1\n\n[[items]]\nid = 2\ntags = [\"x\"]",
+		},
+		{
+			name:  "需要加引号的键和嵌套数组中的行内表",
+			input: `{"k y":{"z":[[{"w":1}],[]],"e":{}}}`,
+			want:  "[\"k y\"]\nz = [[{ w = 1 }], []]\n\n[\"k y\".e]",
+		},
+		{
+			name:  "日期时间字符串",
+			input: `{"d":"2024-02-29","t":"12:30:00","dates":["2024-01-01","2024-02-01"],"mixed":["2024-01-01","x"],"bad":"2023-02-29"}`,
+			want:  "d = 2024-02-29\nt = 12:30:00\ndates = [2024-01-01, 2024-02-01]\nmixed = [\"2024-01-01\", \"x\"]\nbad = \"2023-02-29\"",
+		},
+		{
+			name:  "整数和浮点数混合",
+			input: `{"a":[1,2.5],"b":"line\n\"q\""}`,
+			want:  "a = [1.0, 2.5]\nb = \"line\\n\\\"q\\\"\"",
+		},
+		{
+			name:    "null",
+			input:   `{"a":{"b":null}}`,
+			errPath: "$.a.b",
+		},
+		{
+			name:    "混合类型的数组",
+			input:   `{"a":[1,"x"]}`,
+			errPath: "$.a[1]",
+		},
+		{
+			name:    "嵌套数组的元素类型不一致",
+			input:   `{"a":[[],[1],["x"]]}`,
+			errPath: "$.a[2]",
+		},
+		{
+			name:    "超出范围的整数",
+			input:   `{"n":18446744073709551615}`,
+			errPath: "$.n",
+		},
+		{
+			name:    "超出范围的浮点数",
+			input:   `{"x":{"f":-1e400}}`,
+			errPath: "$.x.f",
+		},
+		{
+			name:    "按浮点数输出时超出范围的整数",
+			input:   `{"a":[0.5,1` + strings.Repeat("0", 400) + `]}`,
+			errPath: "$.a[1]",
+		},
+		{
+			name:    "顶层不是对象",
+			input:   `[1]`,
+			errPath: "$",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.JSONToTOML(ctx, tt.input, FormatOptions{})
+			if tt.errPath != "" {
+				var convErr *ConversionError
+				if !errors.As(err, &convErr) {
+					t.Fatalf("JSONToTOML() error = %v, want ConversionError", err)
+				}
+				if convErr.Path != tt.errPath {
+					t.Errorf("ConversionError.Path = %s, want %s", convErr.Path, tt.errPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONToTOML() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("JSONToTOML() = %q, want %q", result.Result, tt.want)
+			}
+
+			// 输出需要是合法的TOML
+			if _, err := JSONProcessorService.TOMLToJSON(ctx, result.Result, FormatOptions{}); err != nil {
+				t.Errorf("TOMLToJSON() of output error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"sojson/zlog"
+)
+
+// XMLConvention XML与JSON之间的对应约定
+type XMLConvention string
+
+const (
+	// XMLAttr 属性写为 "@名称"，与属性或子元素并存的文本写为 "#text"，只有文本的元素直接写为字符串
+	XMLAttr XMLConvention = "attr"
+	// XMLBadgerFish BadgerFish：每个元素都是对象，文本写为 "$"，属性写为 "@名称"，命名空间声明写在 "@xmlns" 中
+	XMLBadgerFish XMLConvention = "badgerfish"
+	// XMLParker Parker：省略根元素，忽略属性，文本按数字、布尔值推断类型，子元素同名且重复时整体转换为数组
+	XMLParker XMLConvention = "parker"
+)
+
+// ParseXMLConvention 解析XML约定名称，空字符串表示 attr
+func ParseXMLConvention(name string) (XMLConvention, error) {
+	switch convention := XMLConvention(strings.ToLower(strings.TrimSpace(name))); convention {
+	case "":
+		return XMLAttr, nil
+	case XMLAttr, XMLBadgerFish, XMLParker:
+		return convention, nil
+	}
+	return "", fmt.Errorf("不支持的XML约定: %s，可选 attr、badgerfish、parker", name)
+}
+
+// XMLOptions XML转换选项
+type XMLOptions struct {
+	FormatOptions
+	Convention      XMLConvention
+	ForceArrays     []string // 总是转换为数组的元素：带点的为从根元素开始的路径，如 catalog.book，不带点的为元素名
+	StripNamespaces bool     // 去掉元素和属性名的命名空间前缀，并忽略命名空间声明
+	Root            string   // JSON转XML时的根元素名，为空时使用只有一个键的顶层对象的键，否则为 root
+}
+
+// xmlElement 解析出的XML元素
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     []string // 去掉首尾空白后非空的文本片段，与子元素混合时用空格连接
+	offset   int      // 开始标签在输入中的字节偏移
+}
+
+// XMLToJSON 按约定把XML转换为JSON，保持元素和属性的顺序，重复的子元素合并为数组
+func (s *jsonProcessorService) XMLToJSON(ctx context.Context, text string, opts XMLOptions) (*ConversionResult, error) {
+	if opts.Convention == "" {
+		opts.Convention = XMLAttr
+	}
+	root, err := parseXML(text, opts.StripNamespaces)
+	if err != nil {
+		zlog.Errorf(ctx, "XMLToJSON: parseXML failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+
+	c := &xmlConverter{text: text, opts: opts}
+	var tree *jsonNode
+	if opts.Convention == XMLParker {
+		tree = c.parker(root, root.name)
+	} else {
+		tree = &jsonNode{kind: nodeObject}
+		appendMember(tree, root.name, c.value(root, root.name))
+	}
+	c.flushNotes()
+
+	result := formatJSONTree(tree, opts.FormatOptions)
+	zlog.Infof(ctx, "XMLToJSON: successfully converted, convention: %s, input length: %d, output length: %d", opts.Convention, len(text), len(result))
+	return &ConversionResult{Result: result, Notes: c.notes}, nil
+}
+
+// JSONToXML 按约定把JSON转换为XML
+func (s *jsonProcessorService) JSONToXML(ctx context.Context, text string, opts XMLOptions) (*ConversionResult, error) {
+	if err := opts.Dialect.check(); err != nil {
+		return nil, err
+	}
+	if opts.Convention == "" {
+		opts.Convention = XMLAttr
+	}
+
+	tree, err := parseDialectTree(text, opts.Dialect)
+	if err != nil {
+		zlog.Errorf(ctx, "JSONToXML: parseDialectTree failed, input text length: %d, error: %v", len(text), err)
+		return nil, err
+	}
+
+	root, path, value := opts.Root, "$", tree
+	if root == "" {
+		root = "root"
+		// {"catalog": {...}} 以唯一的键作为根元素，与 attr、badgerfish 约定下XML转换出的JSON对应
+		if tree.kind == nodeObject && len(tree.members) == 1 && !isXMLSpecialKey(tree.members[0].keyValue(), opts.Convention) {
+			root, path, value = tree.members[0].keyValue(), "$."+tree.members[0].keyValue(), tree.members[0].value
+		}
+	}
+
+	e := &xmlEncoder{text: text, convention: opts.Convention, newline: "\n"}
+	if !opts.compact() {
+		e.indent = strings.Repeat(" ", opts.Indent)
+		if opts.UseTabs {
+			e.indent = "\t"
+		}
+	} else {
+		e.newline = ""
+	}
+	e.sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + e.newline)
+	if value.kind == nodeArray {
+		// 根元素只能有一个，数组的元素写为根元素下的 item
+		value = &jsonNode{kind: nodeObject, members: []*jsonMember{{key: `"item"`, value: value}}, offset: value.offset}
+	}
+	if err := e.element(root, value, path, 0); err != nil {
+		zlog.Errorf(ctx, "JSONToXML: encode failed, error: %v", err)
+		return nil, err
+	}
+
+	result := strings.TrimSuffix(e.sb.String(), "\n")
+	if opts.CRLF {
+		result = strings.ReplaceAll(result, "\n", "\r\n")
+	}
+	if opts.TrailingNewline {
+		result += newlineOf(opts.FormatOptions)
+	}
+
+	zlog.Infof(ctx, "JSONToXML: successfully converted, convention: %s, input length: %d, output length: %d", opts.Convention, len(text), len(result))
+	return &ConversionResult{Result: result}, nil
+}
+
+// parseXML 解析XML为元素树，忽略声明、注释和处理指令
+func parseXML(text string, stripNamespaces bool) (*xmlElement, error) {
+	dec := xml.NewDecoder(strings.NewReader(text))
+	var root *xmlElement
+	var stack []*xmlElement
+	for {
+		offset := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, xmlError(text, int(dec.InputOffset()), err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && root != nil {
+				return nil, &SyntaxError{Position: newPosition(text, offset), Message: "XML只能有一个根元素"}
+			}
+			el := &xmlElement{name: xmlName(t.Name, stripNamespaces), offset: offset}
+			seen := map[string]bool{}
+			for _, attr := range t.Attr {
+				name := xmlName(attr.Name, false)
+				if seen[name] {
+					return nil, &SyntaxError{Position: newPosition(text, offset), Message: fmt.Sprintf("元素 <%s> 的属性 %s 重复", el.name, name)}
+				}
+				seen[name] = true
+				if stripNamespaces && (attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" && attr.Name.Space == "") {
+					continue
+				}
+				attr.Name = xml.Name{Local: xmlName(attr.Name, stripNamespaces)}
+				el.attrs = append(el.attrs, attr)
+			}
+			if len(stack) == 0 {
+				root = el
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			name := xmlName(t.Name, stripNamespaces)
+			if len(stack) == 0 || stack[len(stack)-1].name != name {
+				expected := "文本结尾"
+				if len(stack) > 0 {
+					expected = "</" + stack[len(stack)-1].name + ">"
+				}
+				return nil, &SyntaxError{Position: newPosition(text, offset), Expected: expected, Message: fmt.Sprintf("结束标签 </%s> 与开始标签不匹配，期望 %s", name, expected)}
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			content := strings.TrimSpace(string(t))
+			if content == "" {
+				continue
+			}
+			if len(stack) == 0 {
+				return nil, &SyntaxError{Position: newPosition(text, offset), Message: "根元素之外不能有文本"}
+			}
+			top := stack[len(stack)-1]
+			top.text = append(top.text, content)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, &SyntaxError{Position: newPosition(text, len(text)), Expected: "</" + stack[len(stack)-1].name + ">", Message: fmt.Sprintf("元素 <%s> 没有结束标签", stack[len(stack)-1].name)}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("输入中没有XML元素")
+	}
+	return root, nil
+}
+
+// xmlError 把 encoding/xml 的错误转换为带位置的语法错误
+func xmlError(text string, offset int, err error) error {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &SyntaxError{Position: newPosition(text, offset), Message: syntaxErr.Msg}
+	}
+	return fmt.Errorf("XML格式错误: %v", err)
+}
+
+// xmlName 返回带前缀的名称，strip 为 true 时去掉前缀
+func xmlName(name xml.Name, strip bool) string {
+	if name.Space == "" || strip {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// xmlConverter 按约定把元素树转换为JSON语法树
+type xmlConverter struct {
+	text  string
+	opts  XMLOptions
+	notes []ConversionNote
+
+	// Parker 约定丢弃的内容，转换结束后各汇总为一条说明
+	droppedAttrs, droppedText int
+	firstAttrs, firstText     int // 第一处被丢弃内容所在元素的偏移
+}
+
+// forceArray 元素是否总是转换为数组
+func (c *xmlConverter) forceArray(name, path string) bool {
+	for _, p := range c.opts.ForceArrays {
+		p = strings.TrimPrefix(strings.TrimSpace(p), "$.")
+		if p == path || !strings.Contains(p, ".") && p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addChildren 把子元素加入对象，同名的子元素合并为数组，数组位于第一个该名称子元素的位置
+func (c *xmlConverter) addChildren(obj *jsonNode, el *xmlElement, path string, convert func(*xmlElement, string) *jsonNode) {
+	members := map[string]*jsonMember{}
+	grouped := map[string]bool{} // 已经是数组的子元素
+	for _, child := range el.children {
+		childPath := path + "." + child.name
+		value := convert(child, childPath)
+		if m, ok := members[child.name]; ok {
+			if !grouped[child.name] {
+				m.value = &jsonNode{kind: nodeArray, elements: []*jsonNode{m.value}}
+				grouped[child.name] = true
+			}
+			m.value.elements = append(m.value.elements, value)
+			continue
+		}
+		if c.forceArray(child.name, childPath) {
+			value = &jsonNode{kind: nodeArray, elements: []*jsonNode{value}}
+			grouped[child.name] = true
+		}
+		appendMember(obj, child.name, value)
+		members[child.name] = obj.members[len(obj.members)-1]
+	}
+}
+
+// value 按 attr 或 badgerfish 约定转换元素
+func (c *xmlConverter) value(el *xmlElement, path string) *jsonNode {
+	text := strings.Join(el.text, " ")
+	if c.opts.Convention == XMLAttr && len(el.attrs) == 0 && len(el.children) == 0 {
+		if len(el.text) == 0 {
+			return newLiteralNode("null")
+		}
+		return newStringNode(text)
+	}
+
+	obj := &jsonNode{kind: nodeObject}
+	var namespaces *jsonNode
+	for _, attr := range el.attrs {
+		name := attr.Name.Local
+		if c.opts.Convention == XMLBadgerFish && (name == "xmlns" || strings.HasPrefix(name, "xmlns:")) {
+			if namespaces == nil {
+				namespaces = &jsonNode{kind: nodeObject}
+				appendMember(obj, "@xmlns", namespaces)
+			}
+			prefix := strings.TrimPrefix(strings.TrimPrefix(name, "xmlns"), ":")
+			if prefix == "" {
+				prefix = "$"
+			}
+			appendMember(namespaces, prefix, newStringNode(attr.Value))
+			continue
+		}
+		appendMember(obj, "@"+name, newStringNode(attr.Value))
+	}
+	c.addChildren(obj, el, path, c.value)
+	if len(el.text) > 0 {
+		key := "#text"
+		if c.opts.Convention == XMLBadgerFish {
+			key = "$"
+		}
+		appendMember(obj, key, newStringNode(text))
+	}
+	return obj
+}
+
+// parker 按 Parker 约定转换元素
+func (c *xmlConverter) parker(el *xmlElement, path string) *jsonNode {
+	if len(el.attrs) > 0 {
+		if c.droppedAttrs == 0 {
+			c.firstAttrs = el.offset
+		}
+		c.droppedAttrs += len(el.attrs)
+	}
+	if len(el.children) == 0 {
+		if len(el.text) == 0 {
+			return newLiteralNode("null")
+		}
+		return parkerScalar(strings.Join(el.text, " "))
+	}
+	if len(el.text) > 0 {
+		if c.droppedText == 0 {
+			c.firstText = el.offset
+		}
+		c.droppedText++
+	}
+
+	// 子元素全部同名且重复时，整个元素转换为数组
+	if len(el.children) > 1 {
+		same := true
+		for _, child := range el.children {
+			same = same && child.name == el.children[0].name
+		}
+		if same && !c.forceArray(el.children[0].name, path+"."+el.children[0].name) {
+			arr := &jsonNode{kind: nodeArray}
+			for _, child := range el.children {
+				arr.elements = append(arr.elements, c.parker(child, path+"."+child.name))
+			}
+			return arr
+		}
+	}
+
+	obj := &jsonNode{kind: nodeObject}
+	c.addChildren(obj, el, path, c.parker)
+	return obj
+}
+
+// flushNotes 汇总 Parker 约定丢弃的属性和文本
+func (c *xmlConverter) flushNotes() {
+	if c.droppedAttrs > 0 {
+		pos := newPosition(c.text, c.firstAttrs)
+		c.notes = append(c.notes, ConversionNote{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf("Parker 约定不保留属性，已忽略 %d 个属性", c.droppedAttrs)})
+	}
+	if c.droppedText > 0 {
+		pos := newPosition(c.text, c.firstText)
+		c.notes = append(c.notes, ConversionNote{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf("Parker 约定不保留与子元素混合的文本，已忽略 %d 个元素中的文本", c.droppedText)})
+	}
+}
+
+// parkerScalar 按 Parker 约定推断文本的类型：JSON数字和 true、false 转换为对应的值，其余为字符串
+func parkerScalar(text string) *jsonNode {
+	switch text {
+	case "true", "false":
+		return newLiteralNode(text)
+	}
+	if node, err := parseJSONTree(text); err == nil && node.kind == nodeNumber {
+		return node
+	}
+	return newStringNode(text)
+}
+
+// isXMLSpecialKey 键是否表示属性、文本或命名空间，而不是子元素
+func isXMLSpecialKey(key string, convention XMLConvention) bool {
+	switch convention {
+	case XMLAttr:
+		return strings.HasPrefix(key, "@") || key == "#text"
+	case XMLBadgerFish:
+		return strings.HasPrefix(key, "@") || key == "$"
+	}
+	return false
+}
+
+// xmlEncoder 把JSON语法树写为XML
+type xmlEncoder struct {
+	text       string
+	convention XMLConvention
+	indent     string // 每层缩进，为空时不换行
+	newline    string
+	sb         strings.Builder
+}
+
+// element 写出名为 name 的元素；值为数组时每个元素写为一个同名元素，嵌套的数组写为其中的 item 元素
+func (e *xmlEncoder) element(name string, node *jsonNode, path string, depth int) error {
+	if !isXMLName(name) {
+		return e.errorf(node, path, fmt.Sprintf("%q 不是合法的XML元素名", name))
+	}
+
+	if node.kind == nodeArray {
+		for i, elem := range node.elements {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			if elem.kind == nodeArray {
+				elem = &jsonNode{kind: nodeObject, members: []*jsonMember{{key: `"item"`, value: elem}}, offset: elem.offset}
+			}
+			if err := e.element(name, elem, elemPath, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pad := strings.Repeat(e.indent, depth)
+	var attrs strings.Builder
+	var text *jsonNode
+	var children []*jsonMember
+	if node.kind == nodeObject {
+		for _, m := range node.members {
+			key, memberPath := m.keyValue(), path+"."+m.keyValue()
+			switch {
+			case e.convention == XMLBadgerFish && key == "@xmlns":
+				if m.value.kind != nodeObject {
+					return e.errorf(m.value, memberPath, "@xmlns 需要是前缀到命名空间的对象")
+				}
+				for _, ns := range m.value.members {
+					attr := "xmlns:" + ns.keyValue()
+					if ns.keyValue() == "$" {
+						attr = "xmlns"
+					}
+					if err := e.attr(&attrs, attr, ns.value, memberPath+"."+ns.keyValue()); err != nil {
+						return err
+					}
+				}
+			case isXMLSpecialKey(key, e.convention) && strings.HasPrefix(key, "@"):
+				if err := e.attr(&attrs, key[1:], m.value, memberPath); err != nil {
+					return err
+				}
+			case isXMLSpecialKey(key, e.convention):
+				if m.value.kind == nodeObject || m.value.kind == nodeArray {
+					return e.errorf(m.value, memberPath, "文本需要是字符串、数字或布尔值")
+				}
+				text = m.value
+			default:
+				children = append(children, m)
+			}
+		}
+	} else if node.kind != nodeLiteral || node.raw != "null" {
+		text = node
+	}
+
+	e.sb.WriteString(pad + "<" + name + attrs.String())
+	if text == nil && len(children) == 0 {
+		e.sb.WriteString("/>" + e.newline)
+		return nil
+	}
+	e.sb.WriteString(">")
+	if len(children) == 0 {
+		e.sb.WriteString(escapeXMLText(xmlScalar(text)) + "</" + name + ">" + e.newline)
+		return nil
+	}
+
+	e.sb.WriteString(e.newline)
+	if text != nil {
+		e.sb.WriteString(pad + e.indent + escapeXMLText(xmlScalar(text)) + e.newline)
+	}
+	for _, m := range children {
+		if err := e.element(m.keyValue(), m.value, path+"."+m.keyValue(), depth+1); err != nil {
+			return err
+		}
+	}
+	e.sb.WriteString(pad + "</" + name + ">" + e.newline)
+	return nil
+}
+
+// attr 写出属性，值需要是标量
+func (e *xmlEncoder) attr(sb *strings.Builder, name string, value *jsonNode, path string) error {
+	if !isXMLName(name) {
+		return e.errorf(value, path, fmt.Sprintf("%q 不是合法的XML属性名", name))
+	}
+	if value.kind == nodeObject || value.kind == nodeArray {
+		return e.errorf(value, path, "属性值需要是字符串、数字或布尔值")
+	}
+	sb.WriteString(" " + name + `="` + escapeXMLAttr(xmlScalar(value)) + `"`)
+	return nil
+}
+
+func (e *xmlEncoder) errorf(node *jsonNode, path string, reason string) error {
+	return &ConversionError{Position: newPosition(e.text, node.offset), Path: path, Target: "XML", Reason: reason}
+}
+
+// xmlScalar 标量的文本，null 为空字符串
+func xmlScalar(node *jsonNode) string {
+	switch {
+	case node.kind == nodeString:
+		return node.stringValue()
+	case node.raw == "null":
+		return ""
+	}
+	return node.raw
+}
+
+// isXMLName 是否为合法的XML名称，允许一个命名空间前缀
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ":") || strings.HasSuffix(name, ":") || strings.Count(name, ":") > 1 {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_' || r == ':':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+)
+
+func escapeXMLText(s string) string {
+	return xmlTextEscaper.Replace(s)
+}
+
+func escapeXMLAttr(s string) string {
+	return xmlAttrEscaper.Replace(s)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testXML = `<?xml version="1.0"?>
+<!-- 目录 -->
+<ns:catalog xmlns:ns="urn:books" xmlns="urn:default" id="1">
+  <book isbn="123"><title>Go</title><price>10.5</price></book>
+  <book isbn="456"><title>Rust &amp; C</title><price>20</price></book>
+  <note>hello <b>bold</b> world</note>
+  <empty/>
+</ns:catalog>`
+
+func TestXMLToJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    XMLOptions
+		want    string
+		notes   int
+		wantErr string
+	}{
+		{
+			name:  "attr约定",
+			input: testXML,
+			want: `{"ns:catalog":{"@xmlns:ns":"urn:books","@xmlns":"urn:default","@id":"1",` +
+				`"book":[{"@isbn":"123","title":"Go","price":"10.5"},{"@isbn":"456","title":"Rust & C","price":"20"}],` +
+				`"note":{"b":"bold","#text":"hello world"},"empty":null}}`,
+		},
+		{
+			name:  "BadgerFish约定",
+			input: testXML,
+			opts:  XMLOptions{Convention: XMLBadgerFish},
+			want: `{"ns:catalog":{"@xmlns":{"ns":"urn:books","$":"urn:default"},"@id":"1",` +
+				`"book":[{"@isbn":"123","title":{"$":"Go"},"price":{"$":"10.5"}},{"@isbn":"456","title":{"$":"Rust & C"},"price":{"$":"20"}}],` +
+				`"note":{"b":{"$":"bold"},"$":"hello world"},"empty":{}}}`,
+		},
+		{
+			name:  "Parker约定",
+			input: testXML,
+			opts:  XMLOptions{Convention: XMLParker},
+			want:  `{"book":[{"title":"Go","price":10.5},{"title":"Rust & C","price":20}],"note":{"b":"bold"},"empty":null}`,
+			notes: 2,
+		},
+		{
+			name:  "Parker约定中同名的子元素转换为数组",
+			input: "<list><item>1</item><item>true</item><item>x</item></list>",
+			opts:  XMLOptions{Convention: XMLParker},
+			want:  `[1,true,"x"]`,
+		},
+		{
+			name:  "去掉命名空间并按路径强制数组",
+			input: testXML,
+			opts:  XMLOptions{StripNamespaces: true, ForceArrays: []string{"catalog.note", "b"}},
+			want: `{"catalog":{"@id":"1",` +
+				`"book":[{"@isbn":"123","title":"Go","price":"10.5"},{"@isbn":"456","title":"Rust & C","price":"20"}],` +
+				`"note":[{"b":["bold"],"#text":"hello world"}],"empty":null}}`,
+		},
+		{
+			name:    "结束标签不匹配",
+			input:   "<a><b></a>",
+			wantErr: "第 1 行第 7 列",
+		},
+		{
+			name:    "没有结束标签",
+			input:   "<a>",
+			wantErr: "没有结束标签",
+		},
+		{
+			name:    "多个根元素",
+			input:   "<a/><b/>",
+			wantErr: "只能有一个根元素",
+		},
+		{
+			name:    "重复的属性",
+			input:   `<a x="1" x="2"/>`,
+			wantErr: "属性 x 重复",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.XMLToJSON(ctx, tt.input, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("XMLToJSON() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("XMLToJSON() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("XMLToJSON() = %s, want %s", result.Result, tt.want)
+			}
+			if len(result.Notes) != tt.notes {
+				t.Errorf("XMLToJSON() notes = %v, want %d", result.Notes, tt.notes)
+			}
+		})
+	}
+}
+
+func TestJSONToXML(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    XMLOptions
+		want    string
+		errPath string // 期望 ConversionError 的路径
+	}{
+		{
+			name:  "attr约定以唯一的键作为根元素",
+			input: `{"catalog":{"@id":"1","book":[{"title":"Go"},{"title":"A<B"}],"empty":null,"note":{"#text":"hi","b":1}}}`,
+			opts:  XMLOptions{FormatOptions: FormatOptions{Indent: 2}},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<catalog id="1">
+  <book>
+    <title>Go</title>
+  </book>
+  <book>
+    <title>A&lt;B</title>
+  </book>
+  <empty/>
+  <note>
+    hi
+    <b>1</b>
+  </note>
+</catalog>`,
+		},
+		{
+			name:  "BadgerFish约定的命名空间",
+			input: `{"ns:a":{"@xmlns":{"$":"urn:d","ns":"urn:n"},"@q":"x\"y","$":"t"}}`,
+			opts:  XMLOptions{Convention: XMLBadgerFish},
+			want:  `<?xml version="1.0" encoding="UTF-8"?><ns:a xmlns="urn:d" xmlns:ns="urn:n" q="x&quot;y">t</ns:a>`,
+		},
+		{
+			name:  "指定根元素和嵌套数组",
+			input: `{"a":[1,[2,3]],"b":true}`,
+			opts:  XMLOptions{Convention: XMLParker, Root: "data"},
+			want:  `<?xml version="1.0" encoding="UTF-8"?><data><a>1</a><a><item>2</item><item>3</item></a><b>true</b></data>`,
+		},
+		{
+			name:  "顶层数组",
+			input: `[1,2]`,
+			want:  `<?xml version="1.0" encoding="UTF-8"?><root><item>1</item><item>2</item></root>`,
+		},
+		{
+			name:    "不合法的元素名",
+			input:   `{"r":{"a b":1}}`,
+			errPath: "$.r.a b",
+		},
+		{
+			name:    "属性值不是标量",
+			input:   `{"r":{"@id":[1]}}`,
+			errPath: "$.r.@id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.JSONToXML(ctx, tt.input, tt.opts)
+			if tt.errPath != "" {
+				var convErr *ConversionError
+				if !errors.As(err, &convErr) {
+					t.Fatalf("JSONToXML() error = %v, want ConversionError", err)
+				}
+				if convErr.Path != tt.errPath {
+					t.Errorf("ConversionError.Path = %s, want %s", convErr.Path, tt.errPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONToXML() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("JSONToXML() = %s, want %s", result.Result, tt.want)
+			}
+		})
+	}
+}
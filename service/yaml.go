@@ -0,0 +1,509 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sojson/zlog"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxYAMLNodes 展开别名后允许的最大节点数，防止 billion laughs 式的别名炸弹
+const maxYAMLNodes = 1000000
+
+// DocumentLayout 多个文档在JSON中的组织方式
+type DocumentLayout string
+
+const (
+	// DocumentsAuto 只有一个文档时直接输出该文档，有多个时输出为数组
+	DocumentsAuto DocumentLayout = ""
+	// DocumentsArray 总是输出为数组，每个文档一个元素
+	DocumentsArray DocumentLayout = "array"
+	// DocumentsNDJSON 每个文档压缩为一行，输出为 NDJSON
+	DocumentsNDJSON DocumentLayout = "ndjson"
+)
+
+// ParseDocumentLayout 解析多文档的组织方式，空字符串表示自动
+func ParseDocumentLayout(name string) (DocumentLayout, error) {
+	switch layout := DocumentLayout(strings.ToLower(strings.TrimSpace(name))); layout {
+	case DocumentsAuto, DocumentsArray, DocumentsNDJSON:
+		return layout, nil
+	case "auto":
+		return DocumentsAuto, nil
+	}
+	return "", fmt.Errorf("不支持的多文档方式: %s，可选 auto、array、ndjson", name)
+}
+
+// YAMLStyle 输出YAML时容器的写法
+type YAMLStyle string
+
+const (
+	// YAMLBlock 块样式，每个成员一行
+	YAMLBlock YAMLStyle = "block"
+	// YAMLFlow 流样式，如 {a: 1, b: [1, 2]}
+	YAMLFlow YAMLStyle = "flow"
+	// YAMLCompact 块样式，只包含标量的数组和对象使用流样式
+	YAMLCompact YAMLStyle = "compact"
+)
+
+// ParseYAMLStyle 解析YAML样式名称，空字符串表示块样式
+func ParseYAMLStyle(name string) (YAMLStyle, error) {
+	switch style := YAMLStyle(strings.ToLower(strings.TrimSpace(name))); style {
+	case "":
+		return YAMLBlock, nil
+	case YAMLBlock, YAMLFlow, YAMLCompact:
+		return style, nil
+	}
+	return "", fmt.Errorf("不支持的YAML样式: %s，可选 block、flow、compact", name)
+}
+
+// YAMLOptions YAML转换选项
+type YAMLOptions struct {
+	FormatOptions
+	Documents DocumentLayout // 多个文档的组织方式
+	Style     YAMLStyle      // 输出YAML时的样式
+}
+
+// YAMLToJSON 把YAML转换为JSON，保持键的顺序；别名、合并键和自定义标签展开时在 Notes 中说明
+func (s *jsonProcessorService) YAMLToJSON(ctx context.Context, text string, opts YAMLOptions) (*ConversionResult, error) {
+	c := &yamlConverter{}
+	var docs []*jsonNode
+	dec := yaml.NewDecoder(strings.NewReader(text))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			zlog.Errorf(ctx, "YAMLToJSON: yaml decode failed, input text length: %d, documents: %d, error: %v", len(text), len(docs), err)
+			return nil, yamlSyntaxError(text, err)
+		}
+
+		node, err := c.convert(&doc)
+		if err != nil {
+			zlog.Errorf(ctx, "YAMLToJSON: convert failed, document: %d, error: %v", len(docs)+1, err)
+			return nil, err
+		}
+		docs = append(docs, node)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("输入中没有YAML文档")
+	}
+
+	result := &ConversionResult{Documents: len(docs), Notes: c.notes}
+	switch {
+	case opts.Documents == DocumentsNDJSON:
+		lines := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			lines = append(lines, formatJSONTree(doc, lineFormatOptions(opts.FormatOptions)))
+		}
+		result.Result = joinLines(lines, opts.FormatOptions)
+	case opts.Documents == DocumentsArray || len(docs) > 1:
+		result.Result = formatJSONTree(&jsonNode{kind: nodeArray, elements: docs}, opts.FormatOptions)
+	default:
+		result.Result = formatJSONTree(docs[0], opts.FormatOptions)
+	}
+
+	zlog.Infof(ctx, "YAMLToJSON: successfully converted, input length: %d, output length: %d, documents: %d, notes: %d", len(text), len(result.Result), len(docs), len(c.notes))
+	return result, nil
+}
+
+// JSONToYAML 把JSON转换为YAML，保持键的顺序，JSONC 和 JSON5 的注释转换为YAML注释；
+// Documents 为 array 时顶层数组的每个元素输出为一个文档，为 ndjson 时每行输出为一个文档
+func (s *jsonProcessorService) JSONToYAML(ctx context.Context, text string, opts YAMLOptions) (*ConversionResult, error) {
+	if err := opts.Dialect.check(); err != nil {
+		return nil, err
+	}
+
+	var docs []*jsonNode
+	switch opts.Documents {
+	case DocumentsNDJSON:
+		offset := 0
+		for _, raw := range strings.SplitAfter(text, "\n") {
+			if line := strings.TrimRight(raw, "\r\n"); strings.TrimSpace(line) != "" {
+				tree, err := parseDialectTree(line, opts.Dialect)
+				if err != nil {
+					zlog.Errorf(ctx, "JSONToYAML: parseDialectTree failed, line offset: %d, error: %v", offset, err)
+					return nil, newLineError(text, offset, true, err)
+				}
+				docs = append(docs, tree)
+			}
+			offset += len(raw)
+		}
+		if len(docs) == 0 {
+			return nil, fmt.Errorf("输入中没有非空行")
+		}
+	default:
+		tree, err := parseDialectTree(text, opts.Dialect)
+		if err != nil {
+			zlog.Errorf(ctx, "JSONToYAML: parseDialectTree failed, input text length: %d, error: %v", len(text), err)
+			return nil, err
+		}
+		docs = []*jsonNode{tree}
+		if opts.Documents == DocumentsArray {
+			if tree.kind != nodeArray {
+				return nil, fmt.Errorf("按数组输出多个文档时输入需要是JSON数组")
+			}
+			docs = tree.elements
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	indent := opts.Indent
+	if opts.UseTabs || indent < 2 {
+		// YAML 不允许用制表符缩进
+		indent = 2
+	}
+	enc.SetIndent(indent)
+	for _, doc := range docs {
+		if opts.KeyOrder != KeyOrderOriginal {
+			sortMembers(doc, opts.KeyOrder)
+		}
+		root := yamlNode(doc, opts.Style, opts.Style == YAMLFlow)
+		if opts.Style == YAMLCompact {
+			// 紧凑样式的文档本身仍使用块样式
+			root.Style = 0
+		}
+		if err := enc.Encode(root); err != nil {
+			zlog.Errorf(ctx, "JSONToYAML: yaml encode failed, documents: %d, error: %v", len(docs), err)
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	result := strings.TrimSuffix(buf.String(), "\n")
+	if opts.CRLF {
+		result = strings.ReplaceAll(result, "\n", "\r\n")
+	}
+	if opts.TrailingNewline {
+		result += newlineOf(opts.FormatOptions)
+	}
+
+	zlog.Infof(ctx, "JSONToYAML: successfully converted, input length: %d, output length: %d, documents: %d", len(text), len(result), len(docs))
+	return &ConversionResult{Result: result, Documents: len(docs)}, nil
+}
+
+// yamlConverter 把 yaml.Node 转换为JSON语法树，记录转换说明
+type yamlConverter struct {
+	notes     []ConversionNote
+	nodes     int // 已生成的节点数
+	expanding int // 正在展开的别名层数，展开的内容在锚点处已经说明过，不再重复记录
+}
+
+func (c *yamlConverter) note(n *yaml.Node, format string, args ...interface{}) {
+	if c.expanding > 0 {
+		return
+	}
+	c.notes = append(c.notes, ConversionNote{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *yamlConverter) convert(n *yaml.Node) (*jsonNode, error) {
+	if c.nodes++; c.nodes > maxYAMLNodes {
+		return nil, fmt.Errorf("展开别名后的节点超过 %d 个", maxYAMLNodes)
+	}
+
+	if n.Tag != "" && !strings.HasPrefix(n.Tag, "!!") && n.Kind != yaml.AliasNode && n.Kind != yaml.DocumentNode {
+		c.note(n, "自定义标签 %s 在JSON中无法表示，已忽略", n.Tag)
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return newLiteralNode("null"), nil
+		}
+		return c.convert(n.Content[0])
+	case yaml.AliasNode:
+		c.note(n, "别名 *%s 已展开为锚点 &%s 的内容", n.Value, n.Alias.Anchor)
+		c.expanding++
+		defer func() { c.expanding-- }()
+		return c.convert(n.Alias)
+	case yaml.SequenceNode:
+		node := &jsonNode{kind: nodeArray}
+		for _, item := range n.Content {
+			elem, err := c.convert(item)
+			if err != nil {
+				return nil, err
+			}
+			node.elements = append(node.elements, elem)
+		}
+		return node, nil
+	case yaml.MappingNode:
+		return c.convertMapping(n)
+	}
+	return c.convertScalar(n), nil
+}
+
+// convertMapping 转换映射，合并键 << 引入的键不覆盖映射中显式写出的键
+func (c *yamlConverter) convertMapping(n *yaml.Node) (*jsonNode, error) {
+	explicit := map[string]bool{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := n.Content[i]; k.ShortTag() != "!!merge" {
+			explicit[c.keyText(k, false)] = true
+		}
+	}
+
+	node := &jsonNode{kind: nodeObject}
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.ShortTag() == "!!merge" {
+			if err := c.merge(node, k, v, explicit, seen); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key := c.keyText(k, true)
+		value, err := c.convert(v)
+		if err != nil {
+			return nil, err
+		}
+		seen[key] = true
+		appendMember(node, key, value)
+	}
+	return node, nil
+}
+
+// merge 展开合并键，值可以是映射或映射组成的序列，序列中靠前的映射优先
+func (c *yamlConverter) merge(node *jsonNode, k *yaml.Node, v *yaml.Node, explicit map[string]bool, seen map[string]bool) error {
+	sources := []*yaml.Node{v}
+	if resolveAlias(v).Kind == yaml.SequenceNode {
+		sources = resolveAlias(v).Content
+	}
+
+	merged := 0
+	for _, source := range sources {
+		if resolveAlias(source).Kind != yaml.MappingNode {
+			return fmt.Errorf("第 %d 行：合并键 << 的值需要是映射或映射组成的序列", k.Line)
+		}
+		obj, err := c.convert(source)
+		if err != nil {
+			return err
+		}
+		for _, m := range obj.members {
+			key := m.keyValue()
+			if explicit[key] || seen[key] {
+				continue
+			}
+			seen[key] = true
+			node.members = append(node.members, m)
+			merged++
+		}
+	}
+	c.note(k, "合并键 << 已展开，合并了 %d 个键", merged)
+	return nil
+}
+
+// keyText 取映射键的文本，复杂键转换为JSON文本
+func (c *yamlConverter) keyText(k *yaml.Node, withNote bool) string {
+	target := resolveAlias(k)
+	if target.Kind == yaml.ScalarNode {
+		if target.ShortTag() == "!!null" {
+			return "null"
+		}
+		return target.Value
+	}
+
+	// 复杂键只在生成成员时记录一次说明
+	saved := c.notes
+	node, err := c.convert(k)
+	if !withNote {
+		c.notes = saved
+	}
+	if err != nil {
+		return target.Value
+	}
+	if withNote {
+		c.note(k, "复杂键已转换为JSON文本")
+	}
+	return formatJSONTree(node, FormatOptions{})
+}
+
+// convertScalar 按解析出的标签转换标量：整数和浮点数转换为数字，时间戳、二进制等按字符串输出
+func (c *yamlConverter) convertScalar(n *yaml.Node) *jsonNode {
+	if !strings.HasPrefix(n.Tag, "!!") {
+		// 忽略自定义标签，按值本身解析
+		untagged := *n
+		untagged.Tag = ""
+		n = &untagged
+	}
+	switch n.ShortTag() {
+	case "!!null":
+		return newLiteralNode("null")
+	case "!!bool":
+		var value bool
+		if err := n.Decode(&value); err == nil {
+			return newLiteralNode(fmt.Sprint(value))
+		}
+	case "!!int", "!!float":
+		switch strings.ToLower(strings.TrimLeft(n.Value, "+-")) {
+		case ".inf", ".nan":
+			c.note(n, "%s 无法用JSON表示，已输出为 null", n.Value)
+			return newLiteralNode("null")
+		}
+		if strings.HasPrefix(n.Value, "0o") || strings.HasPrefix(n.Value, "-0o") {
+			// YAML 1.2 的八进制
+			if node, ok := newNumberNode(strings.Replace(n.Value, "0o", "0", 1)); ok {
+				return node
+			}
+		}
+		if node, ok := newNumberNode(n.Value); ok {
+			return node
+		}
+	case "!!binary":
+		c.note(n, "!!binary 二进制数据按 base64 字符串输出")
+	}
+	return newStringNode(n.Value)
+}
+
+// resolveAlias 返回别名指向的节点
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// yamlNode 把JSON语法树转换为 yaml.Node；flow 为 true 时容器使用流样式
+func yamlNode(node *jsonNode, style YAMLStyle, flow bool) *yaml.Node {
+	n := &yaml.Node{}
+	switch node.kind {
+	case nodeObject:
+		n.Kind, n.Tag = yaml.MappingNode, "!!map"
+		for _, m := range node.members {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m.keyValue()}
+			if yaml11Bools[key.Value] {
+				key.Style = yaml.DoubleQuotedStyle
+			}
+			value := yamlNode(m.value, style, flow)
+			// 成员的注释放在键上
+			key.HeadComment, value.HeadComment = value.HeadComment, ""
+			n.Content = append(n.Content, key, value)
+		}
+	case nodeArray:
+		n.Kind, n.Tag = yaml.SequenceNode, "!!seq"
+		for _, e := range node.elements {
+			n.Content = append(n.Content, yamlNode(e, style, flow))
+		}
+	case nodeString:
+		n.Kind, n.Tag, n.Value = yaml.ScalarNode, "!!str", node.stringValue()
+		switch {
+		case yaml11Bools[n.Value]:
+			n.Style = yaml.DoubleQuotedStyle
+		case strings.Contains(n.Value, "\n") && !flow:
+			n.Style = yaml.LiteralStyle
+		}
+	case nodeNumber:
+		n.Kind, n.Tag, n.Value = yaml.ScalarNode, "!!int", node.raw
+		if strings.ContainsAny(node.raw, ".eE") {
+			n.Tag = "!!float"
+		}
+		if node.nonFinite {
+			n.Tag, n.Value = "!!float", yamlNonFinite(node.raw)
+		}
+	default:
+		n.Kind, n.Value = yaml.ScalarNode, node.raw
+		n.Tag = "!!bool"
+		if node.raw == "null" {
+			n.Tag = "!!null"
+		}
+	}
+
+	if n.Kind != yaml.ScalarNode && (flow || (style == YAMLCompact && onlyScalars(node))) {
+		n.Style = yaml.FlowStyle
+	}
+	if flow {
+		// 流样式中没有放注释的位置
+		return n
+	}
+	n.HeadComment = hashComments(node.leading)
+	n.LineComment = hashComments(node.trailing)
+	n.FootComment = hashComments(append(node.dangling, node.footer...))
+	return n
+}
+
+// yaml11Bools YAML 1.1 中表示布尔值的写法，YAML 1.2 中是普通字符串，
+// 按 YAML 1.1 解析的程序（如 PyYAML、旧版的 go-yaml）会把不加引号的这些字符串读成布尔值
+var yaml11Bools = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true,
+	"n": true, "N": true, "no": true, "No": true, "NO": true,
+	"on": true, "On": true, "ON": true, "off": true, "Off": true, "OFF": true,
+}
+
+// yamlErrorLine 匹配 yaml 错误信息中的行号
+var yamlErrorLine = regexp.MustCompile(`^line (\d+): `)
+
+// yamlSyntaxError 把 yaml 的解析错误包装为带位置的 *LineError，错误信息中没有行号时原样返回
+func yamlSyntaxError(text string, err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	match := yamlErrorLine.FindStringSubmatch(msg)
+	if match == nil {
+		return fmt.Errorf("YAML格式错误: %s", msg)
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	offset := 0
+	for i := 1; i < line && offset < len(text); i++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			offset = len(text)
+			break
+		}
+		offset += next + 1
+	}
+	return newLineError(text, offset, false, fmt.Errorf("YAML格式错误: %s", msg[len(match[0]):]))
+}
+
+// yamlNonFinite 把 NaN、Infinity 写为YAML的 .nan、.inf
+func yamlNonFinite(raw string) string {
+	switch {
+	case raw == "NaN":
+		return ".nan"
+	case strings.HasPrefix(raw, "-"):
+		return "-.inf"
+	}
+	return ".inf"
+}
+
+// onlyScalars 容器是否只包含标量
+func onlyScalars(node *jsonNode) bool {
+	for _, m := range node.members {
+		if m.value.kind == nodeObject || m.value.kind == nodeArray {
+			return false
+		}
+	}
+	for _, e := range node.elements {
+		if e.kind == nodeObject || e.kind == nodeArray {
+			return false
+		}
+	}
+	return true
+}
+
+// hashComments 把 // 和 /* */ 注释改写为 # 注释
+func hashComments(comments []string) string {
+	var lines []string
+	for _, c := range comments {
+		var text string
+		if strings.HasPrefix(c, "//") {
+			text = strings.TrimPrefix(c, "//")
+		} else {
+			text = strings.TrimSuffix(strings.TrimPrefix(c, "/*"), "*/")
+		}
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimLeft(strings.TrimSpace(line), "* ")
+			lines = append(lines, strings.TrimRight("# "+line, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
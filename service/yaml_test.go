@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		input     string
+		documents DocumentLayout
+		want      string
+		notes     []string // 每条说明中应包含的文字
+		wantErr   bool
+	}{
+		{
+			name:  "保持键的顺序",
+			input: "z: 1\na: [true, null, 1.5]\nm:\n  b: x\n  a: 0x1F",
+			want:  `{"z":1,"a":[true,null,1.5],"m":{"b":"x","a":31}}`,
+		},
+		{
+			name:  "时间戳和带引号的数字按字符串输出",
+			input: "date: 2001-12-14\nversion: \"1.10\"\noct: 0o17",
+			want:  `{"date":"2001-12-14","version":"1.10","oct":15}`,
+		},
+		{
+			name:  "多文档自动合并为数组",
+			input: "a: 1\n---\n- 2\n---\nthree",
+			want:  `[{"a":1},[2],"three"]`,
+		},
+		{
+			name:      "单个文档按数组输出",
+			input:     "a: 1",
+			documents: DocumentsArray,
+			want:      `[{"a":1}]`,
+		},
+		{
+			name:      "多文档输出为NDJSON",
+			input:     "---\na: 1\n---\nb: [2]\n",
+			documents: DocumentsNDJSON,
+			want:      "{\"a\":1}\n{\"b\":[2]}",
+		},
+		{
+			name:  "锚点和合并键",
+			input: "base: &b\n  x: 1\n  y: 2\nobj:\n  <<: *b\n  y: 5",
+			want:  `{"base":{"x":1,"y":2},"obj":{"x":1,"y":5}}`,
+			notes: []string{"别名 *b", "合并了 1 个键"},
+		},
+		{
+			name:  "自定义标签和无穷大",
+			input: "a: !secret abc\nb: -.inf",
+			want:  `{"a":"abc","b":null}`,
+			notes: []string{"自定义标签 !secret", "-.inf 无法用JSON表示"},
+		},
+		{
+			name:  "复杂键",
+			input: "? [a, b]\n: c",
+			want:  `{"[\"a\",\"b\"]":"c"}`,
+			notes: []string{"复杂键"},
+		},
+		{
+			name:  "展开的别名中不重复说明",
+			input: "a: &a [!x 1]\nb: *a\nc: *a",
+			want:  `{"a":[1],"b":[1],"c":[1]}`,
+			notes: []string{"自定义标签 !x", "别名 *a", "别名 *a"},
+		},
+		{
+			name:    "格式错误",
+			input:   "a: [1, 2",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.YAMLToJSON(ctx, tt.input, YAMLOptions{Documents: tt.documents})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("YAMLToJSON() expected error, got %s", result.Result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("YAMLToJSON() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("YAMLToJSON() = %s, want %s", result.Result, tt.want)
+			}
+			if len(result.Notes) != len(tt.notes) {
+				t.Fatalf("YAMLToJSON() notes = %v, want %d notes", result.Notes, len(tt.notes))
+			}
+			for i, note := range result.Notes {
+				if !strings.Contains(note.Message, tt.notes[i]) {
+					t.Errorf("note %d = %q, want containing %q", i, note.Message, tt.notes[i])
+				}
+				if note.Line == 0 {
+					t.Errorf("note %d has no line", i)
+				}
+			}
+		})
+	}
+}
+
+func TestYAMLToJSONErrorPosition(t *testing.T) {
+	_, err := JSONProcessorService.YAMLToJSON(context.Background(), "a: 1\n  b: 2\n", YAMLOptions{})
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("YAMLToJSON() error = %v, want *LineError", err)
+	}
+	if lineErr.Line != 2 || lineErr.Offset != 5 {
+		t.Errorf("YAMLToJSON() error position = %+v, want line 2 offset 5", lineErr.Position)
+	}
+}
+
+func TestJSONToYAML(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    YAMLOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "块样式保持键的顺序",
+			input: `{"z":1,"a":{"list":[1,"2",true,null],"f":1.50}}`,
+			want:  "z: 1\na:\n  list:\n    - 1\n    - \"2\"\n    - true\n    - null\n  f: 1.50",
+		},
+		{
+			name:  "流样式",
+			input: `{"a":[1,{"b":"c d"}]}`,
+			opts:  YAMLOptions{Style: YAMLFlow},
+			want:  "{a: [1, {b: c d}]}",
+		},
+		{
+			name:  "紧凑样式",
+			input: `{"a":[1,2],"b":[{"c":1}]}`,
+			opts:  YAMLOptions{Style: YAMLCompact},
+			want:  "a: [1, 2]\nb:\n  - {c: 1}",
+		},
+		{
+			name:  "紧凑样式的文档本身使用块样式",
+			input: `{"a":1,"b":"x"}`,
+			opts:  YAMLOptions{Style: YAMLCompact},
+			want:  "a: 1\nb: x",
+		},
+		{
+			name:  "多行字符串",
+			input: `{"text":"l1\nl2"}`,
+			want:  "text: |-\n  l1\n  l2",
+		},
+		{
+			name:  "JSON5注释和非有限数",
+			input: "{\n  // 超时\n  timeout: NaN,\n  max: -Infinity,\n}",
+			opts:  YAMLOptions{FormatOptions: FormatOptions{Dialect: DialectJSON5}},
+			want:  "# 超时\ntimeout: .nan\nmax: -.inf",
+		},
+		{
+			name:  "YAML 1.1中的布尔值写法加引号",
+			input: `{"on":["yes","No","y","OFF","true","yesterday"]}`,
+			want:  "\"on\":\n  - \"yes\"\n  - \"No\"\n  - \"y\"\n  - \"OFF\"\n  - \"true\"\n  - yesterday",
+		},
+		{
+			name:  "数组的元素输出为多个文档",
+			input: `[{"a":1},{"b":2}]`,
+			opts:  YAMLOptions{Documents: DocumentsArray},
+			want:  "a: 1\n---\nb: 2",
+		},
+		{
+			name:  "NDJSON每行输出为一个文档",
+			input: "{\"a\":1}\n\n[1]\n",
+			opts:  YAMLOptions{Documents: DocumentsNDJSON, FormatOptions: FormatOptions{TrailingNewline: true}},
+			want:  "a: 1\n---\n- 1\n",
+		},
+		{
+			name:  "缩进",
+			input: `{"a":{"b":1}}`,
+			opts:  YAMLOptions{FormatOptions: FormatOptions{Indent: 4}},
+			want:  "a:\n    b: 1",
+		},
+		{
+			name:    "按数组输出时输入不是数组",
+			input:   `{"a":1}`,
+			opts:    YAMLOptions{Documents: DocumentsArray},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONProcessorService.JSONToYAML(ctx, tt.input, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("JSONToYAML() expected error, got %s", result.Result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONToYAML() unexpected error = %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("JSONToYAML() = %q, want %q", result.Result, tt.want)
+			}
+		})
+	}
+}
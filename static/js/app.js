@@ -1,3 +1,9 @@
+// 只按单个文档处理、不支持按行处理模式的功能
+const DOCUMENT_ONLY_FUNCTIONS = [
+    'extract', 'yaml-to-json', 'json-to-yaml', 'toml-to-json', 'json-to-toml', 'xml-to-json', 'json-to-xml',
+    'csv-to-json', 'json-to-csv', 'go-example', 'schema-validate'
+];
+
 class SoJSON {
     constructor() {
         this.initElements();
@@ -14,6 +20,39 @@ class SoJSON {
         this.errorMessage = document.getElementById('error-message');
         this.successMessage = document.getElementById('success-message');
         this.indentSelect = document.getElementById('indent-select');
+        this.lineWidthSelect = document.getElementById('line-width-select');
+        this.sortKeysSelect = document.getElementById('sort-keys-select');
+        this.lineEndingSelect = document.getElementById('line-ending-select');
+        this.dialectSelect = document.getElementById('dialect-select');
+        this.languageSelect = document.getElementById('language-select');
+        this.mongoSelect = document.getElementById('mongo-select');
+        this.modeSelect = document.getElementById('mode-select');
+        this.extractInlineCheck = document.getElementById('extract-inline-check');
+        this.documentsSelect = document.getElementById('documents-select');
+        this.yamlStyleSelect = document.getElementById('yaml-style-select');
+        this.xmlConventionSelect = document.getElementById('xml-convention-select');
+        this.forceArraysInput = document.getElementById('force-arrays-input');
+        this.xmlRootInput = document.getElementById('xml-root-input');
+        this.csvFormatSelect = document.getElementById('csv-format-select');
+        this.arrayEncodingSelect = document.getElementById('array-encoding-select');
+        this.pointerInput = document.getElementById('pointer-input');
+        this.typeNameInput = document.getElementById('type-name-input');
+        this.inlineTypesCheck = document.getElementById('inline-types-check');
+        this.pointerNullableCheck = document.getElementById('pointer-nullable-check');
+        this.tsOutputSelect = document.getElementById('ts-output-select');
+        this.codegenLangSelect = document.getElementById('codegen-lang-select');
+        this.frameworkSelect = document.getElementById('framework-select');
+        this.omitEmptyCheck = document.getElementById('omit-empty-check');
+        this.schemaStrictCheck = document.getElementById('schema-strict-check');
+        this.schemaSection = document.getElementById('schema-section');
+        this.schemaInput = document.getElementById('schema-input');
+        this.clearSchemaBtn = document.getElementById('clear-schema');
+        this.schemaSubjectInput = document.getElementById('schema-subject-input');
+        this.loadSchemaBtn = document.getElementById('load-schema');
+        this.registerSchemaBtn = document.getElementById('register-schema');
+        this.targetDialectSelect = document.getElementById('target-dialect-select');
+        this.colonSpaceCheck = document.getElementById('colon-space-check');
+        this.trailingNewlineCheck = document.getElementById('trailing-newline-check');
         this.inputCount = document.getElementById('input-count');
 
         // 功能按钮
@@ -37,11 +76,17 @@ class SoJSON {
 
         // 输入框变化监听 (Monaco Editor 在 HTML 中已配置)
 
+        // 输入方言变化时，JSONC/JSON5 不再把注释等写法标记为错误
+        this.dialectSelect.addEventListener('change', () => this.updateEditorDiagnostics());
+
         // 操作按钮
         this.clearInputBtn.addEventListener('click', () => this.clearInput());
         this.pasteBtn.addEventListener('click', () => this.pasteFromClipboard());
         this.copyOutputBtn.addEventListener('click', () => this.copyOutput());
         this.downloadBtn.addEventListener('click', () => this.downloadResult());
+        this.clearSchemaBtn.addEventListener('click', () => { this.schemaInput.value = ''; });
+        this.loadSchemaBtn.addEventListener('click', () => this.loadSchema());
+        this.registerSchemaBtn.addEventListener('click', () => this.registerSchema());
 
         // 键盘快捷键
         document.addEventListener('keydown', (e) => this.handleKeyboardShortcuts(e));
@@ -65,30 +110,79 @@ class SoJSON {
         const buttonTexts = {
             'process': '格式化',
             'unescape': '去除转义',
+            'escape': '添加转义',
             'format': '格式化',
-            'validate': '验证'
+            'validate': '验证',
+            'repair': '修复',
+            'convert': '转换',
+            'java': '转换',
+            'extract': '提取',
+            'yaml-to-json': '转换',
+            'json-to-yaml': '转换',
+            'toml-to-json': '转换',
+            'json-to-toml': '转换',
+            'xml-to-json': '转换',
+            'json-to-xml': '转换',
+            'csv-to-json': '转换',
+            'json-to-csv': '转换',
+            'codegen': '生成',
+            'go-example': '生成',
+            'schema-infer': '推断',
+            'schema-validate': '校验'
         };
         this.btnText.textContent = buttonTexts[func] || '处理';
 
+        // YAML、TOML、XML、CSV转JSON及Go结构体生成示例时按输入的格式高亮，避免被当作JSON错误标记
+        const inputLanguages = { 'yaml-to-json': 'yaml', 'toml-to-json': 'ini', 'xml-to-json': 'xml', 'csv-to-json': 'plaintext', 'go-example': 'go' };
+        this.setEditorLanguage(inputLanguages[func] || 'json');
+
+        // 只有按 JSON Schema 校验时需要填写 schema
+        this.schemaSection.style.display = func === 'schema-validate' ? 'flex' : 'none';
+
         // 清除之前的结果和错误
         this.hideMessages();
     }
 
     async processText() {
-        const inputValue = this.getEditorValue().trim();
+        // 不去除首尾空白，保证错误位置与编辑器中的行列一致
+        const inputValue = this.getEditorValue();
         
-        if (!inputValue) {
+        if (!inputValue.trim()) {
             this.showError('请输入要处理的文本');
             return;
         }
+        if (this.currentFunction === 'schema-validate' && !this.schemaInput.value.trim() && !this.schemaSubjectInput.value.trim()) {
+            this.showError('请填写 JSON Schema 或注册表主题');
+            return;
+        }
         
         this.setLoading(true);
         this.hideMessages();
+        this.clearErrorMarkers();
         
         try {
-            const result = await this.callAPI(this.currentFunction, {
+            // Java toString 复用转换接口，并固定来源语言
+            const isJava = this.currentFunction === 'java';
+            const endpoints = {
+                'java': 'convert',
+                'yaml-to-json': 'yaml/to-json',
+                'json-to-yaml': 'yaml/from-json',
+                'toml-to-json': 'toml/to-json',
+                'json-to-toml': 'toml/from-json',
+                'xml-to-json': 'xml/to-json',
+                'json-to-xml': 'xml/from-json',
+                'csv-to-json': 'csv/to-json',
+                'json-to-csv': 'csv/from-json',
+                'codegen': `codegen/${this.codegenLangSelect.value}`,
+                'go-example': 'example/go',
+                'schema-infer': 'schema/infer',
+                'schema-validate': 'schema/validate'
+            };
+            const result = await this.callAPI(endpoints[this.currentFunction] || this.currentFunction, {
                 text: inputValue,
-                indent: parseInt(this.indentSelect.value)
+                ...this.getFormatSettings(),
+                ...(isJava ? { language: 'java' } : {}),
+                ...(this.currentFunction === 'schema-validate' ? this.getSchemaSettings() : {})
             });
             
             if (this.currentFunction === 'validate') {
@@ -98,17 +192,82 @@ class SoJSON {
                         this.showSuccess(result.message || 'JSON格式正确');
                     } else {
                         this.showError(result.error || 'JSON格式错误');
+                        if (result.line_errors && result.line_errors.length > 0) {
+                            this.setLineErrorMarkers(result.line_errors);
+                        } else {
+                            this.setErrorMarker(result.detail, result.error);
+                        }
                     }
                 } else {
                     this.showError('验证响应格式错误');
                 }
+            } else if (this.currentFunction === 'schema-validate') {
+                // 与验证功能相同的响应结构，不符合的位置逐个标记在编辑器中
+                if (result.valid) {
+                    this.showSuccess(result.message || '符合 JSON Schema');
+                } else if (result.violations && result.violations.length > 0) {
+                    this.showError(`${result.error}: ${result.violations[0].instance_path || '/'} ${result.violations[0].message}`);
+                    this.setViolationMarkers(result.violations);
+                } else {
+                    this.showError(result.error || '校验失败');
+                    this.setErrorMarker(result.detail, result.error);
+                }
+            } else if (this.currentFunction === 'extract') {
+                // 提取功能使用 ExtractResponse，未放回原文时逐个列出片段
+                if (result.success) {
+                    const fragments = result.fragments || [];
+                    this.setEditorValue(this.extractInlineCheck.checked
+                        ? result.result
+                        : fragments.map(f => f.result).join('\n\n'));
+                    this.showSuccess(`已提取 ${fragments.length} 个JSON片段`);
+                } else {
+                    this.showError(result.error || '提取失败');
+                }
             } else {
                 // 其他功能使用标准响应结构 (JSONResponse)
                 if (result.success) {
                     this.setEditorValue(result.result);
-                    this.showSuccess('处理成功');
+                    if (this.currentFunction === 'json-to-yaml') {
+                        this.setEditorLanguage('yaml');
+                    } else if (this.currentFunction === 'json-to-toml') {
+                        // Monaco 没有 TOML 语言，使用语法相近的 INI 高亮
+                        this.setEditorLanguage('ini');
+                    } else if (this.currentFunction === 'json-to-xml') {
+                        this.setEditorLanguage('xml');
+                    } else if (this.currentFunction === 'codegen') {
+                        // 目标语言与 Monaco 的语言名一致
+                        this.setEditorLanguage(this.codegenLangSelect.value);
+                    } else if (this.currentFunction === 'json-to-csv') {
+                        // Monaco 没有 CSV 语言，按纯文本显示，下载时按表格格式命名
+                        this.setEditorLanguage('plaintext');
+                        this.downloadFormat = this.csvFormatSelect.value;
+                    } else if (['yaml-to-json', 'toml-to-json', 'xml-to-json', 'csv-to-json', 'go-example'].includes(this.currentFunction)) {
+                        this.setEditorLanguage('json');
+                    }
+                    if (result.line_errors && result.line_errors.length > 0) {
+                        // 部分行失败时结果中只保留成功的行
+                        const lines = result.line_errors.map(e => e.line).join('、');
+                        this.showError(`${result.lines - result.line_errors.length}/${result.lines} 行处理成功，第 ${lines} 行失败: ${result.line_errors[0].error}`);
+                    } else if (result.language && (this.currentFunction === 'convert' || isJava)) {
+                        this.showSuccess(`已从 ${result.language} 转换为JSON`);
+                    } else if (result.notes && result.notes.length > 0) {
+                        // 锚点、标签、inf 等JSON没有对应写法的内容已展开或替换
+                        const notes = result.notes.map(n => `第 ${n.line} 行: ${n.message}`).join('；');
+                        this.showSuccess(`转换成功。${notes}`);
+                    } else if (result.documents) {
+                        this.showSuccess(`已转换 ${result.documents} 个文档`);
+                    } else if (result.fixes && result.fixes.length > 0) {
+                        this.showSuccess(`处理成功，已修复 ${result.fixes.length} 处问题`);
+                    } else {
+                        this.showSuccess('处理成功');
+                    }
                 } else {
                     this.showError(result.error || '处理失败');
+                    if (result.line_errors && result.line_errors.length > 0) {
+                        this.setLineErrorMarkers(result.line_errors);
+                    } else {
+                        this.setErrorMarker(result.detail, result.error);
+                    }
                 }
             }
         } catch (error) {
@@ -119,6 +278,127 @@ class SoJSON {
         }
     }
 
+    // 按所选方言调整编辑器的语法检查
+    updateEditorDiagnostics() {
+        if (typeof monaco === 'undefined' || !monaco.languages.json) {
+            return;
+        }
+        const dialect = this.dialectSelect.value;
+        monaco.languages.json.jsonDefaults.setDiagnosticsOptions({
+            validate: dialect !== 'json5',
+            allowComments: dialect !== 'json',
+            trailingCommas: dialect === 'json' ? 'error' : 'ignore'
+        });
+    }
+
+    // 收集格式化设置
+    getFormatSettings() {
+        const useTabs = this.indentSelect.value === 'tab';
+        const indent = useTabs ? 0 : parseInt(this.indentSelect.value);
+        const sortKeys = this.sortKeysSelect.value;
+        return {
+            indent: indent,
+            use_tabs: useTabs,
+            line_width: parseInt(this.lineWidthSelect.value),
+            sort_keys: sortKeys !== '',
+            natural_sort: sortKeys === 'natural',
+            // 勾选时使用默认策略（缩进时加空格），取消勾选则不加空格
+            space_after_colon: this.colonSpaceCheck.checked ? undefined : false,
+            trailing_newline: this.trailingNewlineCheck.checked,
+            line_ending: this.lineEndingSelect.value,
+            dialect: this.dialectSelect.value,
+            target_dialect: this.targetDialectSelect.value || undefined,
+            language: this.languageSelect.value,
+            // 仅对去除转义+格式化生效
+            mongo: this.mongoSelect.value || undefined,
+            // 提取JSON、格式转换、生成示例和按 Schema 校验只按单个文档处理
+            mode: DOCUMENT_ONLY_FUNCTIONS.includes(this.currentFunction) ? undefined : this.modeSelect.value,
+            inline: this.extractInlineCheck.checked,
+            documents: this.documentsSelect.value || undefined,
+            yaml_style: this.yamlStyleSelect.value,
+            xml_convention: this.xmlConventionSelect.value,
+            // 以逗号分隔的元素名或路径
+            force_arrays: this.forceArraysInput.value.split(',').map(s => s.trim()).filter(s => s),
+            root: this.xmlRootInput.value.trim() || undefined,
+            csv_format: this.csvFormatSelect.value,
+            array_encoding: this.arrayEncodingSelect.value,
+            pointer: this.pointerInput.value.trim() || undefined,
+            type_name: this.typeNameInput.value.trim() || undefined,
+            inline_types: this.inlineTypesCheck.checked,
+            pointer_nullable: this.pointerNullableCheck.checked,
+            // 选择 Zod 时打开 zod 开关，声明方式使用默认值
+            declaration: this.tsOutputSelect.value === 'zod' ? undefined : this.tsOutputSelect.value,
+            zod: this.tsOutputSelect.value === 'zod',
+            omit_empty: this.omitEmptyCheck.checked,
+            strict: this.schemaStrictCheck.checked,
+            // record 仅用于 Java，pydantic 仅用于 Python，其他语言使用默认风格
+            framework: { java: 'record', python: 'pydantic' }[this.codegenLangSelect.value] === this.frameworkSelect.value
+                ? this.frameworkSelect.value : undefined,
+            // 添加转义时，选择"压缩"则先压缩再转义
+            minify: this.currentFunction === 'escape' && indent < 0
+        };
+    }
+
+    // 按 JSON Schema 校验时优先使用填写的 schema，否则按注册表主题的最新版本校验
+    getSchemaSettings() {
+        const schema = this.schemaInput.value;
+        return schema.trim() ? { schema: schema } : { subject: this.schemaSubjectInput.value.trim() };
+    }
+
+    // 从注册表加载主题的最新版本
+    async loadSchema() {
+        const subject = this.schemaSubjectInput.value.trim();
+        if (!subject) {
+            this.showError('请填写注册表主题');
+            return;
+        }
+
+        try {
+            const response = await fetch(`/api/schemas/${encodeURIComponent(subject)}/versions/latest`);
+            const result = await response.json();
+            if (result.success) {
+                this.schemaInput.value = result.schema;
+                this.showSuccess(`已加载 ${result.subject} 的版本 ${result.version}`);
+            } else {
+                this.showError(result.error || '加载失败');
+            }
+        } catch (error) {
+            this.showError('网络请求失败: ' + error.message);
+        }
+    }
+
+    // 把填写的 schema 注册为主题的新版本，不兼容时列出原因
+    async registerSchema() {
+        const subject = this.schemaSubjectInput.value.trim();
+        if (!subject || !this.schemaInput.value.trim()) {
+            this.showError('请填写注册表主题和 JSON Schema');
+            return;
+        }
+
+        try {
+            const response = await fetch(`/api/schemas/${encodeURIComponent(subject)}/versions`, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ schema: this.schemaInput.value })
+            });
+            const result = await response.json();
+            if (result.success) {
+                this.showSuccess(result.message);
+            } else if (result.incompatibilities && result.incompatibilities.length > 0) {
+                const details = result.incompatibilities
+                    .map(i => `版本 ${i.version} ${i.direction} #${i.keyword_path}: ${i.message}`)
+                    .join('；');
+                this.showError(`不满足兼容性要求 ${result.compatibility}: ${details}`);
+            } else {
+                this.showError(result.error || '注册失败');
+            }
+        } catch (error) {
+            this.showError('网络请求失败: ' + error.message);
+        }
+    }
+
     async callAPI(endpoint, data) {
         const response = await fetch(`/api/${endpoint}`, {
             method: 'POST',
@@ -128,7 +408,8 @@ class SoJSON {
             body: JSON.stringify(data)
         });
         
-        if (!response.ok) {
+        // 400 响应同样携带错误信息和位置
+        if (!response.ok && response.status !== 400) {
             throw new Error(`HTTP ${response.status}: ${response.statusText}`);
         }
         
@@ -191,6 +472,78 @@ class SoJSON {
         }
     }
 
+    setEditorLanguage(language) {
+        this.editorLanguage = language;
+        if (window.monacoEditor && window.monaco) {
+            monaco.editor.setModelLanguage(window.monacoEditor.getModel(), language);
+        }
+    }
+
+    // 在编辑器中标记错误位置
+    setErrorMarker(detail, message) {
+        if (!detail || !window.monacoEditor || !window.monaco) {
+            return;
+        }
+
+        const model = window.monacoEditor.getModel();
+        monaco.editor.setModelMarkers(model, 'sojson', [{
+            startLineNumber: detail.line,
+            startColumn: detail.column,
+            endLineNumber: detail.line,
+            endColumn: detail.column + 1,
+            message: message || '',
+            severity: monaco.MarkerSeverity.Error
+        }]);
+        window.monacoEditor.revealPositionInCenter({ lineNumber: detail.line, column: detail.column });
+        window.monacoEditor.setPosition({ lineNumber: detail.line, column: detail.column });
+    }
+
+    // 按行处理时为每一个失败的行添加标记
+    setLineErrorMarkers(lineErrors) {
+        if (!window.monacoEditor || !window.monaco) {
+            return;
+        }
+
+        const markers = lineErrors.map(e => {
+            const detail = e.detail || { line: e.line, column: 1 };
+            return {
+                startLineNumber: detail.line,
+                startColumn: detail.column,
+                endLineNumber: detail.line,
+                endColumn: detail.column + 1,
+                message: e.error,
+                severity: monaco.MarkerSeverity.Error
+            };
+        });
+        monaco.editor.setModelMarkers(window.monacoEditor.getModel(), 'sojson', markers);
+        window.monacoEditor.revealLineInCenter(markers[0].startLineNumber);
+    }
+
+    // 按 JSON Schema 校验时为每一处不符合添加标记，说明中带上值的路径和 schema 中的关键字路径
+    setViolationMarkers(violations) {
+        if (!window.monacoEditor || !window.monaco) {
+            return;
+        }
+
+        const markers = violations.map(v => ({
+            startLineNumber: v.line,
+            startColumn: v.column,
+            endLineNumber: v.line,
+            endColumn: v.column + 1,
+            message: `${v.instance_path || '/'}: ${v.message}`,
+            source: `#${v.keyword_path}`,
+            severity: monaco.MarkerSeverity.Error
+        }));
+        monaco.editor.setModelMarkers(window.monacoEditor.getModel(), 'sojson', markers);
+        window.monacoEditor.revealLineInCenter(markers[0].startLineNumber);
+    }
+
+    clearErrorMarkers() {
+        if (window.monacoEditor && window.monaco) {
+            monaco.editor.setModelMarkers(window.monacoEditor.getModel(), 'sojson', []);
+        }
+    }
+
     focusEditor() {
         if (window.monacoEditor) {
             window.monacoEditor.focus();
@@ -243,8 +596,23 @@ class SoJSON {
             return;
         }
 
-        const filename = `sojson_result_${new Date().toISOString().slice(0, 19).replace(/:/g, '-')}.json`;
-        const blob = new Blob([value], { type: 'application/json' });
+        const formats = {
+            'yaml': { ext: 'yaml', type: 'application/yaml' },
+            'ini': { ext: 'toml', type: 'application/toml' },
+            'xml': { ext: 'xml', type: 'application/xml' },
+            'csv': { ext: 'csv', type: 'text/csv' },
+            'tsv': { ext: 'tsv', type: 'text/tab-separated-values' },
+            'go': { ext: 'go', type: 'text/x-go' },
+            'typescript': { ext: 'ts', type: 'text/typescript' },
+            'java': { ext: 'java', type: 'text/x-java' },
+            'kotlin': { ext: 'kt', type: 'text/x-kotlin' },
+            'rust': { ext: 'rs', type: 'text/x-rust' },
+            'python': { ext: 'py', type: 'text/x-python' }
+        };
+        const language = this.editorLanguage === 'plaintext' ? this.downloadFormat : this.editorLanguage;
+        const format = formats[language] || { ext: 'json', type: 'application/json' };
+        const filename = `sojson_result_${new Date().toISOString().slice(0, 19).replace(/:/g, '-')}.${format.ext}`;
+        const blob = new Blob([value], { type: format.type });
         const url = URL.createObjectURL(blob);
 
         const a = document.createElement('a');